@@ -0,0 +1,87 @@
+// Command gquery re-encodes queries into another dialect, one per line. The
+// queries are taken from the arguments, or from stdin, one per line, if there
+// are none.
+//
+//	gquery -to jsonpath 'users.0.name' '~jsonpointer./users/1/name'
+//	gquery -to giraffe1v1 < queries.txt
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/dialects"
+)
+
+func export(
+	w io.Writer,
+	spec string,
+	to dialects.Dialect,
+) error {
+	q, err := giraffe.GQParse(spec)
+	if err != nil {
+		return err
+	}
+
+	exported, err := q.ExportAs(to)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, exported)
+
+	return err
+}
+
+func run(
+	args []string,
+	stdin io.Reader,
+	stdout io.Writer,
+	stderr io.Writer,
+) int {
+	fs := flag.NewFlagSet("gquery", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	to := fs.String(
+		"to",
+		dialects.Giraffe1v1.String(),
+		"dialect to export as: giraffe1v1, jsonpointer or jsonpath",
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	specs := fs.Args()
+	if len(specs) == 0 {
+		sc := bufio.NewScanner(stdin)
+		for sc.Scan() {
+			if line := strings.TrimSpace(sc.Text()); line != "" {
+				specs = append(specs, line)
+			}
+		}
+
+		if err := sc.Err(); err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	code := 0
+	for _, spec := range specs {
+		if err := export(stdout, spec, dialects.Dialect(*to)); err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: %v\n", spec, err)
+			code = 1
+		}
+	}
+
+	return code
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -21,6 +21,10 @@ const (
 	BraceL Cmd = '['
 	BraceR Cmd = ']'
 
+	// Pattern
+
+	Wildcard Cmd = '*'
+
 	// Control
 
 	Dialect Cmd = '~'
@@ -37,7 +41,6 @@ func (c Cmd) Byte() byte {
 	return byte(c)
 }
 
-//nolint:unused
 var all = map[Cmd]struct{}{
 	Dialect:   {},
 	Pipe:      {},
@@ -52,4 +55,12 @@ var all = map[Cmd]struct{}{
 	Self:      {},
 	BraceL:    {},
 	BraceR:    {},
+	Wildcard:  {},
+}
+
+// IsCmd reports whether the given byte has a special meaning in a query, and
+// must be escaped to be used literally.
+func IsCmd(c byte) bool {
+	_, ok := all[Cmd(c)]
+	return ok
 }
@@ -7,28 +7,39 @@ import (
 
 //goland:noinspection SpellCheckingInspection
 const (
-	QModSelf     QFlag = 0b10000000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModOverwrit QFlag = 0b01000000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModIndeter  QFlag = 0b00100000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModeMaybe   QFlag = 0b00010000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModeMake    QFlag = 0b00001000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModAppend   QFlag = 0b00000100_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModDelete   QFlag = 0b00000010_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModArr      QFlag = 0b00000001_00000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModObj      QFlag = 0b00000000_10000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModRoot     QFlag = 0b00000000_01000000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModLeaf     QFlag = 0b00000000_00100000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModSingle   QFlag = 0b00000000_00010000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModWrite    QFlag = 0b00000000_00001000_00000000_00000000_00000000_00000000_00000000_00000000
-	QModDyn      QFlag = 0b00000000_00000100_00000000_00000000_00000000_00000000_00000000_00000000
-	QModBraces   QFlag = 0b00000000_00000010_00000000_00000000_00000000_00000000_00000000_00000000
-	QModSubQuery QFlag = 0b00000000_00000001_00000000_00000000_00000000_00000000_00000000_00000000
+	QModSelf      QFlag = 0b10000000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModOverwrit  QFlag = 0b01000000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModIndeter   QFlag = 0b00100000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModeMaybe    QFlag = 0b00010000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModeMake     QFlag = 0b00001000_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModAppend    QFlag = 0b00000100_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModDelete    QFlag = 0b00000010_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModArr       QFlag = 0b00000001_00000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModObj       QFlag = 0b00000000_10000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModRoot      QFlag = 0b00000000_01000000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModLeaf      QFlag = 0b00000000_00100000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModSingle    QFlag = 0b00000000_00010000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModWrite     QFlag = 0b00000000_00001000_00000000_00000000_00000000_00000000_00000000_00000000
+	QModDyn       QFlag = 0b00000000_00000100_00000000_00000000_00000000_00000000_00000000_00000000
+	QModBraces    QFlag = 0b00000000_00000010_00000000_00000000_00000000_00000000_00000000_00000000
+	QModSubQuery  QFlag = 0b00000000_00000001_00000000_00000000_00000000_00000000_00000000_00000000
+	QModWildcard  QFlag = 0b00000000_00000000_00000000_10000000_00000000_00000000_00000000_00000000
+	QModDescent   QFlag = 0b00000000_00000000_00000000_01000000_00000000_00000000_00000000_00000000
+	QModSlice     QFlag = 0b00000000_00000000_00000000_00100000_00000000_00000000_00000000_00000000
+	QModNegative  QFlag = 0b00000000_00000000_00000000_00010000_00000000_00000000_00000000_00000000
+	QModPattern   QFlag = 0b00000000_00000000_00000000_00001000_00000000_00000000_00000000_00000000
+	QModPredicate QFlag = 0b00000000_00000000_00000000_00000100_00000000_00000000_00000000_00000000
 )
 
+// The bits of a QFlag, from the least significant: 0-31 are the value (the
+// index of an array segment), 32-33 are unused, 34-39 are the pattern mods
+// QModPredicate(34), QModPattern(35), QModNegative(36), QModSlice(37),
+// QModDescent(38) and QModWildcard(39), 40-47 are the sequence (see SeqShift)
+// and 48-63 are the other mods.
 const (
-	ValueMask    QFlag = 0b00000000_00000000_00000000_11111111_11111111_11111111_11111111_11111111
+	ValueMask    QFlag = 0b00000000_00000000_00000000_00000000_11111111_11111111_11111111_11111111
 	SequenceMask QFlag = 0b00000000_00000000_11111111_00000000_00000000_00000000_00000000_00000000
-	ModMask      QFlag = 0b11111111_11111111_00000000_00000000_00000000_00000000_00000000_00000000
+	ModMask      QFlag = 0b11111111_11111111_00000000_11111111_00000000_00000000_00000000_00000000
 
 	Zero QFlag = 0b0
 
@@ -161,6 +172,33 @@ func (f QFlag) IsBraces() bool {
 	return f&QModBraces != 0
 }
 
+func (f QFlag) IsWildcard() bool {
+	return f&QModWildcard != 0
+}
+
+func (f QFlag) IsDescent() bool {
+	return f&QModDescent != 0
+}
+
+func (f QFlag) IsSlice() bool {
+	return f&QModSlice != 0
+}
+
+func (f QFlag) IsNegative() bool {
+	return f&QModNegative != 0
+}
+
+func (f QFlag) IsPredicate() bool {
+	return f&QModPredicate != 0
+}
+
+// IsPattern reports whether any segment of the query is a wildcard, a
+// recursive descent, a slice, a predicate or a negative index, i.e., whether
+// the query is only made concrete against a datum.
+func (f QFlag) IsPattern() bool {
+	return f&QModPattern != 0
+}
+
 func (f QFlag) IsReadonly() bool {
 	return !f.IsWrite()
 }
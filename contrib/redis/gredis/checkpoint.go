@@ -0,0 +1,78 @@
+package gredis
+
+import (
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/serdes/converters"
+	"github.com/hkoosha/giraffe/core/t11y"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	"github.com/hkoosha/giraffe/hippo"
+	"github.com/hkoosha/giraffe/zebra/zcache"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewCheckpointStore keeps the checkpoints of hippo runs in rds, under the
+// namespace of cfg and for its ttl, in the binary encoding of giraffe, see
+// NewForDatum.
+func NewCheckpointStore(
+	cfg *Config,
+	rds *redis.Client,
+) hippo.CheckpointStore {
+	t11y.NonNil(cfg, rds)
+	cfg.Ensure()
+
+	return &checkpointStore{
+		cache: &adapter[string, giraffe.Datum]{
+			cfg:      cfg,
+			keySerde: converters.String(),
+			valSerde: datumConv{},
+			rds:      rds,
+		},
+	}
+}
+
+type checkpointStore struct {
+	cache zcache.Adapter[string, giraffe.Datum]
+}
+
+func (c *checkpointStore) Save(
+	ctx gtx.Context,
+	cp *hippo.Checkpoint,
+) error {
+	if err := hippo.ValidateRunID(cp.RunID); err != nil {
+		return err
+	}
+
+	dat, err := cp.Datum()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.cache.Set(ctx, cp.RunID, dat)
+
+	return err
+}
+
+func (c *checkpointStore) Load(
+	ctx gtx.Context,
+	runID string,
+) (*hippo.Checkpoint, error) {
+	item, outcome, err := c.cache.Get(ctx, runID)
+	switch {
+	case err != nil:
+		return nil, err
+
+	case outcome == zcache.Miss:
+		return nil, nil
+	}
+
+	return hippo.CheckpointOf(item.Value)
+}
+
+func (c *checkpointStore) Delete(
+	ctx gtx.Context,
+	runID string,
+) error {
+	_, err := c.cache.Unset(ctx, runID)
+
+	return err
+}
@@ -1,6 +1,7 @@
 package gredis
 
 import (
+	"slices"
 	"strings"
 	"time"
 
@@ -29,9 +30,10 @@ func NewConfig(
 	namespace string,
 	ttl time.Duration,
 ) *Config {
-	return zero.
-		WithTTL(ttl).
-		Namespaced(namespace)
+	cp := *zero
+	cp.ttl = ttl
+
+	return cp.Namespaced(namespace)
 }
 
 type Config struct {
@@ -70,7 +72,7 @@ func (c *Config) TTL() time.Duration {
 func (c *Config) WithTTL(
 	ttl time.Duration,
 ) *Config {
-	cp := &*c
+	cp := *c
 	cp.ttl = ttl
 	return cp.Ensure()
 }
@@ -82,7 +84,7 @@ func (c *Config) Timeout() time.Duration {
 func (c *Config) WithTimeout(
 	timeout time.Duration,
 ) *Config {
-	cp := &*c
+	cp := *c
 	cp.timeout = timeout
 	return cp.Ensure()
 }
@@ -90,8 +92,8 @@ func (c *Config) WithTimeout(
 func (c *Config) Namespaced(
 	namespace string,
 ) *Config {
-	cp := &*c
-	cp.nsParts = append(cp.nsParts, namespace)
+	cp := *c
+	cp.nsParts = append(slices.Clip(cp.nsParts), namespace)
 	cp.namespace = strings.Join(cp.nsParts, namespaceSep) + namespaceKeySep
 	return cp.Ensure()
 }
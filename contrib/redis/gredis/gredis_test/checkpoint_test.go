@@ -0,0 +1,291 @@
+package gredis_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/contrib/redis/gredis"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+// fakeRedis serves GET, SET and DEL of RESP2 off a map, refusing any other
+// command the way a server not knowing it does.
+type fakeRedis struct {
+	keys map[string]string
+	mu   sync.Mutex
+}
+
+func serveFakeRedis(
+	t *testing.T,
+) (*fakeRedis, *redis.Client) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	fake := &fakeRedis{
+		keys: make(map[string]string),
+	}
+
+	go func() {
+		for {
+			conn, aErr := ln.Accept()
+			if aErr != nil {
+				return
+			}
+
+			go fake.serve(conn)
+		}
+	}()
+
+	rds := redis.NewClient(&redis.Options{
+		Addr:            ln.Addr().String(),
+		DisableIdentity: true,
+	})
+
+	t.Cleanup(func() {
+		_ = rds.Close()
+		_ = ln.Close()
+	})
+
+	return fake, rds
+}
+
+func (f *fakeRedis) serve(
+	conn net.Conn,
+) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		if _, err = io.WriteString(conn, f.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) exec(
+	args []string,
+) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		v, ok := f.keys[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+
+		return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+
+	case "SET":
+		f.keys[args[1]] = args[2]
+
+		return "+OK\r\n"
+
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := f.keys[k]; ok {
+				delete(f.keys, k)
+				n++
+			}
+		}
+
+		return ":" + strconv.Itoa(n) + "\r\n"
+
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+func (f *fakeRedis) stored() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.keys))
+	for k := range f.keys {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func readCommand(
+	r *bufio.Reader,
+) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(line, "*") {
+		return nil, errors.New("not an array: " + line)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		size, sErr := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if sErr != nil {
+			return nil, sErr
+		}
+
+		b := make([]byte, size+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+
+		args[i] = string(b[:size])
+	}
+
+	return args, nil
+}
+
+func TestCheckpointStore(t *testing.T) {
+	cfg := gredis.NewConfig("giraffe", time.Minute)
+
+	t.Run("config", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		nested := cfg.Namespaced("hippo").WithTTL(time.Hour)
+		assert.Equal(t, "giraffe_hippo:", nested.KeyPrefix())
+		assert.Equal(t, time.Hour, nested.TTL())
+
+		assert.Equal(t, "giraffe:", cfg.KeyPrefix())
+		assert.Equal(t, time.Minute, cfg.TTL())
+		assert.Equal(t, "other:", gredis.NewConfig("other", time.Minute).KeyPrefix())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		fake, rds := serveFakeRedis(t)
+		store := gredis.NewCheckpointStore(cfg, rds)
+		ctx := gtx.Of(t.Context())
+
+		dat := M(giraffe.OfN(
+			giraffe.TupleOf(Q("n"), 7),
+			giraffe.TupleOf(Q("f"), M(giraffe.From(1.5))),
+			giraffe.TupleOf(Q("s"), "x"),
+		))
+		steps := M(giraffe.From([]any{
+			map[string]any{"name": "init"},
+			map[string]any{"name": "a"},
+		}))
+
+		require.NoError(t, store.Save(ctx, &hippo.Checkpoint{
+			Dat:   dat,
+			Steps: steps,
+			RunID: "run-1",
+			Done:  1,
+		}))
+		assert.Equal(t, []string{"giraffe:run-1"}, fake.stored())
+
+		c, err := store.Load(ctx, "run-1")
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		assert.Equal(t, "run-1", c.RunID)
+		assert.Equal(t, 1, c.Done)
+		assert.True(t, dat.Eq(c.Dat))
+		assert.True(t, steps.Eq(c.Steps))
+
+		require.NoError(t, store.Delete(ctx, "run-1"))
+		assert.Empty(t, fake.stored())
+
+		c, err = store.Load(ctx, "run-1")
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, rds := serveFakeRedis(t)
+		store := gredis.NewCheckpointStore(cfg, rds)
+
+		c, err := store.Load(gtx.Of(t.Context()), "run")
+		require.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("invalid run id", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		fake, rds := serveFakeRedis(t)
+		store := gredis.NewCheckpointStore(cfg, rds)
+
+		err := store.Save(gtx.Of(t.Context()), &hippo.Checkpoint{
+			Dat:   giraffe.OfEmpty(),
+			Steps: M(giraffe.From([]any{})),
+			RunID: "../x",
+			Done:  0,
+		})
+		require.ErrorContains(t, err, "invalid run id: ../x")
+		assert.Empty(t, fake.stored())
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, rds := serveFakeRedis(t)
+		store := gredis.NewCheckpointStore(cfg, rds)
+
+		failed := false
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", hippo.Static(giraffe.Of1(Q("a"), 1))).
+			MustWithNext("b", hippo.FnOf(func(
+				gtx.Context,
+				hippo.Call,
+			) (giraffe.Datum, error) {
+				if !failed {
+					failed = true
+
+					return giraffe.OfErr(), EF("failing on purpose")
+				}
+
+				return giraffe.Of1(Q("b"), 2), nil
+			}).WithOutput(Q("b")))
+
+		pipeline := M(hippo.MkPipeline(plan)).WithCheckpoints(store)
+
+		_, err := pipeline.EkranRun(gtx.Of(t.Context()), "run", giraffe.OfEmpty())
+		require.ErrorContains(t, err, "failing on purpose")
+
+		state, err := pipeline.Resume(gtx.Of(t.Context()), "run")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"a": 1, "b": 2}`, string(M(M(state.Get(Q("fin"))).MarshalJSON())))
+	})
+}
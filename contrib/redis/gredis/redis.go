@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/hkoosha/giraffe"
 	"github.com/hkoosha/giraffe/core/serdes/converters"
 	"github.com/hkoosha/giraffe/core/t11y"
 	"github.com/hkoosha/giraffe/zebra/zcache"
@@ -72,6 +73,35 @@ func NewForJson[V any](
 	)
 }
 
+// NewForDatum stores the values in the binary encoding of giraffe, keeping
+// their types exactly, see giraffe.DatumBinarySerde.
+func NewForDatum(
+	cfg *Config,
+) zcache.Adapter[string, giraffe.Datum] {
+	return New(
+		cfg,
+		converters.String(),
+		datumConv{},
+	)
+}
+
+// =============================================================================.
+
+type datumConv struct{}
+
+func (datumConv) Write(d giraffe.Datum) (string, error) {
+	b, err := giraffe.DatumBinarySerde().Write(d)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func (datumConv) Read(s string) (giraffe.Datum, error) {
+	return giraffe.DatumBinarySerde().Read([]byte(s))
+}
+
 // =============================================================================.
 
 var _ zcache.Adapter[string, any] = (*adapter[string, any])(nil)
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"iter"
-	"maps"
 	"math"
 	"math/big"
 	"slices"
@@ -198,7 +197,7 @@ func (d Datum) Pretty() string {
 		return ""
 	}
 
-	r, err := d.Raw()
+	r, err := d.jsonRaw()
 	if err != nil {
 		return getDataErrRepr()
 	}
@@ -220,7 +219,7 @@ func (d Datum) Plain() (any, error) {
 }
 
 func (d Datum) MarshalJSON() ([]byte, error) {
-	n, err := d.raw()
+	n, err := d.jsonRaw()
 	if err != nil {
 		return nil, err
 	}
@@ -229,11 +228,11 @@ func (d Datum) MarshalJSON() ([]byte, error) {
 }
 
 func (d Datum) MarshalJsonString() string {
-	return string(M(gson.Marshal(M(d.raw()))))
+	return string(M(gson.Marshal(M(d.jsonRaw()))))
 }
 
 func (d Datum) MarshalJSONTo(w io.Writer) error {
-	n, err := d.raw()
+	n, err := d.jsonRaw()
 	if err != nil {
 		return err
 	}
@@ -295,9 +294,63 @@ func (d Datum) Lte(other Datum) (bool, error) {
 func (d Datum) Merge(
 	right ...Datum,
 ) (Datum, error) {
-	return d.merge(right)
+	return d.merge(MergingOf(), right)
 }
 
+func (d Datum) MergeWith(
+	m Merging,
+	right ...Datum,
+) (Datum, error) {
+	return d.merge(m, right)
+}
+
+// Diff lists the changes turning d into other, in a deterministic order.
+func (d Datum) Diff(
+	other Datum,
+) ([]Change, error) {
+	deltas, err := d.diff(other)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, len(deltas))
+	for i, it := range deltas {
+		changes[i] = it.change
+	}
+
+	return changes, nil
+}
+
+// PatchTo produces the RFC 6902 JSON Patch turning d into other.
+func (d Datum) PatchTo(
+	other Datum,
+) (JsonPatch, error) {
+	return d.patchTo(other)
+}
+
+// Patched applies an RFC 6902 JSON Patch, either fully or not at all.
+func (d Datum) Patched(
+	patch JsonPatch,
+) (Datum, error) {
+	return d.patched(patch)
+}
+
+// MergePatchTo produces the RFC 7396 JSON Merge Patch turning d into other.
+func (d Datum) MergePatchTo(
+	other Datum,
+) (Datum, error) {
+	return d.mergePatchTo(other)
+}
+
+// MergePatched applies an RFC 7396 JSON Merge Patch.
+func (d Datum) MergePatched(
+	patch Datum,
+) (Datum, error) {
+	return d.mergePatched(patch)
+}
+
+// Set sets value at query, on a copy of d. A query holding patterns, as in
+// `items[?status=="active"].=seen`, sets value at every path it matches.
 func (d Datum) Set(
 	query Query,
 	value any,
@@ -326,6 +379,24 @@ func (d Datum) Get(
 	return d.get(q.impl())
 }
 
+// GetAll is every datum q matches, along with its concrete query. Unlike Get,
+// q may hold patterns: "*" for every child, "**" for any depth, "[2:5]" for a
+// slice, "-1" for the last element and `[?status=="active"]` for the children
+// satisfying a predicate. Missing paths match nothing.
+//
+// A predicate compares a query relative to each child, "#" being the child
+// itself, using one of ==, !=, <, <=, > or >= against a JSON literal. Numbers
+// compare by value and strings lexically, and values not comparable do not
+// satisfy it. A bare query, as in "[?id]", tests for existence.
+//
+//	d.GetAll(Q("items.*.id"))
+//	d.GetAll(Q(`items[?price >= 10].id`))
+func (d Datum) GetAll(
+	q Query,
+) (iter.Seq2[Query, Datum], error) {
+	return d.getAll(q.impl())
+}
+
 func (d Datum) Iter() (iter.Seq[Datum], error) {
 	val, err := d.tryArr()
 	if err != nil {
@@ -343,8 +414,10 @@ func (d Datum) Iter2() (iter.Seq2[string, Datum], error) {
 	}
 
 	return func(yield func(string, Datum) bool) {
-		for k, v := range val {
-			yield(k, v)
+		for _, k := range d.keysInOrder() {
+			if !yield(k, val[k]) {
+				return
+			}
 		}
 	}, nil
 }
@@ -373,12 +446,11 @@ func (d Datum) Tree() []Query {
 }
 
 func (d Datum) Keys() ([]string, error) {
-	val, err := d.tryObj()
-	if err != nil {
+	if _, err := d.tryObj(); err != nil {
 		return nil, err
 	}
 
-	return slices.Collect(maps.Keys(val)), nil
+	return d.keysInOrder(), nil
 }
 
 func (d Datum) Kv() (map[string]string, error) {
@@ -441,7 +513,7 @@ func (d Datum) Flt() (*big.Float, error) {
 		return nil, newTypeCastError(d.typ, Flt)
 
 	default:
-		cp := big.NewFloat(0)
+		cp := new(big.Float)
 		cp.Set(cast[*big.Float](d))
 
 		return cp, nil
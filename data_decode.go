@@ -0,0 +1,80 @@
+package giraffe
+
+import (
+	"slices"
+	"strings"
+)
+
+// Decode reads d into a new T, binding the fields of structs by reflection,
+// with no round trip through JSON.
+//
+// A struct field is read off the query in its giraffe tag, relative to the
+// struct, so that a flat struct binds to a nested datum. A field with no tag
+// is read off the name in its json tag, or its own name, and embedded structs
+// with no tag are flattened. The options follow the query, comma separated:
+//
+//	type User struct {
+//		Name  string            `giraffe:"profile.name"`
+//		Port  uint16            `giraffe:"net.ports.0"`
+//		Email *string           `giraffe:"profile.email"`
+//		Tags  []string          `giraffe:"tags,optional"`
+//		Meta  map[string]Datum  `giraffe:"meta"`
+//		Skip  int               `giraffe:"-"`
+//	}
+//
+// Missing and null values are an error, unless the field is a pointer, left
+// nil, or is optional, left as is. Values are converted by the rules of the
+// accessors: an int field reads as Datum.ISz does and a uint32 as Datum.U32
+// does, failing on overflow, and so on. Float fields also accept ints.
+//
+// Decode does not stop at the first failure, the error is a *DecodeError
+// listing every failing path.
+func Decode[T any](
+	d Datum,
+) (T, error) {
+	var t T
+	if err := decode(d, &t); err != nil {
+		return t, err
+	}
+
+	return t, nil
+}
+
+// Encode makes a Datum of v, writing the fields of structs to the queries in
+// their tags, as Decode reads them. Fields with the omitempty option are left
+// out when zero.
+func Encode[T any](
+	v T,
+) (Datum, error) {
+	return encode(v)
+}
+
+// =====================================.
+
+// DecodeError lists every path Decode failed on, with the reason, as Violations.
+type DecodeError struct {
+	violations []Violation
+}
+
+func (e *DecodeError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString("decode failures: [")
+
+	for i, v := range e.violations {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(v.String())
+	}
+	sb.WriteByte(']')
+
+	return sb.String()
+}
+
+func (e *DecodeError) Code() uint64 {
+	return ErrCodeDataDecodeFailed
+}
+
+func (e *DecodeError) Violations() []Violation {
+	return slices.Clone(e.violations)
+}
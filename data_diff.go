@@ -0,0 +1,162 @@
+package giraffe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type ChangeKind uint8
+
+const (
+	ChangeAdded ChangeKind = iota + 1
+	ChangeRemoved
+	ChangeChanged
+)
+
+func (c ChangeKind) String() string {
+	switch c {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeChanged:
+		return "changed"
+	default:
+		return "err@" + strconv.Itoa(int(c))
+	}
+}
+
+// Change is a single difference reported by Datum.Diff. Old is OfErr() for
+// added paths, and New is OfErr() for removed paths. The query is GQErr()
+// when the roots themselves differ.
+type Change struct {
+	Query Query
+	Old   Datum
+	New   Datum
+	Kind  ChangeKind
+}
+
+func (c Change) String() string {
+	q := "<root>"
+	if c.Query != GQErr() {
+		q = c.Query.String()
+	}
+
+	return fmt.Sprintf("Change[%s, %s]", c.Kind.String(), q)
+}
+
+// =====================================.
+
+type PatchOpKind string
+
+const (
+	PatchAdd     PatchOpKind = "add"
+	PatchRemove  PatchOpKind = "remove"
+	PatchReplace PatchOpKind = "replace"
+	PatchMove    PatchOpKind = "move"
+	PatchCopy    PatchOpKind = "copy"
+	PatchTest    PatchOpKind = "test"
+)
+
+func (k PatchOpKind) String() string {
+	return string(k)
+}
+
+func (k PatchOpKind) hasValue() bool {
+	return k == PatchAdd || k == PatchReplace || k == PatchTest
+}
+
+func (k PatchOpKind) hasFrom() bool {
+	return k == PatchMove || k == PatchCopy
+}
+
+// JsonPatch is an RFC 6902 JSON Patch document.
+type JsonPatch []PatchOp
+
+// PatchOp is a single operation of a JsonPatch. Path and From are RFC 6901
+// JSON Pointers.
+type PatchOp struct {
+	Value Datum
+	Op    PatchOpKind
+	Path  string
+	From  string
+}
+
+func (p PatchOp) String() string {
+	if p.Op.hasFrom() {
+		return fmt.Sprintf("PatchOp[%s, %s, %s]", p.Op, p.From, p.Path)
+	}
+
+	return fmt.Sprintf("PatchOp[%s, %s]", p.Op, p.Path)
+}
+
+func (p PatchOp) MarshalJSON() ([]byte, error) {
+	op := map[string]any{
+		"op":   p.Op,
+		"path": p.Path,
+	}
+
+	if p.Op.hasFrom() {
+		op["from"] = p.From
+	}
+
+	if p.Op.hasValue() {
+		op["value"] = p.Value
+	}
+
+	return json.Marshal(op)
+}
+
+func (p *PatchOp) UnmarshalJSON(b []byte) error {
+	if p == nil {
+		return newNilError()
+	}
+
+	var op struct {
+		Value json.RawMessage `json:"value"`
+		From  *string         `json:"from"`
+		Op    PatchOpKind     `json:"op"`
+		Path  string          `json:"path"`
+	}
+
+	if err := json.Unmarshal(b, &op); err != nil {
+		return newPatchInvalidError(err.Error())
+	}
+
+	switch op.Op {
+	case PatchAdd, PatchRemove, PatchReplace, PatchMove, PatchCopy, PatchTest:
+	default:
+		return newPatchInvalidError("unknown op: " + op.Op.String())
+	}
+
+	value := OfErr()
+	switch {
+	case op.Op.hasValue() && len(op.Value) == 0:
+		return newPatchInvalidError("missing value: " + op.Op.String())
+
+	case op.Op.hasValue():
+		var err error
+		if value, err = ofJson(op.Value); err != nil {
+			return err
+		}
+	}
+
+	from := ""
+	switch {
+	case op.Op.hasFrom() && op.From == nil:
+		return newPatchInvalidError("missing from: " + op.Op.String())
+
+	case op.Op.hasFrom():
+		from = *op.From
+	}
+
+	*p = PatchOp{
+		Value: value,
+		Op:    op.Op,
+		Path:  op.Path,
+		From:  from,
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package giraffe
+
+// Tx is a batch of writes to a single copy of a Datum, made by Datum.Edit.
+// Reads see the writes made so far. Once a write fails, the transaction has
+// failed, and so does every write after it.
+type Tx struct {
+	d   Datum
+	err error
+}
+
+// Edit runs fn against a copy of d, returning the copy with all the writes fn
+// made, or none of them if either fn or any of its writes fails. Unlike a
+// chain of Datum.Set calls, d is copied once, no matter the writes.
+//
+//	d, err = d.Edit(func(tx *giraffe.Tx) error {
+//		_ = tx.Set(Q("user.=name"), "ann")
+//		_ = tx.Append(Q("user.tags"), "new")
+//		return tx.Delete(Q("user.legacy"))
+//	})
+func (d Datum) Edit(
+	fn func(tx *Tx) error,
+) (Datum, error) {
+	return d.edit(fn)
+}
+
+// Set sets value at query, as Datum.Set does, with the modifiers the query
+// holds: "+" appends, "!" deletes, "$" makes the path and "=" overwrites.
+func (tx *Tx) Set(
+	query Query,
+	value any,
+) error {
+	return tx.set(query.impl(), nil, value)
+}
+
+// Append appends value to the array at query.
+func (tx *Tx) Append(
+	query Query,
+	value any,
+) error {
+	q, err := txAppending(query.impl())
+
+	return tx.set(q, err, value)
+}
+
+// Make sets value at query, making the missing path on the way.
+func (tx *Tx) Make(
+	query Query,
+	value any,
+) error {
+	return tx.set(query.WithMake().impl(), nil, value)
+}
+
+// Delete deletes what is at query.
+func (tx *Tx) Delete(
+	query Query,
+) error {
+	q, err := txDeleting(query.impl())
+
+	return tx.set(q, err, nil)
+}
+
+// Get reads query off the datum being edited, as Datum.Get does.
+func (tx *Tx) Get(
+	query Query,
+) (Datum, error) {
+	if tx.err != nil {
+		return errD, tx.err
+	}
+
+	return tx.d.get(query.impl())
+}
+
+// Has reports whether query is set on the datum being edited.
+func (tx *Tx) Has(
+	query Query,
+) (bool, error) {
+	if tx.err != nil {
+		return false, tx.err
+	}
+
+	return tx.d.has(query.impl())
+}
@@ -0,0 +1,268 @@
+package giraffe
+
+import (
+	"strconv"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// MergeResolver decides the value of a conflicting path during a merge. The
+// path is absolute, from the root of the merged datums, and is GQErr() when
+// the roots themselves conflict.
+type MergeResolver = func(
+	path Query,
+	left Datum,
+	right Datum,
+) (Datum, error)
+
+// MergePolicy selects what happens when both sides of a merge have different
+// values (or types) at the same path.
+type MergePolicy uint8
+
+const (
+	// MergePolicyError fails the merge on conflict.
+	MergePolicyError MergePolicy = iota
+
+	// MergePolicyLeftWins keeps the value already present.
+	MergePolicyLeftWins
+
+	// MergePolicyRightWins replaces the value already present.
+	MergePolicyRightWins
+
+	// MergePolicyCollect keeps both values, as a two element array.
+	MergePolicyCollect
+
+	// MergePolicyResolve delegates to a MergeResolver, see
+	// Merging.WithResolver.
+	MergePolicyResolve
+)
+
+func (p MergePolicy) String() string {
+	switch p {
+	case MergePolicyError:
+		return "error"
+	case MergePolicyLeftWins:
+		return "left"
+	case MergePolicyRightWins:
+		return "right"
+	case MergePolicyCollect:
+		return "collect"
+	case MergePolicyResolve:
+		return "resolve"
+	default:
+		return "err@" + strconv.Itoa(int(p))
+	}
+}
+
+func (p MergePolicy) MarshalText() ([]byte, error) {
+	if p > MergePolicyResolve {
+		return nil, newMergeUnknownPolicyError(p.String())
+	}
+
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText does not accept MergePolicyResolve, as the resolver itself
+// can not be decoded.
+func (p *MergePolicy) UnmarshalText(b []byte) error {
+	if p == nil {
+		return newNilError()
+	}
+
+	for _, it := range []MergePolicy{
+		MergePolicyError,
+		MergePolicyLeftWins,
+		MergePolicyRightWins,
+		MergePolicyCollect,
+	} {
+		if it.String() == string(b) {
+			*p = it
+
+			return nil
+		}
+	}
+
+	return newMergeUnknownPolicyError(string(b))
+}
+
+// ArrMerge selects how two arrays found under the same path are merged.
+type ArrMerge uint8
+
+const (
+	// ArrMergeIndex merges the elements position by position, recursively.
+	// The excess elements of the longer array are kept as is.
+	ArrMergeIndex ArrMerge = iota
+
+	// ArrMergeConcat appends the elements of the right array to the left.
+	ArrMergeConcat
+
+	// ArrMergeUnion keeps each distinct element (by Datum.Eq) once, in the
+	// order they are first seen.
+	ArrMergeUnion
+
+	// ArrMergeKeyed matches object elements on an identity field and merges
+	// the matched pairs recursively. Unmatched right elements are appended.
+	ArrMergeKeyed
+)
+
+func (a ArrMerge) String() string {
+	switch a {
+	case ArrMergeIndex:
+		return "index"
+	case ArrMergeConcat:
+		return "concat"
+	case ArrMergeUnion:
+		return "union"
+	case ArrMergeKeyed:
+		return "keyed"
+	default:
+		return "err@" + strconv.Itoa(int(a))
+	}
+}
+
+func (a ArrMerge) MarshalText() ([]byte, error) {
+	if a > ArrMergeKeyed {
+		return nil, newMergeUnknownPolicyError(a.String())
+	}
+
+	return []byte(a.String()), nil
+}
+
+func (a *ArrMerge) UnmarshalText(b []byte) error {
+	if a == nil {
+		return newNilError()
+	}
+
+	for _, it := range []ArrMerge{
+		ArrMergeIndex,
+		ArrMergeConcat,
+		ArrMergeUnion,
+		ArrMergeKeyed,
+	} {
+		if it.String() == string(b) {
+			*a = it
+
+			return nil
+		}
+	}
+
+	return newMergeUnknownPolicyError(string(b))
+}
+
+// =====================================.
+
+func MergingOf() Merging {
+	return Merging{
+		resolver: nil,
+		key:      nil,
+		arr:      ArrMergeIndex,
+		policy:   MergePolicyError,
+	}
+}
+
+// Merging configures Datum.MergeWith. The zero value is the default used by
+// Datum.Merge.
+type Merging struct {
+	resolver MergeResolver
+	key      *Query
+	arr      ArrMerge
+	policy   MergePolicy
+}
+
+func (m Merging) String() string {
+	arr := m.arr.String()
+	if m.key != nil {
+		arr += "=" + m.key.String()
+	}
+
+	return "Merging[" + arr + ", " + m.policy.String() + "]"
+}
+
+func (m Merging) Policy() MergePolicy {
+	return m.policy
+}
+
+func (m Merging) WithPolicy(
+	policy MergePolicy,
+) Merging {
+	if policy == MergePolicyResolve {
+		panic(EF("resolve policy needs a resolver, use WithResolver"))
+	}
+
+	m.policy = policy
+	m.resolver = nil
+
+	return m
+}
+
+func (m Merging) WithLeftWins() Merging {
+	return m.WithPolicy(MergePolicyLeftWins)
+}
+
+func (m Merging) WithRightWins() Merging {
+	return m.WithPolicy(MergePolicyRightWins)
+}
+
+func (m Merging) WithCollect() Merging {
+	return m.WithPolicy(MergePolicyCollect)
+}
+
+func (m Merging) WithoutConflicts() Merging {
+	return m.WithPolicy(MergePolicyError)
+}
+
+func (m Merging) WithResolver(
+	resolver MergeResolver,
+) Merging {
+	if resolver == nil {
+		panic(EF("nil merge resolver"))
+	}
+
+	m.policy = MergePolicyResolve
+	m.resolver = resolver
+
+	return m
+}
+
+func (m Merging) Arr() ArrMerge {
+	return m.arr
+}
+
+// Key is the identity field used by ArrMergeKeyed.
+func (m Merging) Key() (Query, bool) {
+	if m.key == nil {
+		return GQErr(), false
+	}
+
+	return *m.key, true
+}
+
+func (m Merging) WithKeyed(
+	key Query,
+) Merging {
+	m.arr = ArrMergeKeyed
+	m.key = &key
+
+	return m
+}
+
+func (m Merging) WithConcat() Merging {
+	m.arr = ArrMergeConcat
+	m.key = nil
+
+	return m
+}
+
+func (m Merging) WithUnion() Merging {
+	m.arr = ArrMergeUnion
+	m.key = nil
+
+	return m
+}
+
+func (m Merging) WithIndex() Merging {
+	m.arr = ArrMergeIndex
+	m.key = nil
+
+	return m
+}
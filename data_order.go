@@ -0,0 +1,45 @@
+package giraffe
+
+import (
+	"sync/atomic"
+)
+
+var orderedKeys atomic.Bool
+
+// IsOrderedKeys reports whether the objects made from now on keep the
+// insertion order of their keys.
+func IsOrderedKeys() bool {
+	return orderedKeys.Load()
+}
+
+// EnableOrderedKeys makes every object made from now on, by parsing, Set,
+// Merge or Nest, keep the insertion order of its keys. The already existing
+// datums are not affected, see Datum.Ordered for a per datum opt-in.
+func EnableOrderedKeys() {
+	orderedKeys.Store(true)
+}
+
+func DisableOrderedKeys() {
+	orderedKeys.Store(false)
+}
+
+// =====================================.
+
+// Ordered returns a copy of d in which every object keeps the insertion order
+// of its keys. The objects which are not ordered yet start with their keys
+// sorted.
+func (d Datum) Ordered() Datum {
+	return d.ordered(true)
+}
+
+// Unordered returns a copy of d with every object as a plain map, in which
+// case the keys are always listed sorted.
+func (d Datum) Unordered() Datum {
+	return d.ordered(false)
+}
+
+// IsOrdered reports whether d is an object keeping the insertion order of its
+// keys. It does not look at the nested objects.
+func (d Datum) IsOrdered() bool {
+	return d.isOrdered()
+}
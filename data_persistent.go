@@ -0,0 +1,27 @@
+package giraffe
+
+// Persistent returns d with every object and array in it persistent, backed
+// by a hash array mapped trie and a vector trie respectively. Writing a
+// persistent datum, by Set, Merge, Edit or appending, copies only the path
+// written and shares every other subtree with the original, making the writes
+// on large datums cheap. Reads are as they are on any other datum, though the
+// keys of a persistent object are listed sorted, as with Unordered.
+//
+//	base := d.Persistent()
+//	next, err := base.Set(Q("user.name"), "koosha") // Shares all but user.
+func (d Datum) Persistent() Datum {
+	return d.persistent(true)
+}
+
+// Materialized returns a copy of d with every object and array in it a plain
+// map and slice, undoing Persistent. Ordered objects stay ordered.
+func (d Datum) Materialized() Datum {
+	return d.persistent(false)
+}
+
+// IsPersistent reports whether d is a persistent object or array. It does not
+// look at the nested ones, as the objects and arrays made by writing a
+// persistent datum are persistent, while those set as is keep what they are.
+func (d Datum) IsPersistent() bool {
+	return d.isPersistent()
+}
@@ -0,0 +1,61 @@
+package giraffe
+
+import (
+	"sync"
+)
+
+// PipeFn is a transform usable as a stage of Datum.Pipe.
+type PipeFn = func(Datum) (Datum, error)
+
+var pipeFns = struct {
+	sync.RWMutex
+	m map[string]PipeFn
+}{
+	RWMutex: sync.RWMutex{},
+	m: map[string]PipeFn{
+		"keys":    pipeKeys,
+		"len":     pipeLen,
+		"values":  pipeValues,
+		"flatten": pipeFlatten,
+	},
+}
+
+// RegisterPipeFn makes fn usable as a stage of Datum.Pipe, under the given
+// name. Names are identifiers, registered once.
+//
+// The builtin transforms are:
+//   - keys: the keys of an object, or the indices of an array.
+//   - len: the length of an object, array or string, zero for null.
+//   - values: the values of an object in order of its keys, or an array as is.
+//   - flatten: an array with its nested arrays flattened, recursively.
+func RegisterPipeFn(
+	name string,
+	fn PipeFn,
+) error {
+	if err := pipeFnNameValid(name); err != nil {
+		return err
+	}
+
+	pipeFns.Lock()
+	defer pipeFns.Unlock()
+
+	if _, ok := pipeFns.m[name]; ok {
+		return newPipeFnInvalidError(name, "already registered")
+	}
+
+	pipeFns.m[name] = fn
+
+	return nil
+}
+
+// Pipe runs the stages of spec, separated by "|", each against the result of
+// the previous one, starting from d. A stage is either the name of a
+// registered PipeFn, or a query, where "#" is the datum at hand. A query
+// clashing with a PipeFn name is written escaped, as in "\keys" or "#.keys".
+//
+//	d.Pipe("users | 0 | keys | len")
+func (d Datum) Pipe(
+	spec string,
+) (Datum, error) {
+	return d.pipe(spec)
+}
@@ -9,13 +9,19 @@ import (
 	. "github.com/hkoosha/giraffe/core/t11y/dot"
 )
 
-type datumSerde struct{}
+type datumSerde struct {
+	ordered bool
+}
 
 func (s datumSerde) Write(v Datum) ([]byte, error) {
 	return v.MarshalJSON()
 }
 
 func (s datumSerde) Read(b []byte) (Datum, error) {
+	if s.ordered || IsOrderedKeys() {
+		return ofJsonOrdered(b)
+	}
+
 	v, err := gson.Unmarshal[any](b)
 	if err != nil {
 		return OfErr(), err
@@ -39,5 +45,48 @@ func (s datumSerde) StreamFrom(r io.Reader) (Datum, error) {
 }
 
 func DatumSerde() serdes.Serde[Datum] {
-	return datumSerde{}
+	return datumSerde{ordered: false}
+}
+
+// DatumOrderedSerde reads objects keeping the order of their keys, regardless
+// of EnableOrderedKeys.
+func DatumOrderedSerde() serdes.Serde[Datum] {
+	return datumSerde{ordered: true}
+}
+
+// DatumYamlSerde reads the first document of a YAML stream. Anchors, aliases
+// and merge keys are resolved on read. Floats are written out exactly, and
+// read back at the precision their value needs.
+func DatumYamlSerde() serdes.Serde[Datum] {
+	return datumYamlSerde{}
+}
+
+// DatumTomlSerde only accepts objects at the root, with no null values, ints
+// fitting in 64 bits and floats fitting in a float64 exactly. Unlike the other
+// serdes, it does not keep the order of keys: tables are read and written with
+// their keys sorted, regardless of EnableOrderedKeys and of ordered objects.
+func DatumTomlSerde() serdes.Serde[Datum] {
+	return datumTomlSerde{}
+}
+
+// DatumCborSerde encodes as RFC 8949 CBOR, with bignum and bigfloat tags for
+// the values beyond 64 bits. A bigfloat has no precision, only a value, see
+// DatumYamlSerde.
+func DatumCborSerde() serdes.Serde[Datum] {
+	return datumCborSerde{}
+}
+
+// DatumMsgpackSerde encodes as MessagePack, with extension types 1 (big int)
+// and 2 (big float) for the values beyond 64 bits. Floats keep their value,
+// not their precision, as with DatumCborSerde.
+func DatumMsgpackSerde() serdes.Serde[Datum] {
+	return datumMsgpackSerde{}
+}
+
+// DatumBinarySerde encodes in a compact, versioned binary format, much faster
+// than JSON and keeping the type and precision of numbers, nil values and the
+// order of the keys of ordered objects exactly. It is meant for snapshots,
+// caches and transport between giraffe processes, not for interop.
+func DatumBinarySerde() serdes.Serde[Datum] {
+	return datumBinarySerde{}
 }
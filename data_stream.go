@@ -0,0 +1,107 @@
+package giraffe
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"slices"
+)
+
+// StreamOf reads JSON from r token by token, without materialising the whole
+// document. Each of the iterators consumes r, so only one of them can be
+// used, once. Errors stop the iteration and are reported by Err.
+func StreamOf(
+	r io.Reader,
+) *DatumStream {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	return &DatumStream{
+		dec:        dec,
+		projection: nil,
+		err:        nil,
+	}
+}
+
+type DatumStream struct {
+	dec        *json.Decoder
+	err        error
+	projection [][]streamSeg
+}
+
+func (s *DatumStream) String() string {
+	return "DatumStream"
+}
+
+// WithProjection limits Leaves to the given paths. Each path found in the
+// document is yielded once, with its whole subtree materialised, and
+// everything else is skipped. Paths which are not found are not yielded.
+func (s *DatumStream) WithProjection(
+	projection ...Query,
+) (*DatumStream, error) {
+	segs := make([][]streamSeg, len(projection))
+	for i, q := range projection {
+		var err error
+		if segs[i], err = streamSegsOf(q); err != nil {
+			return nil, err
+		}
+	}
+
+	return &DatumStream{
+		dec:        s.dec,
+		err:        s.err,
+		projection: append(slices.Clone(s.projection), segs...),
+	}, nil
+}
+
+// Leaves yields each scalar, empty object and empty array of the document,
+// in document order, by its path. A scalar document is yielded by GQErr().
+func (s *DatumStream) Leaves() iter.Seq2[Query, Datum] {
+	return func(yield func(Query, Datum) bool) {
+		w := streamWalker{
+			dec:        s.dec,
+			projection: s.projection,
+			yield:      yield,
+			path:       nil,
+		}
+
+		if _, err := w.value(); err != nil {
+			s.err = err
+		}
+	}
+}
+
+// Tuples is Leaves, as tuples.
+func (s *DatumStream) Tuples() iter.Seq[Tuple] {
+	return func(yield func(Tuple) bool) {
+		for q, d := range s.Leaves() {
+			if !yield(Tuple{Query: q, Dat: d}) {
+				return
+			}
+		}
+	}
+}
+
+// Values yields each top level value of a stream of concatenated values,
+// such as NDJSON (JSON lines). The projection is not applied.
+func (s *DatumStream) Values() iter.Seq[Datum] {
+	return func(yield func(Datum) bool) {
+		for s.dec.More() {
+			d, err := s.next()
+			if err != nil {
+				s.err = err
+
+				return
+			}
+
+			if !yield(d) {
+				return
+			}
+		}
+	}
+}
+
+// Err is the error which stopped the iteration, if any.
+func (s *DatumStream) Err() error {
+	return s.err
+}
@@ -2,8 +2,8 @@ package giraffe
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
-	"slices"
 	"strings"
 
 	"github.com/hkoosha/giraffe/cmd"
@@ -48,7 +48,7 @@ func (d Datum) string0() string {
 		sb.WriteByte('{')
 
 		i := 0
-		for k := range d.obj() {
+		for _, k := range d.keysInOrder() {
 			if i >= 3 {
 				sb.WriteString(", ...")
 
@@ -103,6 +103,18 @@ func (d Datum) plain() (any, error) {
 }
 
 func (d Datum) raw() (any, error) {
+	return d.raw0(false)
+}
+
+// jsonRaw is raw, except for the ordered objects which encode their keys in
+// order.
+func (d Datum) jsonRaw() (any, error) {
+	return d.raw0(true)
+}
+
+func (d Datum) raw0(
+	keepOrder bool,
+) (any, error) {
 	switch {
 	case d.typ.IsErr():
 		return nil, newInvalidDatumError()
@@ -125,13 +137,18 @@ func (d Datum) raw() (any, error) {
 
 	case d.typ.IsArr():
 		return z.TryApplied(d.arr(), func(it Datum) (any, error) {
-			return it.raw()
+			return it.raw0(keepOrder)
 		})
 
 	case d.typ.IsObj():
-		return z.TryApplied2(d.obj(), func(_ string, it Datum) (any, error) {
-			return it.raw()
+		m, err := z.TryApplied2(d.obj(), func(_ string, it Datum) (any, error) {
+			return it.raw0(keepOrder)
 		})
+		if err != nil || !keepOrder || !d.isOrdered() {
+			return m, err
+		}
+
+		return rawOrdered{keys: d.keysInOrder(), m: m}, nil
 
 	case d.typ.isZero():
 		panic(EF("unreachable, datum is zero"))
@@ -189,6 +206,14 @@ func (d Datum) eq(
 func (d Datum) cmp(
 	other Datum,
 ) (int, error) {
+	switch {
+	case d.typ.IsStr() && other.typ.IsStr():
+		return strings.Compare(M(d.Str()), M(other.Str())), nil
+
+	case d.isNum() && other.isNum() && (d.typ.IsFlt() || other.typ.IsFlt()):
+		return d.num().Cmp(other.num()), nil
+	}
+
 	dI, err := d.Int()
 	if err != nil {
 		return -1, err
@@ -202,60 +227,16 @@ func (d Datum) cmp(
 	return dI.Cmp(oI), nil
 }
 
-func (d Datum) merge(
-	right []Datum,
-) (Datum, error) {
-	var err error
-
-	fin := d
-	for _, r := range right {
-		fin, err = fin.merge0(r, []string{})
-		if err != nil {
-			return OfErr(), err
-		}
-	}
-
-	return fin, nil
+func (d Datum) isNum() bool {
+	return !d.typ.IsNil() && (d.typ.IsInt() || d.typ.IsFlt())
 }
 
-func (d Datum) merge0(
-	right Datum,
-	path []string,
-) (Datum, error) {
-	if d.typ != right.typ {
-		return OfErr(), newMergeIncompatibleTypesError(d.typ, right.typ)
+func (d Datum) num() *big.Float {
+	if d.typ.IsInt() {
+		return new(big.Float).SetInt(M(d.Int()))
 	}
 
-	switch {
-	case d.typ.IsObj():
-		fin := Of(d)
-		obj := fin.obj()
-
-		for k, v := range right.obj() {
-			if existing, ok := obj[k]; ok {
-				nPath := append(slices.Clone(path), k)
-				var err error
-				if obj[k], err = existing.merge0(v, nPath); err != nil {
-					return OfErr(), err
-				}
-			} else {
-				obj[k] = v
-			}
-		}
-
-		return fin, nil
-
-	case d.typ.IsArr():
-		// TODO merge array
-		panic(EF("TODO - merge array"))
-
-	default:
-		if !d.eq(right) {
-			return OfErr(), newMergeClashingKeysError(path)
-		}
-
-		return d, nil
-	}
+	return M(d.Flt())
 }
 
 func (d Datum) nest(
@@ -271,13 +252,15 @@ func (d Datum) nest(
 		return errD, err
 	}
 
-	nested := OfEmpty()
-	for k, v := range obj {
+	nested := newObj(d.isOrdered())
+	for _, k := range d.keysInOrder() {
+		v := obj[k]
+
 		qK, err := GQParse(k)
 		if err != nil {
 			return errD, err
 		}
-		qK = Query(q.String() + cmd.Sep.String() + qK.String())
+		qK = Query(q.String() + cmd.Sep.String() + qK.String()).WithMake()
 
 		nested, err = nested.Set(qK, v)
 		if err != nil {
@@ -292,16 +275,26 @@ func (d Datum) set(
 	q queryT,
 	value any,
 ) (Datum, error) {
-	// q, err := qq.Resolved(d.resolver)
-	// if err != nil {
-	// 	return OfErr(), err
-	// }
+	q, err := d.resolved(q)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	if q.Flags().IsPattern() && hasPatternFrom(q.Root()) {
+		return d.setAll(q, value)
+	}
 
 	if !d.typ.IsArr() && !d.typ.IsObj() {
 		panic(EF("TODO unimplemented, set for non-container types: %s", d.typ.String()))
 	}
 
-	cp := M(of(d.deref()))
+	// A persistent datum is shared as is, each write making a new version of
+	// what it changes.
+	cp := d
+	if !d.isPersistent() {
+		cp = M(of(d.deref()))
+	}
+
 	if err := modify(&cp, q, value); err != nil {
 		return errD, err
 	}
@@ -312,14 +305,14 @@ func (d Datum) set(
 func (d Datum) has(
 	q queryT,
 ) (bool, error) {
-	// q, err := qq.Resolved(d.resolver)
-	// if err != nil {
-	// 	return false, err
-	// }
+	q, err := d.resolved(q)
+	if err != nil {
+		return false, err
+	}
 
 	switch {
-	case q.Flags().IsObj() && d.typ.IsObj():
-		v, ok := d.obj()[q.Attr()]
+	case isKeyIn(q, d.typ) && d.typ.IsObj():
+		v, ok := d.objAt(q.Attr())
 		if !ok {
 			return false, nil
 		}
@@ -333,15 +326,15 @@ func (d Datum) has(
 		return ok, nil
 
 	case q.Flags().IsArr() && d.typ.IsArr():
-		arr := d.arr()
-		if q.Index() >= len(arr) {
+		i := indexIn(q, d.len())
+		if i < 0 || i >= d.len() {
 			return false, nil
 		}
 
 		if q.Flags().IsLeaf() {
 			return true, nil
 		}
-		ok, err := arr[q.Index()].has(q.Next())
+		ok, err := d.arrAt(i).has(q.Next())
 		if err != nil {
 			return false, err
 		}
@@ -396,33 +389,6 @@ func copyToArr[T any](
 
 // =============================================================================.
 
-func newMergeIncompatibleTypesError(
-	left Type,
-	right Type,
-) error {
-	return newDataMakeError(
-		ErrCodeDataMergeIncompatibleTypes,
-		fmt.Sprintf(
-			"incompatible types on merge: left=%s, right=%s",
-			left.String(),
-			right.String(),
-		),
-	)
-}
-
-func newMergeClashingKeysError(
-	key []string,
-) error {
-	key = z.Applied(key, func(it string) string {
-		return strings.ReplaceAll(it, ".", `\.`)
-	})
-
-	return newDataMakeError(
-		ErrCodeDataMergeClashingKeys,
-		"clashing keys: ["+strings.Join(key, cmd.Sep.String())+"]",
-	)
-}
-
 func newDataWriteUnexpectedValueError(
 	q queryT,
 	v any,
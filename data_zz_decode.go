@@ -0,0 +1,664 @@
+package giraffe
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+	"github.com/hkoosha/giraffe/internal/reflected"
+)
+
+const (
+	codecTag          = "giraffe"
+	codecTagSkip      = "-"
+	codecOptOptional  = "optional"
+	codecOptOmitEmpty = "omitempty"
+)
+
+var (
+	tDatum  = reflect.TypeFor[Datum]()
+	tBigInt = reflect.TypeFor[*big.Int]()
+	tBigFlt = reflect.TypeFor[*big.Float]()
+
+	// codecPlans caches the fields of each struct type, or the error of its
+	// tags.
+	codecPlans sync.Map
+)
+
+// codecField is a field of a struct and the query it binds to. The index goes
+// through the embedded structs which are flattened.
+type codecField struct {
+	query     Query
+	index     []int
+	optional  bool
+	omitEmpty bool
+}
+
+type codecPlanned struct {
+	err    error
+	fields []codecField
+}
+
+func codecPlan(
+	t reflect.Type,
+) ([]codecField, error) {
+	if cached, ok := codecPlans.Load(t); ok {
+		p := cached.(codecPlanned) //nolint:forcetypeassert
+
+		return p.fields, p.err
+	}
+
+	fields, err := codecPlan0(t, nil)
+	codecPlans.Store(t, codecPlanned{err: err, fields: fields})
+
+	return fields, err
+}
+
+func codecPlan0(
+	t reflect.Type,
+	parent []int,
+) ([]codecField, error) {
+	var fields []codecField
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+		index := append(slices.Clone(parent), i)
+
+		tag, tagged := f.Tag.Lookup(codecTag)
+		spec, opts, _ := strings.Cut(tag, ",")
+
+		switch {
+		case spec == codecTagSkip:
+			continue
+
+		case spec == "" && f.Anonymous && f.Type.Kind() == reflect.Struct:
+			embedded, err := codecPlan0(f.Type, index)
+			if err != nil {
+				return nil, err
+			}
+
+			fields = append(fields, embedded...)
+
+			continue
+
+		case !f.IsExported():
+			continue
+
+		case spec == "":
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			switch {
+			case name == codecTagSkip && !tagged:
+				continue
+
+			case name == "" || name == codecTagSkip:
+				name = f.Name
+			}
+
+			spec = internal.Escaped(name)
+		}
+
+		field, err := codecFieldOf(t, f, spec, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		field.index = index
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func codecFieldOf(
+	t reflect.Type,
+	f reflect.StructField,
+	spec string,
+	opts string,
+) (codecField, error) {
+	field := codecField{
+		query:     Query(spec),
+		index:     nil,
+		optional:  false,
+		omitEmpty: false,
+	}
+
+	q, err := internal.Parse(spec)
+	if err != nil {
+		return field, E(err, newCodecInvalidTagError(t, f, "invalid query"))
+	}
+
+	if err = readonly(q); err != nil {
+		return field, E(err, newCodecInvalidTagError(t, f, "query must be read-only"))
+	}
+
+	if qf := q.Flags(); qf.IsIndeterministic() || qf.IsDyn() {
+		return field, newCodecInvalidTagError(t, f, "query must be deterministic and not dynamic")
+	}
+
+	for opt := range strings.SplitSeq(opts, ",") {
+		switch opt {
+		case "":
+
+		case codecOptOptional:
+			field.optional = true
+
+		case codecOptOmitEmpty:
+			field.omitEmpty = true
+
+		default:
+			return field, newCodecInvalidTagError(t, f, "unknown option: "+opt)
+		}
+	}
+
+	return field, nil
+}
+
+// =====================================.
+
+func decode(
+	d Datum,
+	target any,
+) error {
+	var violations []Violation
+	decodeValue(GQErr(), d, reflect.ValueOf(target).Elem(), &violations)
+
+	if len(violations) > 0 {
+		return E(&DecodeError{violations: violations})
+	}
+
+	return nil
+}
+
+func isNilable(
+	t reflect.Type,
+) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+
+	default:
+		return false
+	}
+}
+
+//nolint:gocyclo,cyclop
+func decodeValue(
+	path Query,
+	d Datum,
+	v reflect.Value,
+	violations *[]Violation,
+) {
+	t := v.Type()
+
+	fail := func(err error) {
+		addViolation(violations, path, err.Error())
+	}
+
+	switch {
+	case t == tDatum:
+		v.Set(reflect.ValueOf(M(of(d.deref()))))
+
+		return
+
+	case d.typ.IsNil() && isNilable(t):
+		v.SetZero()
+
+		return
+
+	case d.typ.IsNil():
+		fail(newNilError())
+
+		return
+
+	case t == tBigInt:
+		if i, err := d.Int(); err != nil {
+			fail(err)
+		} else {
+			v.Set(reflect.ValueOf(i))
+		}
+
+		return
+
+	case t == tBigFlt:
+		if f, err := decodeFlt(d); err != nil {
+			fail(err)
+		} else {
+			v.Set(reflect.ValueOf(f))
+		}
+
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+
+		decodeValue(path, d, v.Elem(), violations)
+
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			fail(newDataMakeUnimplementedType(t))
+
+			return
+		}
+
+		if p, err := d.Plain(); err != nil {
+			fail(err)
+		} else {
+			v.Set(reflect.ValueOf(p))
+		}
+
+	case reflect.Bool:
+		if b, err := d.Bln(); err != nil {
+			fail(err)
+		} else {
+			v.SetBool(b)
+		}
+
+	case reflect.String:
+		if s, err := d.Str(); err != nil {
+			fail(err)
+		} else {
+			v.SetString(s)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := decodeInt(d, t.Kind()); err != nil {
+			fail(err)
+		} else {
+			v.SetInt(i)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u, err := decodeUint(d, t.Kind()); err != nil {
+			fail(err)
+		} else {
+			v.SetUint(u)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if f, err := decodeFloat(d, t.Kind()); err != nil {
+			fail(err)
+		} else {
+			v.SetFloat(f)
+		}
+
+	case reflect.Slice, reflect.Array:
+		decodeSeq(path, d, v, violations)
+
+	case reflect.Map:
+		decodeMap(path, d, v, violations)
+
+	case reflect.Struct:
+		decodeStruct(path, d, v, violations)
+
+	default:
+		fail(newDataMakeUnimplementedType(t))
+	}
+}
+
+func decodeInt(
+	d Datum,
+	kind reflect.Kind,
+) (int64, error) {
+	switch kind {
+	case reflect.Int8:
+		i, err := d.I08()
+
+		return int64(i), err
+
+	case reflect.Int16:
+		i, err := d.I16()
+
+		return int64(i), err
+
+	case reflect.Int32:
+		i, err := d.I32()
+
+		return int64(i), err
+
+	case reflect.Int64:
+		return d.I64()
+
+	default:
+		i, err := d.ISz()
+
+		return int64(i), err
+	}
+}
+
+func decodeUint(
+	d Datum,
+	kind reflect.Kind,
+) (uint64, error) {
+	switch kind {
+	case reflect.Uint8:
+		u, err := d.U08()
+
+		return uint64(u), err
+
+	case reflect.Uint16:
+		u, err := d.U16()
+
+		return uint64(u), err
+
+	case reflect.Uint32:
+		u, err := d.U32()
+
+		return uint64(u), err
+
+	case reflect.Uint64:
+		return d.U64()
+
+	default:
+		u, err := d.USz()
+
+		return uint64(u), err
+	}
+}
+
+// decodeFlt reads a float, or an int as a float.
+func decodeFlt(
+	d Datum,
+) (*big.Float, error) {
+	if d.typ.IsInt() {
+		i, err := d.Int()
+		if err != nil {
+			return nil, err
+		}
+
+		return new(big.Float).SetInt(i), nil
+	}
+
+	return d.Flt()
+}
+
+func decodeFloat(
+	d Datum,
+	kind reflect.Kind,
+) (float64, error) {
+	f, err := decodeFlt(d)
+	if err != nil {
+		return 0, err
+	}
+
+	if kind == reflect.Float32 {
+		f32, _ := f.Float32()
+		if math.IsInf(float64(f32), 0) && !f.IsInf() {
+			return 0, newDataReadFloatOverflowError(reflected.TF32)
+		}
+
+		return float64(f32), nil
+	}
+
+	f64, _ := f.Float64()
+	if math.IsInf(f64, 0) && !f.IsInf() {
+		return 0, newDataReadFloatOverflowError(reflected.TF64)
+	}
+
+	return f64, nil
+}
+
+func decodeSeq(
+	path Query,
+	d Datum,
+	v reflect.Value,
+	violations *[]Violation,
+) {
+	if !d.typ.IsArr() {
+		addViolation(violations, path, newTypeCastError(d.typ, Arr).Error())
+
+		return
+	}
+
+	n := d.len()
+	if v.Kind() == reflect.Slice {
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+	} else if v.Len() != n {
+		addViolation(violations, path, fmt.Sprintf("expected %d items, got %d", v.Len(), n))
+
+		return
+	}
+
+	for i := range n {
+		decodeValue(schemaPath(path, strconv.Itoa(i)), d.arrAt(i), v.Index(i), violations)
+	}
+}
+
+func decodeMap(
+	path Query,
+	d Datum,
+	v reflect.Value,
+	violations *[]Violation,
+) {
+	t := v.Type()
+
+	switch {
+	case t.Key().Kind() != reflect.String:
+		addViolation(violations, path, newDataMakeUnexpectedTypeError(t.Key(), reflected.TStr).Error())
+
+		return
+
+	case !d.typ.IsObj():
+		addViolation(violations, path, newTypeCastError(d.typ, Obj).Error())
+
+		return
+	}
+
+	m := reflect.MakeMapWithSize(t, d.len())
+	for _, k := range d.keysInOrder() {
+		item, _ := d.objAt(k)
+		elem := reflect.New(t.Elem()).Elem()
+		decodeValue(schemaPath(path, internal.Escaped(k)), item, elem, violations)
+		m.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), elem)
+	}
+
+	v.Set(m)
+}
+
+func decodeStruct(
+	path Query,
+	d Datum,
+	v reflect.Value,
+	violations *[]Violation,
+) {
+	fields, err := codecPlan(v.Type())
+	switch {
+	case err != nil:
+		addViolation(violations, path, err.Error())
+
+		return
+
+	case !d.typ.IsObj():
+		addViolation(violations, path, newTypeCastError(d.typ, Obj).Error())
+
+		return
+	}
+
+	for _, f := range fields {
+		fPath := schemaPath(path, f.query.String())
+		fv := v.FieldByIndex(f.index)
+
+		ok, err := d.Has(f.query)
+		switch {
+		case err != nil:
+			addViolation(violations, fPath, err.Error())
+
+			continue
+
+		case !ok && fv.Kind() == reflect.Pointer:
+			fv.SetZero()
+
+			continue
+
+		case !ok && !f.optional:
+			addViolation(violations, fPath, "missing")
+
+			continue
+
+		case !ok:
+			continue
+		}
+
+		item, err := d.Get(f.query)
+		switch {
+		case err != nil:
+			addViolation(violations, fPath, err.Error())
+
+		case item.typ.IsNil() && f.optional:
+
+		default:
+			decodeValue(fPath, item, fv, violations)
+		}
+	}
+}
+
+// =====================================.
+
+func encode(
+	v any,
+) (Datum, error) {
+	return encodeValue(reflect.ValueOf(v))
+}
+
+func encodeValue(
+	r reflect.Value,
+) (Datum, error) {
+	if !r.IsValid() {
+		return _newDatum(Nil, nil), nil
+	}
+
+	t := r.Type()
+	if t == tDatum || t == tBigInt || t == tBigFlt {
+		if t != tDatum && r.IsNil() {
+			return _newDatum(Nil, nil), nil
+		}
+
+		return of(r.Interface())
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if r.IsNil() {
+			return _newDatum(Nil, nil), nil
+		}
+
+		return encodeValue(r.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && r.IsNil() {
+			return _newDatum(Nil, nil), nil
+		}
+
+		arr := make([]Datum, r.Len())
+		for i := range arr {
+			var err error
+			if arr[i], err = encodeValue(r.Index(i)); err != nil {
+				return OfErr(), err
+			}
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	case reflect.Map:
+		return encodeMap(r)
+
+	case reflect.Struct:
+		return encodeStruct(r)
+
+	default:
+		return of(r.Interface())
+	}
+}
+
+func encodeMap(
+	r reflect.Value,
+) (Datum, error) {
+	t := r.Type()
+
+	switch {
+	case t.Key().Kind() != reflect.String:
+		return OfErr(), newDataMakeUnexpectedTypeError(t.Key(), reflected.TStr)
+
+	case r.IsNil():
+		return _newDatum(Nil, nil), nil
+	}
+
+	keys := r.MapKeys()
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		return cmp.Compare(a.String(), b.String())
+	})
+
+	obj := newObj(false)
+	for _, k := range keys {
+		item, err := encodeValue(r.MapIndex(k))
+		if err != nil {
+			return OfErr(), err
+		}
+
+		obj.putObj(k.String(), item)
+	}
+
+	return obj, nil
+}
+
+func encodeStruct(
+	r reflect.Value,
+) (Datum, error) {
+	fields, err := codecPlan(r.Type())
+	if err != nil {
+		return OfErr(), err
+	}
+
+	return newObj(false).Edit(func(tx *Tx) error {
+		for _, f := range fields {
+			fv := r.FieldByIndex(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+
+			item, err := encodeValue(fv)
+			if err != nil {
+				return E(err, newCodecFieldError(f.query))
+			}
+
+			if err = tx.Make(f.query, item); err != nil {
+				return E(err, newCodecFieldError(f.query))
+			}
+		}
+
+		return nil
+	})
+}
+
+// =====================================.
+
+func newCodecInvalidTagError(
+	t reflect.Type,
+	f reflect.StructField,
+	reason string,
+) error {
+	return newGiraffeError(
+		ErrCodeDataCodecInvalidTag,
+		fmt.Sprintf("invalid %s tag: field=%s.%s, reason=%s", codecTag, t.String(), f.Name, reason),
+	)
+}
+
+func newCodecFieldError(
+	q Query,
+) error {
+	return newGiraffeError(
+		ErrCodeDataEncodeFailed,
+		"cannot encode field: "+q.String(),
+	)
+}
@@ -0,0 +1,616 @@
+package giraffe
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+	"github.com/hkoosha/giraffe/internal/queryimpl/jsonpointer"
+)
+
+type delta struct {
+	path   []string
+	change Change
+}
+
+// diffPath is where a diff is at, both as the reference tokens of a JSON
+// Pointer and as the segments of a query, keys escaped.
+type diffPath struct {
+	tokens []string
+	segs   []string
+}
+
+func (p diffPath) key(
+	k string,
+) diffPath {
+	return diffPath{
+		tokens: append(slices.Clone(p.tokens), k),
+		segs:   append(slices.Clone(p.segs), internal.Escaped(k)),
+	}
+}
+
+func (p diffPath) index(
+	i int,
+) diffPath {
+	return diffPath{
+		tokens: append(slices.Clone(p.tokens), strconv.Itoa(i)),
+		segs:   append(slices.Clone(p.segs), strconv.Itoa(i)),
+	}
+}
+
+func (d Datum) diff(
+	other Datum,
+) ([]delta, error) {
+	if d.typ.IsErr() || other.typ.IsErr() {
+		return nil, newInvalidDatumError()
+	}
+
+	var deltas []delta
+	if err := diff0(&deltas, diffPath{tokens: []string{}, segs: []string{}}, d, other); err != nil {
+		return nil, err
+	}
+
+	return deltas, nil
+}
+
+func diff0(
+	deltas *[]delta,
+	path diffPath,
+	left Datum,
+	right Datum,
+) error {
+	switch {
+	case left.typ != right.typ:
+		return addDelta(deltas, path, ChangeChanged, left, right)
+
+	case left.typ.IsObj():
+		lObj := left.obj()
+		rObj := right.obj()
+
+		keys := slices.Sorted(maps.Keys(lObj))
+		for _, k := range slices.Sorted(maps.Keys(rObj)) {
+			if _, ok := lObj[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			l, lOk := lObj[k]
+			r, rOk := rObj[k]
+			nPath := path.key(k)
+
+			var err error
+			switch {
+			case !rOk:
+				err = addDelta(deltas, nPath, ChangeRemoved, l, OfErr())
+			case !lOk:
+				err = addDelta(deltas, nPath, ChangeAdded, OfErr(), r)
+			default:
+				err = diff0(deltas, nPath, l, r)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case left.typ.IsArr():
+		lArr := left.arr()
+		rArr := right.arr()
+
+		for i := range min(len(lArr), len(rArr)) {
+			nPath := path.index(i)
+			if err := diff0(deltas, nPath, lArr[i], rArr[i]); err != nil {
+				return err
+			}
+		}
+
+		// Removed from the end, so that each index is still valid when the
+		// changes are applied one after the other.
+		for i := len(lArr) - 1; i >= len(rArr); i-- {
+			nPath := path.index(i)
+			if err := addDelta(deltas, nPath, ChangeRemoved, lArr[i], OfErr()); err != nil {
+				return err
+			}
+		}
+
+		for i := len(lArr); i < len(rArr); i++ {
+			nPath := path.index(i)
+			if err := addDelta(deltas, nPath, ChangeAdded, OfErr(), rArr[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case !left.eq(right):
+		return addDelta(deltas, path, ChangeChanged, left, right)
+
+	default:
+		return nil
+	}
+}
+
+func addDelta(
+	deltas *[]delta,
+	path diffPath,
+	kind ChangeKind,
+	left Datum,
+	right Datum,
+) error {
+	q, err := pathQuery(path.segs)
+	if err != nil {
+		return err
+	}
+
+	*deltas = append(*deltas, delta{
+		path: path.tokens,
+		change: Change{
+			Query: q,
+			Old:   left,
+			New:   right,
+			Kind:  kind,
+		},
+	})
+
+	return nil
+}
+
+// pathQuery is the query of the segments of path, keys already escaped, or
+// GQErr() for the root.
+func pathQuery(
+	path []string,
+) (Query, error) {
+	if len(path) == 0 {
+		return GQErr(), nil
+	}
+
+	return GQParse(strings.Join(path, cmd.Sep.String()))
+}
+
+// =====================================.
+
+func (d Datum) patchTo(
+	other Datum,
+) (JsonPatch, error) {
+	deltas, err := d.diff(other)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(JsonPatch, len(deltas))
+	for i, it := range deltas {
+		op := PatchOp{
+			Value: it.change.New,
+			Op:    PatchReplace,
+			Path:  ptrOf(it.path),
+			From:  "",
+		}
+
+		switch it.change.Kind {
+		case ChangeAdded:
+			op.Op = PatchAdd
+
+		case ChangeRemoved:
+			op.Op = PatchRemove
+			op.Value = OfErr()
+
+		case ChangeChanged:
+
+		default:
+			panic(EF("unreachable, unknown change: %s", it.change.Kind.String()))
+		}
+
+		patch[i] = op
+	}
+
+	return patch, nil
+}
+
+func (d Datum) patched(
+	patch JsonPatch,
+) (Datum, error) {
+	fin := d
+
+	for _, op := range patch {
+		path, err := ptrPath(op.Path)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		switch op.Op {
+		case PatchAdd:
+			fin, err = fin.patchAdd(path, op.Value)
+
+		case PatchRemove:
+			fin, err = fin.patchRemove(path)
+
+		case PatchReplace:
+			fin, err = fin.patchReplace(path, op.Value)
+
+		case PatchMove, PatchCopy:
+			var from []string
+			if from, err = ptrPath(op.From); err != nil {
+				return OfErr(), err
+			}
+
+			fin, err = fin.patchMoveOrCopy(from, path, op.Op == PatchMove)
+
+		case PatchTest:
+			var at Datum
+			if at, err = fin.patchAt(path); err == nil && !at.eq(op.Value) {
+				err = newPatchTestFailedError(op.Path)
+			}
+
+		default:
+			err = newPatchInvalidError("unknown op: " + op.Op.String())
+		}
+
+		if err != nil {
+			return OfErr(), err
+		}
+	}
+
+	return fin, nil
+}
+
+func (d Datum) patchAdd(
+	path []string,
+	value Datum,
+) (Datum, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return d.patchIn(path, func(parent Datum, last string) (Datum, error) {
+		switch {
+		case parent.typ.IsObj():
+			obj := parent.objClone()
+			obj.putObj(last, value)
+
+			return obj, nil
+
+		case parent.typ.IsArr() && last == "-":
+			return parent.arrWith(parent.len(), value), nil
+
+		case parent.typ.IsArr():
+			i, ok := ptrIndex(last, parent.len()+1)
+			switch {
+			case !ok:
+				return OfErr(), newPatchMissingPathError(ptrOf(path))
+
+			case i == parent.len():
+				return parent.arrWith(i, value), nil
+			}
+
+			arr := slices.Insert(slices.Clone(parent.arr()), i, value)
+
+			return newArr(arr, parent.isPersistent()), nil
+
+		default:
+			return OfErr(), newPatchMissingPathError(ptrOf(path))
+		}
+	})
+}
+
+func (d Datum) patchRemove(
+	path []string,
+) (Datum, error) {
+	if len(path) == 0 {
+		return OfErr(), newPatchInvalidError("cannot remove root")
+	}
+
+	return d.patchIn(path, func(parent Datum, last string) (Datum, error) {
+		if _, ok := parent.patchChild(last); !ok {
+			return OfErr(), newPatchMissingPathError(ptrOf(path))
+		}
+
+		if parent.typ.IsObj() {
+			obj := parent.objClone()
+			obj.delObj(last)
+
+			return obj, nil
+		}
+
+		i := M(strconv.Atoi(last))
+		arr := slices.Delete(slices.Clone(parent.arr()), i, i+1)
+
+		return newArr(arr, parent.isPersistent()), nil
+	})
+}
+
+func (d Datum) patchReplace(
+	path []string,
+	value Datum,
+) (Datum, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return d.patchIn(path, func(parent Datum, last string) (Datum, error) {
+		if _, ok := parent.patchChild(last); !ok {
+			return OfErr(), newPatchMissingPathError(ptrOf(path))
+		}
+
+		return parent.withPatchChild(last, value), nil
+	})
+}
+
+func (d Datum) patchMoveOrCopy(
+	from []string,
+	path []string,
+	isMove bool,
+) (Datum, error) {
+	if isMove && len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+		return OfErr(), newPatchInvalidError("cannot move into own child: " + ptrOf(from))
+	}
+
+	value, err := d.patchAt(from)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	fin := d
+	if isMove {
+		if fin, err = fin.patchRemove(from); err != nil {
+			return OfErr(), err
+		}
+	}
+
+	return fin.patchAdd(path, value)
+}
+
+func (d Datum) patchAt(
+	path []string,
+) (Datum, error) {
+	at := d
+	for i, seg := range path {
+		var ok bool
+		if at, ok = at.patchChild(seg); !ok {
+			return OfErr(), newPatchMissingPathError(ptrOf(path[:i+1]))
+		}
+	}
+
+	return at, nil
+}
+
+// patchIn rebuilds the containers along the path, sharing everything else.
+func (d Datum) patchIn(
+	path []string,
+	op func(parent Datum, last string) (Datum, error),
+) (Datum, error) {
+	if len(path) == 1 {
+		return op(d, path[0])
+	}
+
+	child, ok := d.patchChild(path[0])
+	if !ok {
+		return OfErr(), newPatchMissingPathError(ptrOf(path[:1]))
+	}
+
+	patched, err := child.patchIn(path[1:], op)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	return d.withPatchChild(path[0], patched), nil
+}
+
+func (d Datum) patchChild(
+	seg string,
+) (Datum, bool) {
+	switch {
+	case d.typ.IsObj():
+		return d.objAt(seg)
+
+	case d.typ.IsArr():
+		i, ok := ptrIndex(seg, d.len())
+		if !ok {
+			return OfErr(), false
+		}
+
+		return d.arrAt(i), true
+
+	default:
+		return OfErr(), false
+	}
+}
+
+func (d Datum) withPatchChild(
+	seg string,
+	value Datum,
+) Datum {
+	if d.typ.IsObj() {
+		obj := d.objClone()
+		obj.putObj(seg, value)
+
+		return obj
+	}
+
+	return d.arrWith(M(strconv.Atoi(seg)), value)
+}
+
+// =====================================.
+
+func (d Datum) mergePatchTo(
+	other Datum,
+) (Datum, error) {
+	switch {
+	case d.typ.IsErr() || other.typ.IsErr():
+		return OfErr(), newInvalidDatumError()
+
+	case !d.typ.IsObj() || !other.typ.IsObj():
+		return other, nil
+	}
+
+	lObj := d.obj()
+	rObj := other.obj()
+	patch := make(map[string]Datum)
+
+	for k := range lObj {
+		if _, ok := rObj[k]; !ok {
+			patch[k] = _newDatum(Nil, nil)
+		}
+	}
+
+	for k, r := range rObj {
+		l, ok := lObj[k]
+		switch {
+		case !ok:
+			patch[k] = r
+
+		case l.eq(r):
+
+		default:
+			p, err := l.mergePatchTo(r)
+			if err != nil {
+				return OfErr(), err
+			}
+			patch[k] = p
+		}
+	}
+
+	return _newDatum(Obj, patch), nil
+}
+
+func (d Datum) mergePatched(
+	patch Datum,
+) (Datum, error) {
+	switch {
+	case d.typ.IsErr() || patch.typ.IsErr():
+		return OfErr(), newInvalidDatumError()
+
+	case !patch.typ.IsObj():
+		return patch, nil
+	}
+
+	var obj Datum
+	if d.typ.IsObj() {
+		obj = d.objClone()
+	} else {
+		obj = newObj(patch.isOrdered())
+	}
+
+	for _, k := range patch.keysInOrder() {
+		p := patch.obj()[k]
+
+		if p.typ.IsNil() {
+			obj.delObj(k)
+
+			continue
+		}
+
+		target, ok := obj.obj()[k]
+		if !ok {
+			target = OfErr()
+		}
+
+		patched, err := target.mergePatchedOrReplaced(p)
+		if err != nil {
+			return OfErr(), err
+		}
+		obj.putObj(k, patched)
+	}
+
+	return obj, nil
+}
+
+func (d Datum) mergePatchedOrReplaced(
+	patch Datum,
+) (Datum, error) {
+	if !patch.typ.IsObj() {
+		return patch, nil
+	}
+
+	if !d.typ.IsObj() {
+		return OfEmpty().mergePatched(patch)
+	}
+
+	return d.mergePatched(patch)
+}
+
+// =====================================.
+
+func ptrOf(
+	path []string,
+) string {
+	sb := strings.Builder{}
+
+	for _, p := range path {
+		sb.WriteByte('/')
+		p = strings.ReplaceAll(p, "~", "~0")
+		p = strings.ReplaceAll(p, "/", "~1")
+		sb.WriteString(p)
+	}
+
+	return sb.String()
+}
+
+func ptrPath(
+	ptr string,
+) ([]string, error) {
+	path, err := jsonpointer.Tokens(ptr)
+	if err != nil {
+		return nil, newPatchInvalidError("invalid pointer: " + ptr)
+	}
+
+	return path, nil
+}
+
+func ptrIndex(
+	seg string,
+	limit int,
+) (int, bool) {
+	if seg == "" || (len(seg) > 1 && seg[0] == '0') {
+		return -1, false
+	}
+
+	for i := range len(seg) {
+		if seg[i] < '0' || '9' < seg[i] {
+			return -1, false
+		}
+	}
+
+	i, err := strconv.Atoi(seg)
+	if err != nil || i >= limit {
+		return -1, false
+	}
+
+	return i, true
+}
+
+// =============================================================================.
+
+func newPatchInvalidError(
+	msg string,
+) error {
+	return newGiraffeError(
+		ErrCodeDataPatchInvalid,
+		"invalid patch: "+msg,
+	)
+}
+
+func newPatchMissingPathError(
+	ptr string,
+) error {
+	return newGiraffeError(
+		ErrCodeDataPatchMissingPath,
+		"patch path does not exist: "+ptr,
+	)
+}
+
+func newPatchTestFailedError(
+	ptr string,
+) error {
+	return newGiraffeError(
+		ErrCodeDataPatchTestFailed,
+		"patch test failed: "+ptr,
+	)
+}
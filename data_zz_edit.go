@@ -0,0 +1,89 @@
+package giraffe
+
+import (
+	"github.com/hkoosha/giraffe/cmd"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+)
+
+func (d Datum) edit(
+	fn func(*Tx) error,
+) (Datum, error) {
+	tx := &Tx{
+		d:   d,
+		err: nil,
+	}
+
+	// Scalars are never written to in place, as every write to them fails.
+	if d.typ.IsArr() || d.typ.IsObj() {
+		tx.d = M(of(d.deref()))
+	}
+
+	if err := fn(tx); err != nil {
+		return errD, err
+	}
+
+	if tx.err != nil {
+		return errD, tx.err
+	}
+
+	return tx.d, nil
+}
+
+// set writes to the copy of the transaction in place, patterns included, each
+// match being written to one after the other. A query failing to be made, as
+// told by qErr, fails the transaction as well.
+func (tx *Tx) set(
+	q queryT,
+	qErr error,
+	value any,
+) error {
+	if tx.err != nil {
+		return tx.err
+	}
+
+	err := qErr
+	if err == nil {
+		q, err = tx.d.resolved(q)
+	}
+
+	switch {
+	case err != nil:
+
+	case !tx.d.typ.IsArr() && !tx.d.typ.IsObj():
+		err = newQueryTypeCastError(tx.d.typ, q.Flags())
+
+	case q.Flags().IsPattern() && hasPatternFrom(q.Root()):
+		var paths []queryT
+		if paths, err = tx.d.writesOf(q); err != nil {
+			break
+		}
+
+		for _, concrete := range paths {
+			if err = modify(&tx.d, concrete, value); err != nil {
+				break
+			}
+		}
+
+	default:
+		err = modify(&tx.d, q, value)
+	}
+
+	tx.err = err
+
+	return err
+}
+
+// txAppending is q with an appending segment added.
+func txAppending(
+	q queryT,
+) (queryT, error) {
+	return internal.Parse(q.Escaped() + cmd.Sep.String() + cmd.Append.String())
+}
+
+// txDeleting is q deleting.
+func txDeleting(
+	q queryT,
+) (queryT, error) {
+	return internal.Parse(cmd.Delete.String() + q.Escaped())
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"strconv"
 
 	"github.com/hkoosha/giraffe/core/serdes/gson"
 	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
 	"github.com/hkoosha/giraffe/internal/gdatum"
 	"github.com/hkoosha/giraffe/internal/reflected"
 	"github.com/hkoosha/giraffe/zebra/z"
@@ -18,7 +20,7 @@ import (
 var (
 	errD     = _newDatum(Type(0), nil)
 	emptyObj = _newDatum(Obj, map[string]Datum{})
-	emptyArr = _newDatum(Obj, []Datum{})
+	emptyArr = _newDatum(Arr, []Datum{})
 
 	_datumPtrType = reflect.TypeOf((*Datum)(nil))
 	_prohibited   = map[reflect.Type]z.NA{
@@ -39,11 +41,19 @@ var (
 func ofJson(
 	v []byte,
 ) (Datum, error) {
+	if IsOrderedKeys() {
+		return ofJsonOrdered(v)
+	}
+
 	fromJ, err := gson.Unmarshal[any](v)
 	if err != nil {
 		return OfErr(), err
 	}
 
+	if fromJ == nil {
+		return _newDatum(Nil, nil), nil
+	}
+
 	val, typ, err := _ofAny(fromJ, reflect.ValueOf(fromJ))
 	if err != nil {
 		return OfErr(), err
@@ -280,6 +290,29 @@ func _ofStruct(
 		return datAgain.deref(), datAgain.typ, nil
 	}
 
+	// Persistent datums are immutable, hence shared.
+	switch p := v.(type) {
+	case objPersistent:
+		return p, Obj, nil
+
+	case arrPersistent:
+		return p, Arr, nil
+	}
+
+	if o, ok := v.(objOrdered); ok {
+		keys := o.order()
+		cp := newObjOrdered(len(keys))
+		for _, k := range keys {
+			child, err := of(o.m[k])
+			if err != nil {
+				return nil, Err, err
+			}
+			cp.put(k, child)
+		}
+
+		return objOrdered{m: cp.m, keys: cp.keys}, Obj, nil
+	}
+
 	if isProhibited(r.Type()) {
 		return nil, Err, newDataMakeProhibitedTypeError(r.Type())
 	}
@@ -345,6 +378,13 @@ func _ofMap(
 		}
 	}
 
+	if IsOrderedKeys() {
+		// Go maps have no order of their own.
+		ordered := dat.ordered(true)
+
+		return ordered.deref(), Obj, nil
+	}
+
 	return dat.obj(), Obj, nil
 }
 
@@ -378,7 +418,7 @@ func _ofPtr(
 		return cp, Int, nil
 
 	case *big.Float:
-		cp := big.NewFloat(0)
+		cp := new(big.Float)
 		cp.Set(vv)
 
 		return cp, Flt, nil
@@ -497,3 +537,19 @@ func newDataMakeDuplicatedKeyError(
 		"duplicated key: "+q.String(),
 	))
 }
+
+// newDataMakeDuplicatedObjKeyError is newDataMakeDuplicatedKeyError for a key
+// read off a document, which, as the empty key, may make no query.
+func newDataMakeDuplicatedObjKeyError(
+	k string,
+) error {
+	q, err := internal.Parse(internal.Escaped(k))
+	if err != nil {
+		return E(newDataMakeError(
+			ErrCodeDataMakeDuplicateKey,
+			"duplicated key: "+strconv.Quote(k),
+		))
+	}
+
+	return newDataMakeDuplicatedKeyError(Query(q.String()))
+}
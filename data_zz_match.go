@@ -0,0 +1,337 @@
+package giraffe
+
+import (
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+)
+
+func (d Datum) getAll(
+	q queryT,
+) (iter.Seq2[Query, Datum], error) {
+	q, err := d.resolved(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := readonly(q); err != nil {
+		return nil, err
+	}
+
+	return func(yield func(Query, Datum) bool) {
+		d.match(q.Root(), nil, false, func(path []string, v Datum) bool {
+			return yield(matchQuery(path), v)
+		})
+	}, nil
+}
+
+// readonly fails for a query with any segment appending, deleting or otherwise
+// writing.
+func readonly(
+	q queryT,
+) error {
+	for at := q.Root(); ; at = at.Next() {
+		if at.Flags().IsAppend() || at.Flags().IsDelete() || at.Flags().IsWrite() {
+			return newDataReadOnlyError(q)
+		}
+
+		if at.Flags().IsLeaf() {
+			return nil
+		}
+	}
+}
+
+// setAll sets value at every path q matches in d. The segments following the
+// last pattern are kept as is, so that they may as well make or append.
+func (d Datum) setAll(
+	q queryT,
+	value any,
+) (Datum, error) {
+	paths, err := d.writesOf(q)
+	if err != nil {
+		return errD, err
+	}
+
+	fin := d
+	for _, concrete := range paths {
+		if fin, err = fin.set(concrete, value); err != nil {
+			return errD, err
+		}
+	}
+
+	return fin, nil
+}
+
+// writesOf is the concrete queries the pattern q writes to in d, keeping the
+// modifiers of q.
+func (d Datum) writesOf(
+	q queryT,
+) ([]queryT, error) {
+	if q.Flags().IsDelete() {
+		return nil, newDataWriteIndeterministicError(q)
+	}
+
+	var paths []string
+	d.match(q.Root(), nil, true, func(path []string, _ Datum) bool {
+		paths = append(paths, strings.Join(path, cmd.Sep.String()))
+		return true
+	})
+
+	fin := make([]queryT, len(paths))
+	for i, path := range paths {
+		concrete, err := internal.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fin[i] = concrete
+	}
+
+	return fin, nil
+}
+
+// match walks q down d, calling yield with the concrete path of each datum q
+// matches. It reports whether to carry on. When writing, the segments keep
+// their modifiers, and the walk stops at the last pattern.
+func (d Datum) match(
+	q queryT,
+	at []string,
+	write bool,
+	yield func([]string, Datum) bool,
+) bool {
+	qf := q.Flags()
+	dt := d.typ
+
+	if write && !hasPatternFrom(q) {
+		path := at[:len(at):len(at)]
+		for s := q; ; s = s.Next() {
+			path = append(path, segmentOf(s))
+			if s.Flags().IsLeaf() {
+				return yield(path, d)
+			}
+		}
+	}
+
+	next := func(seg string, v Datum) bool {
+		if write {
+			seg = qf.ReconstructPreMod() + seg
+		}
+
+		path := append(at[:len(at):len(at)], seg)
+		if qf.IsLeaf() {
+			return yield(path, v)
+		}
+
+		return v.match(q.Next(), path, write, yield)
+	}
+
+	switch {
+	case qf.IsSelf() && qf.IsLeaf():
+		return yield(at, d)
+
+	case qf.IsSelf():
+		return d.match(q.Next(), at, write, yield)
+
+	case qf.IsDescent():
+		return d.descend(q, at, write, yield)
+
+	case (qf.IsWildcard() || qf.IsPredicate()) && dt.IsObj():
+		for _, k := range d.keysInOrder() {
+			v, _ := d.objAt(k)
+			if qf.IsPredicate() && !v.test(q) {
+				continue
+			}
+
+			if !next(internal.Escaped(k), v) {
+				return false
+			}
+		}
+
+	case (qf.IsWildcard() || qf.IsPredicate()) && dt.IsArr():
+		for i, v := range d.arrAll() {
+			if qf.IsPredicate() && !v.test(q) {
+				continue
+			}
+
+			if !next(strconv.Itoa(i), v) {
+				return false
+			}
+		}
+
+	case qf.IsSlice() && dt.IsArr():
+		from, to := q.Slice(d.len())
+		for i := from; i < to; i++ {
+			if !next(strconv.Itoa(i), d.arrAt(i)) {
+				return false
+			}
+		}
+
+	case qf.IsArr() && dt.IsArr():
+		if i := indexIn(q, d.len()); 0 <= i && i < d.len() {
+			return next(strconv.Itoa(i), d.arrAt(i))
+		}
+
+	case isKeyIn(q, dt) && dt.IsObj():
+		if v, ok := d.objAt(q.Attr()); ok {
+			return next(internal.Escaped(q.Attr()), v)
+		}
+	}
+
+	return true
+}
+
+// descend matches the rest of q, after a recursive descent, against d and
+// every datum nested in it, depth first.
+func (d Datum) descend(
+	q queryT,
+	at []string,
+	write bool,
+	yield func([]string, Datum) bool,
+) bool {
+	if q.Flags().IsLeaf() {
+		if !yield(at, d) {
+			return false
+		}
+	} else if !d.match(q.Next(), at, write, yield) {
+		return false
+	}
+
+	mods := ""
+	if write {
+		mods = q.Flags().ReconstructPreMod()
+	}
+
+	switch {
+	case d.typ.IsObj():
+		for _, k := range d.keysInOrder() {
+			v, _ := d.objAt(k)
+			path := append(at[:len(at):len(at)], mods+internal.Escaped(k))
+			if !v.descend(q, path, write, yield) {
+				return false
+			}
+		}
+
+	case d.typ.IsArr():
+		for i, v := range d.arrAll() {
+			path := append(at[:len(at):len(at)], mods+strconv.Itoa(i))
+			if !v.descend(q, path, write, yield) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// test reports whether d satisfies the predicate segment q. A missing query,
+// or values not comparable, do not satisfy it.
+func (d Datum) test(
+	q queryT,
+) bool {
+	left, op, literal := q.Predicate()
+
+	lq, err := internal.Parse(left)
+	if err != nil {
+		return false
+	}
+
+	v, err := d.get(lq)
+	if err != nil {
+		return false
+	}
+
+	if op == "" {
+		return true
+	}
+
+	lit := M(ofJson([]byte(literal)))
+
+	if op == "==" || op == "!=" {
+		same := v.eq(lit)
+		if v.isNum() && lit.isNum() {
+			same = v.num().Cmp(lit.num()) == 0
+		}
+
+		return same == (op == "==")
+	}
+
+	c, err := v.cmp(lit)
+	if err != nil {
+		return false
+	}
+
+	switch op {
+	case "<":
+		return c < 0
+
+	case "<=":
+		return c <= 0
+
+	case ">":
+		return c > 0
+
+	case ">=":
+		return c >= 0
+
+	default:
+		panic(EF("unreachable: unknown predicate op: %s", op))
+	}
+}
+
+func hasPatternFrom(
+	q queryT,
+) bool {
+	for at := q; ; at = at.Next() {
+		f := at.Flags()
+		if f.IsWildcard() || f.IsDescent() || f.IsSlice() || f.IsPredicate() {
+			return true
+		}
+
+		if f.IsLeaf() {
+			return false
+		}
+	}
+}
+
+// segmentOf is the single segment q, with its modifiers, as it is written.
+func segmentOf(
+	q queryT,
+) string {
+	qf := q.Flags()
+	mods := qf.ReconstructPreMod()
+
+	switch {
+	case qf.IsSelf(), qf.IsAppend():
+		return mods
+
+	case qf.IsArr():
+		return mods + q.Attr()
+
+	default:
+		return mods + internal.Escaped(q.Attr())
+	}
+}
+
+func matchQuery(
+	path []string,
+) Query {
+	if len(path) == 0 {
+		return Query(cmd.Self.String())
+	}
+
+	return Query(strings.Join(path, cmd.Sep.String()))
+}
+
+func newDataWriteIndeterministicError(
+	query queryT,
+) error {
+	return newDataWriteError(
+		query,
+		ErrCodeDataWriteIndeterministicQuery,
+		"query matches no single path",
+	)
+}
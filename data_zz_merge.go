@@ -0,0 +1,299 @@
+package giraffe
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+	"github.com/hkoosha/giraffe/zebra/z"
+)
+
+func (d Datum) merge(
+	m Merging,
+	right []Datum,
+) (Datum, error) {
+	var err error
+
+	fin := d
+	for _, r := range right {
+		fin, err = fin.merge0(m, r, []string{})
+		if err != nil {
+			return OfErr(), err
+		}
+	}
+
+	return fin, nil
+}
+
+func (d Datum) merge0(
+	m Merging,
+	right Datum,
+	path []string,
+) (Datum, error) {
+	if d.typ != right.typ {
+		return m.conflict(
+			path,
+			d,
+			right,
+			newMergeIncompatibleTypesError(d.typ, right.typ),
+		)
+	}
+
+	switch {
+	case d.typ.IsObj():
+		fin := Of(d)
+
+		for _, k := range right.keysInOrder() {
+			v, _ := right.objAt(k)
+
+			if existing, ok := fin.objAt(k); ok {
+				nPath := append(slices.Clone(path), internal.Escaped(k))
+				var err error
+				if v, err = existing.merge0(m, v, nPath); err != nil {
+					return OfErr(), err
+				}
+			}
+
+			fin.putObj(k, v)
+		}
+
+		return fin, nil
+
+	case d.typ.IsArr():
+		return d.mergeArr(m, right, path)
+
+	default:
+		if !d.eq(right) {
+			return m.conflict(path, d, right, newMergeClashingKeysError(path))
+		}
+
+		return d, nil
+	}
+}
+
+func (m Merging) conflict(
+	path []string,
+	left Datum,
+	right Datum,
+	err error,
+) (Datum, error) {
+	switch m.policy {
+	case MergePolicyError:
+		return OfErr(), err
+
+	case MergePolicyLeftWins:
+		return left, nil
+
+	case MergePolicyRightWins:
+		return right, nil
+
+	case MergePolicyCollect:
+		return _newDatum(Arr, []Datum{left, right}), nil
+
+	case MergePolicyResolve:
+		q, qErr := pathQuery(path)
+		if qErr != nil {
+			return OfErr(), E(qErr, err)
+		}
+
+		resolved, rErr := m.resolver(q, left, right)
+		if rErr != nil {
+			return OfErr(), E(rErr, err)
+		}
+
+		return resolved, nil
+
+	default:
+		panic(EF("unreachable, unknown merge policy: %s", m.policy.String()))
+	}
+}
+
+func (d Datum) mergeArr(
+	m Merging,
+	right Datum,
+	path []string,
+) (Datum, error) {
+	var (
+		arr []Datum
+		err error
+	)
+
+	switch m.arr {
+	case ArrMergeIndex:
+		arr, err = mergeArrIndex(m, d.arr(), right.arr(), path)
+
+	case ArrMergeConcat:
+		arr = slices.Concat(d.arr(), right.arr())
+
+	case ArrMergeUnion:
+		arr = mergeArrUnion(d.arr(), right.arr())
+
+	case ArrMergeKeyed:
+		arr, err = mergeArrKeyed(m, d.arr(), right.arr(), path)
+
+	default:
+		panic(EF("unreachable, unknown array merge: %s", m.arr.String()))
+	}
+
+	if err != nil {
+		return OfErr(), err
+	}
+
+	return newArr(arr, d.isPersistent()), nil
+}
+
+func mergeArrIndex(
+	m Merging,
+	left []Datum,
+	right []Datum,
+	path []string,
+) ([]Datum, error) {
+	arr := make([]Datum, max(len(left), len(right)))
+
+	for i := range arr {
+		switch {
+		case i >= len(left):
+			arr[i] = right[i]
+
+		case i >= len(right):
+			arr[i] = left[i]
+
+		default:
+			nPath := append(slices.Clone(path), strconv.Itoa(i))
+
+			var err error
+			if arr[i], err = left[i].merge0(m, right[i], nPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return arr, nil
+}
+
+func mergeArrUnion(
+	left []Datum,
+	right []Datum,
+) []Datum {
+	arr := make([]Datum, 0, len(left)+len(right))
+
+	for _, it := range slices.Concat(left, right) {
+		if !slices.ContainsFunc(arr, it.eq) {
+			arr = append(arr, it)
+		}
+	}
+
+	return arr
+}
+
+func mergeArrKeyed(
+	m Merging,
+	left []Datum,
+	right []Datum,
+	path []string,
+) ([]Datum, error) {
+	if m.key == nil {
+		panic(EF("unreachable, keyed array merge without key"))
+	}
+
+	q := m.key.impl()
+
+	identities := func(arr []Datum) ([]Datum, error) {
+		return z.TryApplied(arr, func(it Datum) (Datum, error) {
+			if !it.typ.IsObj() {
+				return OfErr(), newMergeMissingIdentityError(path, *m.key)
+			}
+
+			id, err := it.get(q)
+			if err != nil {
+				return OfErr(), E(err, newMergeMissingIdentityError(path, *m.key))
+			}
+
+			return id, nil
+		})
+	}
+
+	lIds, err := identities(left)
+	if err != nil {
+		return nil, err
+	}
+
+	rIds, err := identities(right)
+	if err != nil {
+		return nil, err
+	}
+
+	arr := slices.Clone(left)
+
+	for i, r := range right {
+		at := slices.IndexFunc(lIds, rIds[i].eq)
+		if at < 0 {
+			// Later items of right with the same identity merge into this one.
+			arr = append(arr, r)
+			lIds = append(lIds, rIds[i])
+
+			continue
+		}
+
+		nPath := append(slices.Clone(path), strconv.Itoa(at))
+		if arr[at], err = arr[at].merge0(m, r, nPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return arr, nil
+}
+
+// =============================================================================.
+
+func newMergeIncompatibleTypesError(
+	left Type,
+	right Type,
+) error {
+	return newDataMakeError(
+		ErrCodeDataMergeIncompatibleTypes,
+		fmt.Sprintf(
+			"incompatible types on merge: left=%s, right=%s",
+			left.String(),
+			right.String(),
+		),
+	)
+}
+
+func newMergeClashingKeysError(
+	key []string,
+) error {
+	return newDataMakeError(
+		ErrCodeDataMergeClashingKeys,
+		"clashing keys: ["+mergePath(key)+"]",
+	)
+}
+
+func newMergeMissingIdentityError(
+	key []string,
+	identity Query,
+) error {
+	return newDataMakeError(
+		ErrCodeDataMergeMissingIdentity,
+		"array element without identity: ["+mergePath(key)+"], identity="+identity.String(),
+	)
+}
+
+func newMergeUnknownPolicyError(
+	policy string,
+) error {
+	return newDataMakeError(
+		ErrCodeDataMergeUnknownPolicy,
+		"unknown merge policy: "+policy,
+	)
+}
+
+func mergePath(
+	key []string,
+) string {
+	return strings.Join(key, cmd.Sep.String())
+}
@@ -0,0 +1,329 @@
+package giraffe
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/hkoosha/giraffe/core/serdes/gson"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// objOrdered is the representation of the objects keeping the insertion order
+// of their keys. The map is the one handed out by obj(), so keys may go stale
+// when the map is written directly, they are reconciled on read.
+type objOrdered struct {
+	m    map[string]Datum
+	keys []string
+}
+
+func newObjOrdered(
+	size int,
+) *objOrdered {
+	return &objOrdered{
+		m:    make(map[string]Datum, size),
+		keys: make([]string, 0, size),
+	}
+}
+
+func (o *objOrdered) put(
+	k string,
+	v Datum,
+) {
+	if _, ok := o.m[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+
+	o.m[k] = v
+}
+
+func (o *objOrdered) order() []string {
+	if len(o.keys) == len(o.m) {
+		return o.keys
+	}
+
+	seen := make(map[string]bool, len(o.m))
+	keys := make([]string, 0, len(o.m))
+
+	for _, k := range o.keys {
+		if _, ok := o.m[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	var missing []string
+	for k := range o.m {
+		if !seen[k] {
+			missing = append(missing, k)
+		}
+	}
+	slices.Sort(missing)
+
+	return append(keys, missing...)
+}
+
+// datum makes an object out of o, which is ordered only if asked to.
+func (o *objOrdered) datum(
+	ordered bool,
+) Datum {
+	if ordered {
+		return _newDatum(Obj, objOrdered{m: o.m, keys: o.keys})
+	}
+
+	return _newDatum(Obj, o.m)
+}
+
+// =====================================.
+
+func (d Datum) isOrdered() bool {
+	if !d.typ.IsObj() || d.typ.IsNil() {
+		return false
+	}
+
+	_, ok := d.deref().(objOrdered)
+
+	return ok
+}
+
+// putObj sets a key of an object in place, recording the key as the last one
+// if it is new.
+func (d *Datum) putObj(
+	k string,
+	v Datum,
+) {
+	if p, ok := d.deref().(objPersistent); ok {
+		a := any(objPersistent{m: p.m.Set(k, v)})
+		d.val = &a
+
+		return
+	}
+
+	o, ok := d.deref().(objOrdered)
+	if !ok {
+		d.obj()[k] = v
+
+		return
+	}
+
+	if _, exists := o.m[k]; !exists {
+		o.keys = append(o.order(), k)
+		a := any(o)
+		d.val = &a
+	}
+
+	o.m[k] = v
+}
+
+func (d *Datum) delObj(
+	k string,
+) {
+	if p, ok := d.deref().(objPersistent); ok {
+		a := any(objPersistent{m: p.m.Delete(k)})
+		d.val = &a
+
+		return
+	}
+
+	if o, ok := d.deref().(objOrdered); ok {
+		o.keys = slices.DeleteFunc(slices.Clone(o.order()), func(it string) bool {
+			return it == k
+		})
+		delete(o.m, k)
+		a := any(o)
+		d.val = &a
+
+		return
+	}
+
+	obj := d.obj()
+	delete(obj, k)
+	a := any(obj)
+	d.val = &a
+}
+
+// objClone is a shallow copy of an object, keeping its representation. A
+// persistent object is immutable, and is shared as is.
+func (d Datum) objClone() Datum {
+	if d.isPersistent() {
+		return d
+	}
+
+	if o, ok := d.deref().(objOrdered); ok {
+		return _newDatum(Obj, objOrdered{
+			m:    maps.Clone(o.m),
+			keys: slices.Clone(o.order()),
+		})
+	}
+
+	return _newDatum(Obj, maps.Clone(d.obj()))
+}
+
+// newObj makes an empty object, ordered if asked to or if ordering is enabled
+// globally.
+func newObj(
+	ordered bool,
+) Datum {
+	return newObjOrdered(0).datum(ordered || IsOrderedKeys())
+}
+
+func (d Datum) ordered(
+	ordered bool,
+) Datum {
+	switch {
+	case d.typ.IsErr() || d.typ.IsNil():
+		return d
+
+	case d.typ.IsArr():
+		arr := make([]Datum, d.len())
+		for i := range arr {
+			arr[i] = d.arrAt(i).ordered(ordered)
+		}
+
+		return _newDatum(Arr, arr)
+
+	case d.typ.IsObj():
+		keys := d.keysInOrder()
+		obj := newObjOrdered(len(keys))
+		for _, k := range keys {
+			v, _ := d.objAt(k)
+			obj.put(k, v.ordered(ordered))
+		}
+
+		return obj.datum(ordered)
+
+	default:
+		return M(of(d))
+	}
+}
+
+// =====================================.
+
+// rawOrdered is the raw form of an ordered object, encoding its keys in order.
+type rawOrdered struct {
+	keys []string
+	m    map[string]any
+}
+
+func (r rawOrdered) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+
+	for i, k := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		kJ, err := gson.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+
+		vJ, err := gson.Marshal(r.m[k])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(kJ)
+		buf.WriteByte(':')
+		buf.Write(vJ)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// =====================================.
+
+// ofJsonOrdered parses a JSON document keeping the order of the object keys.
+func ofJsonOrdered(
+	b []byte,
+) (Datum, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	d, err := ofJsonTokens(dec, 0)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	if _, err := dec.Token(); err == nil {
+		return OfErr(), newDataMakeUnmarshalError(EF("trailing data after json value"))
+	}
+
+	return d, nil
+}
+
+func ofJsonTokens(
+	dec *json.Decoder,
+	depth int,
+) (Datum, error) {
+	if depth > serdeMaxDepth {
+		return OfErr(), newDataMakeUnmarshalError(EF("nesting too deep"))
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return OfErr(), newDataMakeUnmarshalError(err)
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := newObjOrdered(0)
+		for dec.More() {
+			kTok, err := dec.Token()
+			if err != nil {
+				return OfErr(), newDataMakeUnmarshalError(err)
+			}
+
+			k, ok := kTok.(string)
+			if !ok {
+				panic(EF("unreachable, non-string object key: %v", kTok))
+			}
+
+			v, err := ofJsonTokens(dec, depth+1)
+			if err != nil {
+				return OfErr(), err
+			}
+
+			obj.put(k, v)
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		return obj.datum(true), nil
+
+	case json.Delim('['):
+		arr := []Datum{}
+		for dec.More() {
+			v, err := ofJsonTokens(dec, depth+1)
+			if err != nil {
+				return OfErr(), err
+			}
+
+			arr = append(arr, v)
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	case nil:
+		return _newDatum(Nil, nil), nil
+
+	default:
+		val, typ, err := _ofAny(tok, reflect.ValueOf(tok))
+		if err != nil {
+			return OfErr(), err
+		}
+
+		return _newDatum(typ, val), nil
+	}
+}
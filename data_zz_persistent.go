@@ -0,0 +1,217 @@
+package giraffe
+
+import (
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/hkoosha/giraffe/internal/persistent"
+)
+
+// objPersistent is the representation of the persistent objects. Being
+// immutable, it is shared as is by the copies of a datum, and every write makes
+// a new version of it.
+type objPersistent struct {
+	m persistent.Map[Datum]
+}
+
+// arrPersistent is the representation of the persistent arrays, see
+// objPersistent.
+type arrPersistent struct {
+	v persistent.Vector[Datum]
+}
+
+func (d Datum) isPersistent() bool {
+	if d.typ.IsNil() {
+		return false
+	}
+
+	switch d.deref().(type) {
+	case objPersistent, arrPersistent:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// persistent is d with every object and array in it persistent, or none.
+func (d Datum) persistent(
+	on bool,
+) Datum {
+	switch {
+	case d.typ.IsErr() || d.typ.IsNil():
+		return d
+
+	case d.typ.IsArr():
+		arr := make([]Datum, d.len())
+		for i := range arr {
+			arr[i] = d.arrAt(i).persistent(on)
+		}
+
+		return newArr(arr, on)
+
+	case d.typ.IsObj():
+		if !on {
+			obj := newObjOrdered(d.len())
+			for _, k := range d.keysInOrder() {
+				v, _ := d.objAt(k)
+				obj.put(k, v.persistent(on))
+			}
+
+			return obj.datum(d.isOrdered())
+		}
+
+		o := objPersistent{m: persistent.Map[Datum]{}}
+		for _, k := range d.keysInOrder() {
+			v, _ := d.objAt(k)
+			o.m = o.m.Set(k, v.persistent(on))
+		}
+
+		return _newDatum(Obj, o)
+
+	default:
+		return d
+	}
+}
+
+// persistentShallow is d as a persistent object or array, leaving what is in
+// it as is. Writing in place, as the writes of modify do, is only ever safe
+// on a copy of a datum, and the copy of a persistent datum shares what is in
+// it. Hence, the writes make each datum on their path persistent first.
+func (d Datum) persistentShallow() Datum {
+	switch {
+	case d.typ.isZero(), d.typ.IsErr(), d.typ.IsNil(), d.isPersistent():
+		return d
+
+	case d.typ.IsArr():
+		return newArr(d.arr(), true)
+
+	case d.typ.IsObj():
+		o := objPersistent{m: persistent.Map[Datum]{}}
+		for k, v := range d.obj() {
+			o.m = o.m.Set(k, v)
+		}
+
+		return _newDatum(Obj, o)
+
+	default:
+		return d
+	}
+}
+
+// newArr makes an array of arr, persistent if asked to.
+func newArr(
+	arr []Datum,
+	persist bool,
+) Datum {
+	if persist {
+		return _newDatum(Arr, arrPersistent{v: persistent.VectorOf(arr...)})
+	}
+
+	return _newDatum(Arr, arr)
+}
+
+// objAt reads a key of an object without materializing a persistent one.
+func (d Datum) objAt(
+	k string,
+) (Datum, bool) {
+	if o, ok := d.deref().(objPersistent); ok {
+		return o.m.Get(k)
+	}
+
+	v, ok := d.obj()[k]
+
+	return v, ok
+}
+
+// arrAt reads an index of an array without materializing a persistent one.
+func (d Datum) arrAt(
+	i int,
+) Datum {
+	if a, ok := d.deref().(arrPersistent); ok {
+		return a.v.Get(i)
+	}
+
+	return d.arr()[i]
+}
+
+// putArr sets an index of an array in place.
+func (d *Datum) putArr(
+	i int,
+	v Datum,
+) {
+	a, ok := d.deref().(arrPersistent)
+	if !ok {
+		d.arr()[i] = v
+
+		return
+	}
+
+	n := any(arrPersistent{v: a.v.Set(i, v)})
+	d.val = &n
+}
+
+// appendArr appends to an array in place.
+func (d *Datum) appendArr(
+	v Datum,
+) {
+	var n any
+	if a, ok := d.deref().(arrPersistent); ok {
+		n = arrPersistent{v: a.v.Append(v)}
+	} else {
+		n = append(d.arr(), v)
+	}
+
+	d.val = &n
+}
+
+// arrWith is a copy of an array with index i set to v, or v appended if i is
+// its length, keeping its representation and leaving d as is.
+func (d Datum) arrWith(
+	i int,
+	v Datum,
+) Datum {
+	fin := d
+	if !d.isPersistent() {
+		fin = newArr(slices.Clone(d.arr()), false)
+	}
+
+	if i == fin.len() {
+		fin.appendArr(v)
+	} else {
+		fin.putArr(i, v)
+	}
+
+	return fin
+}
+
+// arrAll iterates the items of an array without materializing a persistent
+// one.
+func (d Datum) arrAll() iter.Seq2[int, Datum] {
+	if a, ok := d.deref().(arrPersistent); ok {
+		return a.v.All()
+	}
+
+	return slices.All(d.arr())
+}
+
+// setArr replaces the items of an array in place, keeping its representation.
+func (d *Datum) setArr(
+	arr []Datum,
+) {
+	*d = newArr(arr, d.isPersistent())
+}
+
+func (o objPersistent) materialized() map[string]Datum {
+	return maps.Collect(o.m.All())
+}
+
+func (a arrPersistent) materialized() []Datum {
+	arr := make([]Datum, 0, a.v.Len())
+	for _, it := range a.v.All() {
+		arr = append(arr, it)
+	}
+
+	return slices.Clip(arr)
+}
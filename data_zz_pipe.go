@@ -0,0 +1,176 @@
+package giraffe
+
+import (
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal/pipeimpl"
+)
+
+func (d Datum) pipe(
+	spec string,
+) (Datum, error) {
+	p, err := pipeimpl.Parse(spec)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	specs := p.Specs()
+	fin := d
+
+	for i, step := range p.Steps() {
+		if fn, ok := pipeFn(specs[i]); ok {
+			fin, err = fn(fin)
+		} else {
+			fin, err = fin.get(step)
+		}
+
+		if err != nil {
+			return OfErr(), newPipeStageError(i, specs[i], err)
+		}
+	}
+
+	return fin, nil
+}
+
+func pipeFn(
+	name string,
+) (PipeFn, bool) {
+	pipeFns.RLock()
+	defer pipeFns.RUnlock()
+
+	fn, ok := pipeFns.m[name]
+
+	return fn, ok
+}
+
+var pipeFnNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func pipeFnNameValid(
+	name string,
+) error {
+	if !pipeFnNameRegex.MatchString(name) {
+		return newPipeFnInvalidError(name, "must be an identifier")
+	}
+
+	return nil
+}
+
+// =====================================.
+
+func pipeKeys(
+	d Datum,
+) (Datum, error) {
+	switch {
+	case d.typ.IsObj():
+		keys := d.keysInOrder()
+		arr := make([]Datum, len(keys))
+		for i, k := range keys {
+			arr[i] = _newDatum(Str, k)
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	case d.typ.IsArr():
+		arr := make([]Datum, len(d.arr()))
+		for i := range arr {
+			arr[i] = _newDatum(Int, big.NewInt(int64(i)))
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	default:
+		return OfErr(), newTypeCastError(d.typ, Obj)
+	}
+}
+
+func pipeLen(
+	d Datum,
+) (Datum, error) {
+	switch {
+	case d.typ.IsNil():
+		return _newDatum(Int, big.NewInt(0)), nil
+
+	case d.typ.IsObj(), d.typ.IsArr():
+		return _newDatum(Int, big.NewInt(int64(d.len()))), nil
+
+	case d.typ.IsStr():
+		return _newDatum(Int, big.NewInt(int64(utf8.RuneCountInString(M(d.Str()))))), nil
+
+	default:
+		return OfErr(), newTypeCastError(d.typ, Arr)
+	}
+}
+
+func pipeValues(
+	d Datum,
+) (Datum, error) {
+	switch {
+	case d.typ.IsObj():
+		obj := d.obj()
+		arr := make([]Datum, 0, len(obj))
+		for _, k := range d.keysInOrder() {
+			arr = append(arr, obj[k])
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	case d.typ.IsArr():
+		return d, nil
+
+	default:
+		return OfErr(), newTypeCastError(d.typ, Obj)
+	}
+}
+
+func pipeFlatten(
+	d Datum,
+) (Datum, error) {
+	if !d.typ.IsArr() {
+		return OfErr(), newTypeCastError(d.typ, Arr)
+	}
+
+	var flatten func([]Datum) []Datum
+	flatten = func(arr []Datum) []Datum {
+		var fin []Datum
+		for _, it := range arr {
+			if it.typ.IsArr() {
+				fin = append(fin, flatten(it.arr())...)
+			} else {
+				fin = append(fin, it)
+			}
+		}
+
+		return fin
+	}
+
+	return _newDatum(Arr, slices.Clip(append([]Datum{}, flatten(d.arr())...))), nil
+}
+
+// =============================================================================.
+
+func newPipeStageError(
+	stage int,
+	spec string,
+	err error,
+) error {
+	return E(err, newGiraffeError(
+		ErrCodePipeStageFailed,
+		"pipe stage failed: #"+strconv.Itoa(stage)+": "+strings.TrimSpace(spec),
+	))
+}
+
+func newPipeFnInvalidError(
+	name string,
+	reason string,
+) error {
+	return newGiraffeError(
+		ErrCodePipeFnInvalid,
+		"invalid pipe fn: "+strconv.Quote(name)+", "+reason,
+	)
+}
@@ -12,6 +12,42 @@ import (
 	"github.com/hkoosha/giraffe/internal/gstrings"
 )
 
+// indexIn is the index q addresses in an array of length n, with a negative
+// index counted from the end. The result may well be out of bounds.
+func indexIn(
+	q queryT,
+	n int,
+) int {
+	i := q.Index()
+	if q.Flags().IsNegative() {
+		i += n
+	}
+
+	return i
+}
+
+// isKeyIn reports whether q addresses the key q.Attr() in a datum of type dt,
+// rather than an index. An index but an append, against an object, is the key
+// it is written as, so that keys such as "0" or "-1" stay reachable, and a
+// negative index is a key against anything but an array.
+func isKeyIn(
+	q queryT,
+	dt Type,
+) bool {
+	qf := q.Flags()
+
+	switch {
+	case qf.IsObj():
+		return true
+
+	case !qf.IsArr(), qf.IsAppend():
+		return false
+
+	default:
+		return dt.IsObj() || qf.IsNegative() && !dt.IsArr()
+	}
+}
+
 func (d Datum) hasShallow(
 	q queryT,
 ) bool {
@@ -27,18 +63,24 @@ func (d Datum) hasShallow(
 	}
 
 	switch {
-	case qf.IsArr():
-		return dt.IsArr() && 0 <= q.Index() && q.Index() < len(d.arr())
-
-	case qf.IsObj():
+	case isKeyIn(q, dt):
 		if !dt.IsObj() {
 			return false
 		}
 
-		_, ok := d.obj()[q.Attr()]
+		_, ok := d.objAt(q.Attr())
 
 		return ok
 
+	case qf.IsArr():
+		if !dt.IsArr() {
+			return false
+		}
+
+		i := indexIn(q, d.len())
+
+		return 0 <= i && i < d.len()
+
 	default:
 		panic(EF("unreachable, unknown query type: %s", q.String()))
 	}
@@ -55,6 +97,14 @@ func (d Datum) tryObj() (map[string]Datum, error) {
 		return nil, newTypeCastError(dt, Obj)
 
 	default:
+		switch o := d.deref().(type) {
+		case objOrdered:
+			return o.m, nil
+
+		case objPersistent:
+			return o.materialized(), nil
+		}
+
 		return cast[map[string]Datum](d), nil
 	}
 }
@@ -70,6 +120,10 @@ func (d Datum) tryArr() ([]Datum, error) {
 		return nil, newTypeCastError(dt, Arr)
 
 	default:
+		if a, ok := d.deref().(arrPersistent); ok {
+			return a.materialized(), nil
+		}
+
 		return cast[[]Datum](d), nil
 	}
 }
@@ -82,9 +136,17 @@ func (d Datum) tryLen() (int, error) {
 		return -1, newNilError()
 
 	case dt.IsArr():
+		if a, ok := d.deref().(arrPersistent); ok {
+			return a.v.Len(), nil
+		}
+
 		return len(d.arr()), nil
 
 	case dt.IsObj():
+		if o, ok := d.deref().(objPersistent); ok {
+			return o.m.Len(), nil
+		}
+
 		return len(d.obj()), nil
 
 	default:
@@ -96,6 +158,33 @@ func (d Datum) obj() map[string]Datum {
 	return M(d.tryObj())
 }
 
+// keysInOrder returns the keys of an object in the order they are serialized,
+// which is sorted unless the object is ordered.
+func (d Datum) keysInOrder() []string {
+	switch o := d.deref().(type) {
+	case objOrdered:
+		return o.order()
+
+	case objPersistent:
+		keys := make([]string, 0, o.m.Len())
+		for k := range o.m.All() {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		return keys
+	}
+
+	obj := d.obj()
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
 func (d Datum) arr() []Datum {
 	return M(d.tryArr())
 }
@@ -107,17 +196,20 @@ func (d Datum) len() int {
 func (d Datum) get(
 	q queryT,
 ) (Datum, error) {
-	// 	q, err := q.Resolved(d.resolver)
+	q, err := d.resolved(q)
+	if err != nil {
+		return OfErr(), err
+	}
 
 	qf := q.Flags()
 	dt := d.typ
 
 	switch {
 	case qf.IsIndeterministic():
-		return OfErr(), newDataReadOnlyError(q)
+		return OfErr(), newDataReadIndeterministicQueryError(q)
 
 	case !qf.IsReadonly():
-		return OfErr(), newDataReadIndeterministicQueryError(q)
+		return OfErr(), newDataReadOnlyError(q)
 
 	case qf.IsSelf():
 		if qf.IsLeaf() {
@@ -127,20 +219,20 @@ func (d Datum) get(
 		return d.get(q.Next())
 
 	case qf.IsArr() && dt.IsArr():
-		v := d.arr()
-		i := q.Index()
+		n := d.len()
+		i := indexIn(q, n)
 
 		switch {
-		case i >= len(v):
+		case i < 0, i >= n:
 			return OfErr(), newDataReadOutOfBoundsError(q)
 		case qf.IsLeaf():
-			return v[i], nil
+			return d.arrAt(i), nil
 		default:
-			return v[i].get(q.Next())
+			return d.arrAt(i).get(q.Next())
 		}
 
-	case qf.IsObj() && dt.IsObj():
-		v, ok := d.obj()[q.Attr()]
+	case isKeyIn(q, dt) && dt.IsObj():
+		v, ok := d.objAt(q.Attr())
 		if !ok {
 			return OfErr(), newDataReadMissingKeyError(q)
 		} else if qf.IsLeaf() {
@@ -173,7 +265,28 @@ func (d Datum) resolver(
 		return "", err
 	}
 
-	return dd.Str()
+	switch {
+	case dd.typ.IsInt():
+		return M(dd.Int()).String(), nil
+
+	case dd.typ.IsStr():
+		return internal.Escaped(M(dd.Str())), nil
+
+	default:
+		return "", newTypeCastError(dd.typ, Str)
+	}
+}
+
+// resolved substitutes the bracketed sub-queries of a dynamic query with their
+// values in d: a string becomes a key and an integer an index.
+func (d Datum) resolved(
+	q queryT,
+) (queryT, error) {
+	if !q.Flags().IsDyn() {
+		return q, nil
+	}
+
+	return q.Resolved(d.resolver)
 }
 
 func (d Datum) tree() []queryT {
@@ -280,6 +393,15 @@ func newDataReadIntegerOverflowError(
 	)
 }
 
+func newDataReadFloatOverflowError(
+	need reflect.Type,
+) error {
+	return newGiraffeError(
+		ErrCodeOverflowError,
+		"float does not fit: target="+need.String(),
+	)
+}
+
 func newDataReadUnexpectedTypeError(
 	query queryT,
 	expecting Type,
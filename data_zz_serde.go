@@ -0,0 +1,165 @@
+package giraffe
+
+import (
+	"math/big"
+	"strings"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// serdeMaxDepth bounds the nesting of decoded documents, so that a malicious
+// payload can not exhaust the stack.
+const serdeMaxDepth = 1024
+
+// ofBigInt keeps the representation of the ints which fit in int64 identical
+// to the ones read from JSON, so that Datum.Eq holds between the formats.
+func ofBigInt(
+	i *big.Int,
+) Datum {
+	if i.IsInt64() {
+		return _newDatum(Int, big.NewInt(i.Int64()))
+	}
+
+	return _newDatum(Int, i)
+}
+
+// fltText formats f so that it is never read back as an int, and so that
+// parseFlt reads it back as it is. The shortest text of f is not always enough,
+// as next to powers of two or past the precision of float64, in which case f
+// is written out exactly.
+func fltText(
+	f *big.Float,
+) string {
+	text := fltTextOf(f, -1)
+	if f.IsInf() {
+		return text
+	}
+
+	if back, err := parseFlt(text); err == nil && _newDatum(Flt, back).eq(_newDatum(Flt, f)) {
+		return text
+	}
+
+	// f is mant * 2^exp, its exact decimal text having at most as many digits
+	// as mant and 5^-exp, or 2^exp, together.
+	prec := int(f.MinPrec())
+	exp := f.MantExp(nil) - prec
+
+	return fltTextOf(f, (prec*30103+max(exp, -exp)*69898)/100000+2)
+}
+
+func fltTextOf(
+	f *big.Float,
+	digits int,
+) string {
+	text := f.Text('g', digits)
+	if !strings.ContainsAny(text, ".eEInf") {
+		text += ".0"
+	}
+
+	return text
+}
+
+// parseFlt reads the decimal text of a float at a precision that fits its
+// digits, each carrying less than 4 bits, see fltOf.
+func parseFlt(
+	text string,
+) (*big.Float, error) {
+	f, _, err := big.ParseFloat(text, 10, max(53, uint(4*len(text))), big.ToNearestEven)
+	if err != nil {
+		return nil, E(err)
+	}
+
+	return fltOf(f), nil
+}
+
+// fltOf keeps f, read off a document, at the precision of its value, never
+// below the one of float64, and as a float64 is read if it is one, so that
+// Datum.Eq holds between the formats. The formats keep the value of a float,
+// not its precision, so a float at any other precision reads back equal by
+// Cmp only, and by Datum.Eq across any later round trip.
+func fltOf(
+	f *big.Float,
+) *big.Float {
+	if f64, acc := f.Float64(); acc == big.Exact {
+		return big.NewFloat(f64)
+	}
+
+	return f.SetPrec(max(53, f.MinPrec()))
+}
+
+// =====================================.
+
+type serdeReader struct {
+	b  []byte
+	at int
+}
+
+func (r *serdeReader) remaining() int {
+	return len(r.b) - r.at
+}
+
+func (r *serdeReader) byte() (byte, error) {
+	if r.at >= len(r.b) {
+		return 0, newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	r.at++
+
+	return r.b[r.at-1], nil
+}
+
+func (r *serdeReader) peek() (byte, error) {
+	if r.at >= len(r.b) {
+		return 0, newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	return r.b[r.at], nil
+}
+
+func (r *serdeReader) take(
+	n uint64,
+) ([]byte, error) {
+	if n > uint64(r.remaining()) {
+		return nil, newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	r.at += int(n)
+
+	return r.b[r.at-int(n) : r.at], nil
+}
+
+func (r *serdeReader) uint(
+	size int,
+) (uint64, error) {
+	b, err := r.take(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+
+	var n uint64
+	for _, it := range b {
+		n = n<<8 | uint64(it)
+	}
+
+	return n, nil
+}
+
+func (r *serdeReader) end() error {
+	if r.remaining() != 0 {
+		return newDataMakeUnmarshalError(EF("trailing data: %d bytes", r.remaining()))
+	}
+
+	return nil
+}
+
+func appendUint(
+	buf []byte,
+	n uint64,
+	size int,
+) []byte {
+	for i := size - 1; i >= 0; i-- {
+		buf = append(buf, byte(n>>(8*i)))
+	}
+
+	return buf
+}
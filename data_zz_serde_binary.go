@@ -0,0 +1,383 @@
+package giraffe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// The binary encoding is the magic and the version, followed by a single
+// value. Each value is a tag byte, followed by:
+//   - nothing, for nil and the bools,
+//   - a zigzag varint, for the ints fitting in 64 bits,
+//   - the float64 bits, little endian, for the floats of 53 bits precision,
+//   - a uvarint length and the gob encoding, for the big ints and floats,
+//   - a uvarint length and the bytes, for the strings,
+//   - a uvarint count and the items, for the arrays,
+//   - a uvarint count and the keys, each a uvarint length and the bytes,
+//     followed by its value, for the objects.
+const (
+	binMagic0  byte = 'g'
+	binMagic1  byte = 'd'
+	binVersion byte = 1
+	binHeader       = 3
+
+	binNil        byte = 0x00
+	binFalse      byte = 0x01
+	binTrue       byte = 0x02
+	binInt        byte = 0x03
+	binBigInt     byte = 0x04
+	binFlt        byte = 0x05
+	binBigFlt     byte = 0x06
+	binStr        byte = 0x07
+	binArr        byte = 0x08
+	binObj        byte = 0x09
+	binObjOrdered byte = 0x0a
+
+	binFltPrec = 53
+)
+
+// datumBinarySerde keeps the type, the precision and the order of keys of
+// the ordered objects exactly.
+type datumBinarySerde struct{}
+
+func (s datumBinarySerde) Write(v Datum) ([]byte, error) {
+	return v.bin([]byte{binMagic0, binMagic1, binVersion})
+}
+
+func (s datumBinarySerde) Read(b []byte) (Datum, error) {
+	r := &serdeReader{b: b, at: 0}
+
+	header, err := r.take(binHeader)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	switch {
+	case header[0] != binMagic0 || header[1] != binMagic1:
+		return OfErr(), newDataMakeUnmarshalError(EF("not a binary datum"))
+
+	case header[2] != binVersion:
+		return OfErr(), newDataMakeUnmarshalError(EF("unsupported binary datum version: %d", header[2]))
+	}
+
+	d, err := r.bin(0)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	if err := r.end(); err != nil {
+		return OfErr(), err
+	}
+
+	return d, nil
+}
+
+func (s datumBinarySerde) StreamTo(w io.Writer, v Datum) error {
+	b, err := s.Write(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return E(err)
+}
+
+func (s datumBinarySerde) StreamFrom(r io.Reader) (Datum, error) {
+	payload := new(bytes.Buffer)
+	if _, err := io.Copy(payload, r); err != nil {
+		return OfErr(), E(err)
+	}
+
+	return s.Read(payload.Bytes())
+}
+
+// =====================================.
+
+func binBytes(
+	buf []byte,
+	b []byte,
+) []byte {
+	return append(binary.AppendUvarint(buf, uint64(len(b))), b...)
+}
+
+func (d Datum) bin(
+	buf []byte,
+) ([]byte, error) {
+	switch {
+	case d.typ.IsErr():
+		return nil, newInvalidDatumError()
+
+	case d.typ.IsNil():
+		return append(buf, binNil), nil
+
+	case d.typ.IsBln():
+		if cast[bool](d) {
+			return append(buf, binTrue), nil
+		}
+
+		return append(buf, binFalse), nil
+
+	case d.typ.IsInt():
+		i := cast[*big.Int](d)
+		if i.IsInt64() {
+			return binary.AppendVarint(append(buf, binInt), i.Int64()), nil
+		}
+
+		b, err := i.GobEncode()
+		if err != nil {
+			return nil, E(err)
+		}
+
+		return binBytes(append(buf, binBigInt), b), nil
+
+	case d.typ.IsFlt():
+		f := cast[*big.Float](d)
+		if f64, acc := f.Float64(); acc == big.Exact && f.Prec() == binFltPrec && f.Mode() == big.ToNearestEven {
+			return binary.LittleEndian.AppendUint64(append(buf, binFlt), math.Float64bits(f64)), nil
+		}
+
+		b, err := f.GobEncode()
+		if err != nil {
+			return nil, E(err)
+		}
+
+		return binBytes(append(buf, binBigFlt), b), nil
+
+	case d.typ.IsStr():
+		return binBytes(append(buf, binStr), []byte(cast[string](d))), nil
+
+	case d.typ.IsArr():
+		n := d.len()
+		buf = binary.AppendUvarint(append(buf, binArr), uint64(n))
+		for i := range n {
+			var err error
+			if buf, err = d.arrAt(i).bin(buf); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	case d.typ.IsObj():
+		tag := binObj
+		if d.isOrdered() {
+			tag = binObjOrdered
+		}
+
+		keys := d.keysInOrder()
+		buf = binary.AppendUvarint(append(buf, tag), uint64(len(keys)))
+		for _, k := range keys {
+			buf = binBytes(buf, []byte(k))
+
+			v, _ := d.objAt(k)
+
+			var err error
+			if buf, err = v.bin(buf); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	default:
+		panic(EF("unreachable, unknown datum type: %s", d.typ.String()))
+	}
+}
+
+// =====================================.
+
+func (r *serdeReader) uvarint() (uint64, error) {
+	n, size := binary.Uvarint(r.b[r.at:])
+	if size <= 0 {
+		return 0, newDataMakeUnmarshalError(EF("invalid varint"))
+	}
+
+	r.at += size
+
+	return n, nil
+}
+
+func (r *serdeReader) binBytes() ([]byte, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.take(n)
+}
+
+func (r *serdeReader) bin(
+	depth int,
+) (Datum, error) {
+	if depth > serdeMaxDepth {
+		return OfErr(), newDataMakeUnmarshalError(EF("nesting too deep"))
+	}
+
+	tag, err := r.byte()
+	if err != nil {
+		return OfErr(), err
+	}
+
+	switch tag {
+	case binNil:
+		return _newDatum(Nil, nil), nil
+
+	case binFalse:
+		return _newDatum(Bln, false), nil
+
+	case binTrue:
+		return _newDatum(Bln, true), nil
+
+	case binInt:
+		i, size := binary.Varint(r.b[r.at:])
+		if size <= 0 {
+			return OfErr(), newDataMakeUnmarshalError(EF("invalid varint"))
+		}
+
+		r.at += size
+
+		return _newDatum(Int, big.NewInt(i)), nil
+
+	case binBigInt:
+		b, err := r.binBytes()
+		if err != nil {
+			return OfErr(), err
+		}
+
+		i := new(big.Int)
+		if err := i.GobDecode(b); err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		return ofBigInt(i), nil
+
+	case binFlt:
+		n, err := r.take(8)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		f := math.Float64frombits(binary.LittleEndian.Uint64(n))
+		if math.IsNaN(f) {
+			return OfErr(), newDataMakeUnmarshalError(EF("nan is not supported"))
+		}
+
+		return _newDatum(Flt, big.NewFloat(f)), nil
+
+	case binBigFlt:
+		b, err := r.binBytes()
+		if err != nil {
+			return OfErr(), err
+		}
+
+		// GobDecode takes rounding modes, accuracies and forms out of range,
+		// making floats which panic once used.
+		if len(b) > 1 && (b[1]>>5 > byte(big.ToPositiveInf) || b[1]>>3&3 > 2 || b[1]>>1&3 > 2) {
+			return OfErr(), newDataMakeUnmarshalError(EF("invalid binary big float"))
+		}
+
+		f := new(big.Float)
+		if err := f.GobDecode(b); err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		// As written as binFlt.
+		if f64, acc := f.Float64(); acc == big.Exact && f.Prec() == binFltPrec && f.Mode() == big.ToNearestEven {
+			return _newDatum(Flt, big.NewFloat(f64)), nil
+		}
+
+		return _newDatum(Flt, f), nil
+
+	case binStr:
+		b, err := r.binBytes()
+		if err != nil {
+			return OfErr(), err
+		}
+
+		if !utf8.Valid(b) {
+			return OfErr(), newDataMakeUnmarshalError(EF("invalid utf-8 in binary str"))
+		}
+
+		return _newDatum(Str, string(b)), nil
+
+	case binArr:
+		return r.binArr(depth)
+
+	case binObj, binObjOrdered:
+		return r.binObj(tag == binObjOrdered, depth)
+
+	default:
+		return OfErr(), newDataMakeUnmarshalError(EF("unsupported binary datum tag: 0x%02x", tag))
+	}
+}
+
+func (r *serdeReader) binArr(
+	depth int,
+) (Datum, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return OfErr(), err
+	}
+
+	// Every item takes at least one byte.
+	if n > uint64(r.remaining()) {
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	arr := make([]Datum, n)
+	for i := range arr {
+		if arr[i], err = r.bin(depth + 1); err != nil {
+			return OfErr(), err
+		}
+	}
+
+	return _newDatum(Arr, arr), nil
+}
+
+func (r *serdeReader) binObj(
+	ordered bool,
+	depth int,
+) (Datum, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return OfErr(), err
+	}
+
+	// Every entry takes at least two bytes.
+	if n > uint64(r.remaining()/2) {
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	obj := newObjOrdered(int(n))
+	for range n {
+		b, err := r.binBytes()
+		if err != nil {
+			return OfErr(), err
+		}
+
+		if !utf8.Valid(b) {
+			return OfErr(), newDataMakeUnmarshalError(EF("invalid utf-8 in binary key"))
+		}
+
+		k := string(b)
+		if _, ok := obj.m[k]; ok {
+			return OfErr(), newDataMakeDuplicatedObjKeyError(k)
+		}
+
+		v, err := r.bin(depth + 1)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		obj.put(k, v)
+	}
+
+	return obj.datum(ordered || IsOrderedKeys()), nil
+}
@@ -0,0 +1,560 @@
+package giraffe
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/big"
+	"unicode/utf8"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// RFC 8949.
+const (
+	cborMajorUint  byte = 0
+	cborMajorNint  byte = 1
+	cborMajorBytes byte = 2
+	cborMajorText  byte = 3
+	cborMajorArr   byte = 4
+	cborMajorMap   byte = 5
+	cborMajorTag   byte = 6
+	cborMajorOther byte = 7
+
+	cborFalse     byte = 0xf4
+	cborTrue      byte = 0xf5
+	cborNull      byte = 0xf6
+	cborUndefined byte = 0xf7
+	cborFloat64   byte = 0xfb
+	cborBreak     byte = 0xff
+
+	cborInfoIndefinite byte = 31
+
+	cborTagPosBignum  uint64 = 2
+	cborTagNegBignum  uint64 = 3
+	cborTagDecimal    uint64 = 4
+	cborTagBigfloat   uint64 = 5
+	cborMaxExp        int64  = math.MaxInt32
+	cborMinExp        int64  = math.MinInt32
+	cborBigfloatItems        = 2
+)
+
+// datumCborSerde encodes ints beyond 64 bits as bignums and floats which are
+// not exactly representable as float64 as bigfloats.
+type datumCborSerde struct{}
+
+func (s datumCborSerde) Write(v Datum) ([]byte, error) {
+	return v.cbor(nil)
+}
+
+func (s datumCborSerde) Read(b []byte) (Datum, error) {
+	r := &serdeReader{b: b, at: 0}
+
+	d, err := r.cbor(0)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	if err := r.end(); err != nil {
+		return OfErr(), err
+	}
+
+	return d, nil
+}
+
+func (s datumCborSerde) StreamTo(w io.Writer, v Datum) error {
+	b, err := s.Write(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return E(err)
+}
+
+func (s datumCborSerde) StreamFrom(r io.Reader) (Datum, error) {
+	payload := new(bytes.Buffer)
+	if _, err := io.Copy(payload, r); err != nil {
+		return OfErr(), E(err)
+	}
+
+	return s.Read(payload.Bytes())
+}
+
+// =====================================.
+
+func cborHead(
+	buf []byte,
+	major byte,
+	n uint64,
+) []byte {
+	major <<= 5
+
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return appendUint(append(buf, major|24), n, 1)
+	case n <= math.MaxUint16:
+		return appendUint(append(buf, major|25), n, 2)
+	case n <= math.MaxUint32:
+		return appendUint(append(buf, major|26), n, 4)
+	default:
+		return appendUint(append(buf, major|27), n, 8)
+	}
+}
+
+func cborInt(
+	buf []byte,
+	i *big.Int,
+) []byte {
+	if i.Sign() >= 0 {
+		if i.IsUint64() {
+			return cborHead(buf, cborMajorUint, i.Uint64())
+		}
+
+		buf = cborHead(buf, cborMajorTag, cborTagPosBignum)
+		b := i.Bytes()
+
+		return append(cborHead(buf, cborMajorBytes, uint64(len(b))), b...)
+	}
+
+	// Negative ints are encoded as -1-n.
+	n := new(big.Int).Not(i)
+	if n.IsUint64() {
+		return cborHead(buf, cborMajorNint, n.Uint64())
+	}
+
+	buf = cborHead(buf, cborMajorTag, cborTagNegBignum)
+	b := n.Bytes()
+
+	return append(cborHead(buf, cborMajorBytes, uint64(len(b))), b...)
+}
+
+func (d Datum) cbor(
+	buf []byte,
+) ([]byte, error) {
+	switch {
+	case d.typ.IsErr():
+		return nil, newInvalidDatumError()
+
+	case d.typ.IsNil():
+		return append(buf, cborNull), nil
+
+	case d.typ.IsBln():
+		if M(d.Bln()) {
+			return append(buf, cborTrue), nil
+		}
+
+		return append(buf, cborFalse), nil
+
+	case d.typ.IsInt():
+		return cborInt(buf, M(d.Int())), nil
+
+	case d.typ.IsFlt():
+		f := M(d.Flt())
+		if f64, acc := f.Float64(); acc == big.Exact {
+			return appendUint(append(buf, cborFloat64), math.Float64bits(f64), 8), nil
+		}
+
+		// Bigfloat: [exponent, mantissa] where value = mantissa * 2^exponent.
+		prec := int(f.MinPrec())
+		exp := f.MantExp(nil) - prec
+		mant, _ := new(big.Float).SetMantExp(f, -exp).Int(nil)
+
+		buf = cborHead(buf, cborMajorTag, cborTagBigfloat)
+		buf = cborHead(buf, cborMajorArr, cborBigfloatItems)
+		buf = cborInt(buf, big.NewInt(int64(exp)))
+
+		return cborInt(buf, mant), nil
+
+	case d.typ.IsStr():
+		s := M(d.Str())
+
+		return append(cborHead(buf, cborMajorText, uint64(len(s))), s...), nil
+
+	case d.typ.IsArr():
+		buf = cborHead(buf, cborMajorArr, uint64(len(d.arr())))
+		for _, it := range d.arr() {
+			var err error
+			if buf, err = it.cbor(buf); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	case d.typ.IsObj():
+		obj := d.obj()
+		buf = cborHead(buf, cborMajorMap, uint64(len(obj)))
+		for _, k := range d.keysInOrder() {
+			buf = append(cborHead(buf, cborMajorText, uint64(len(k))), k...)
+
+			var err error
+			if buf, err = obj[k].cbor(buf); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	default:
+		panic(EF("unreachable, unknown datum type: %s", d.typ.String()))
+	}
+}
+
+// =====================================.
+
+// cborHead reads the head of the next item, n is the argument of the head and
+// indefinite is set for the indefinite length items and break.
+func (r *serdeReader) cborHead() (byte, byte, uint64, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	major := b >> 5
+	info := b & 0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+
+	case info <= 27:
+		n, err := r.uint(1 << (info - 24))
+
+		return major, info, n, err
+
+	case info == cborInfoIndefinite:
+		return major, info, 0, nil
+
+	default:
+		return 0, 0, 0, newDataMakeUnmarshalError(EF("reserved cbor additional info: %d", info))
+	}
+}
+
+func (r *serdeReader) cborBreak() (bool, error) {
+	b, err := r.peek()
+	if err != nil {
+		return false, err
+	}
+
+	if b == cborBreak {
+		r.at++
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *serdeReader) cborBytes(
+	major byte,
+	info byte,
+	n uint64,
+) ([]byte, error) {
+	if info != cborInfoIndefinite {
+		return r.take(n)
+	}
+
+	var out []byte
+
+	for {
+		if done, err := r.cborBreak(); err != nil {
+			return nil, err
+		} else if done {
+			return out, nil
+		}
+
+		chunkMajor, chunkInfo, chunkN, err := r.cborHead()
+		if err != nil {
+			return nil, err
+		}
+
+		if chunkMajor != major || chunkInfo == cborInfoIndefinite {
+			return nil, newDataMakeUnmarshalError(EF("invalid cbor chunk"))
+		}
+
+		chunk, err := r.take(chunkN)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, chunk...)
+	}
+}
+
+func (r *serdeReader) cbor(
+	depth int,
+) (Datum, error) {
+	if depth > serdeMaxDepth {
+		return OfErr(), newDataMakeUnmarshalError(EF("nesting too deep"))
+	}
+
+	major, info, n, err := r.cborHead()
+	if err != nil {
+		return OfErr(), err
+	}
+
+	switch major {
+	case cborMajorUint:
+		return ofBigInt(new(big.Int).SetUint64(n)), nil
+
+	case cborMajorNint:
+		return ofBigInt(new(big.Int).Not(new(big.Int).SetUint64(n))), nil
+
+	case cborMajorBytes:
+		return OfErr(), newDataMakeUnmarshalError(EF("cbor byte strings are not supported"))
+
+	case cborMajorText:
+		b, err := r.cborBytes(major, info, n)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		if !utf8.Valid(b) {
+			return OfErr(), newDataMakeUnmarshalError(EF("invalid utf-8 in cbor text"))
+		}
+
+		return _newDatum(Str, string(b)), nil
+
+	case cborMajorArr:
+		return r.cborArr(info, n, depth)
+
+	case cborMajorMap:
+		return r.cborMap(info, n, depth)
+
+	case cborMajorTag:
+		return r.cborTag(n, depth)
+
+	default:
+		return r.cborOther(info, n)
+	}
+}
+
+func (r *serdeReader) cborArr(
+	info byte,
+	n uint64,
+	depth int,
+) (Datum, error) {
+	indefinite := info == cborInfoIndefinite
+
+	// Every item takes at least one byte.
+	if !indefinite && n > uint64(r.remaining()) {
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	var arr []Datum
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			if done, err := r.cborBreak(); err != nil {
+				return OfErr(), err
+			} else if done {
+				break
+			}
+		}
+
+		it, err := r.cbor(depth + 1)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		arr = append(arr, it)
+	}
+
+	if arr == nil {
+		arr = []Datum{}
+	}
+
+	return _newDatum(Arr, arr), nil
+}
+
+func (r *serdeReader) cborMap(
+	info byte,
+	n uint64,
+	depth int,
+) (Datum, error) {
+	indefinite := info == cborInfoIndefinite
+
+	if !indefinite && n > uint64(r.remaining()) {
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	obj := newObjOrdered(0)
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite {
+			if done, err := r.cborBreak(); err != nil {
+				return OfErr(), err
+			} else if done {
+				break
+			}
+		}
+
+		key, err := r.cbor(depth + 1)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		if !key.typ.IsStr() {
+			return OfErr(), newDataMakeUnmarshalError(EF("non-string cbor map key: %s", key.typ.String()))
+		}
+
+		k := M(key.Str())
+		if _, ok := obj.m[k]; ok {
+			return OfErr(), newDataMakeDuplicatedObjKeyError(k)
+		}
+
+		v, err := r.cbor(depth + 1)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		obj.put(k, v)
+	}
+
+	return obj.datum(IsOrderedKeys()), nil
+}
+
+func (r *serdeReader) cborTag(
+	tag uint64,
+	depth int,
+) (Datum, error) {
+	switch tag {
+	case cborTagPosBignum, cborTagNegBignum:
+		major, info, n, err := r.cborHead()
+		if err != nil {
+			return OfErr(), err
+		}
+
+		if major != cborMajorBytes {
+			return OfErr(), newDataMakeUnmarshalError(EF("cbor bignum must be a byte string"))
+		}
+
+		b, err := r.cborBytes(major, info, n)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		i := new(big.Int).SetBytes(b)
+		if tag == cborTagNegBignum {
+			i.Not(i)
+		}
+
+		return ofBigInt(i), nil
+
+	case cborTagDecimal, cborTagBigfloat:
+		exp, mant, err := r.cborExpMant(depth)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		prec := max(53, uint(mant.BitLen()))
+
+		if tag == cborTagBigfloat {
+			f := new(big.Float).SetPrec(prec).SetInt(mant)
+
+			return _newDatum(Flt, fltOf(f.SetMantExp(f, int(exp)))), nil
+		}
+
+		// Decimal fractions are rounded to the precision of their mantissa.
+		f, _, err := big.ParseFloat(mant.String()+"e"+big.NewInt(exp).String(), 10, prec, big.ToNearestEven)
+		if err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		return _newDatum(Flt, fltOf(f)), nil
+
+	default:
+		// Unknown tags are transparent.
+		return r.cbor(depth + 1)
+	}
+}
+
+func (r *serdeReader) cborExpMant(
+	depth int,
+) (int64, *big.Int, error) {
+	pair, err := r.cbor(depth + 1)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if !pair.typ.IsArr() || len(pair.arr()) != cborBigfloatItems ||
+		!pair.arr()[0].typ.IsInt() || !pair.arr()[1].typ.IsInt() {
+		return 0, nil, newDataMakeUnmarshalError(EF("cbor decimal or bigfloat must be [int, int]"))
+	}
+
+	exp := M(pair.arr()[0].Int())
+	if !exp.IsInt64() || exp.Int64() > cborMaxExp || exp.Int64() < cborMinExp {
+		return 0, nil, newDataMakeUnmarshalError(EF("cbor exponent out of range: %s", exp.String()))
+	}
+
+	return exp.Int64(), M(pair.arr()[1].Int()), nil
+}
+
+func (r *serdeReader) cborOther(
+	info byte,
+	n uint64,
+) (Datum, error) {
+	var f float64
+
+	switch cborMajorOther<<5 | info {
+	case cborFalse:
+		return _newDatum(Bln, false), nil
+
+	case cborTrue:
+		return _newDatum(Bln, true), nil
+
+	case cborNull, cborUndefined:
+		return _newDatum(Nil, nil), nil
+
+	case 0xf9:
+		f = halfToFloat64(uint16(n))
+
+	case 0xfa:
+		f = float64(math.Float32frombits(uint32(n)))
+
+	case cborFloat64:
+		f = math.Float64frombits(n)
+
+	case cborBreak:
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected cbor break"))
+
+	default:
+		return OfErr(), newDataMakeUnmarshalError(EF("unsupported cbor simple value: %d", n))
+	}
+
+	if math.IsNaN(f) {
+		return OfErr(), newDataMakeUnmarshalError(EF("nan is not supported"))
+	}
+
+	return _newDatum(Flt, big.NewFloat(f)), nil
+}
+
+func halfToFloat64(
+	h uint16,
+) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	var f float64
+
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+
+	return f
+}
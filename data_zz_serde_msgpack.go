@@ -0,0 +1,515 @@
+package giraffe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/big"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// https://github.com/msgpack/msgpack/blob/master/spec.md
+const (
+	msgpackNil     byte = 0xc0
+	msgpackFalse   byte = 0xc2
+	msgpackTrue    byte = 0xc3
+	msgpackFloat32 byte = 0xca
+	msgpackFloat64 byte = 0xcb
+	msgpackUint8   byte = 0xcc
+	msgpackUint16  byte = 0xcd
+	msgpackUint32  byte = 0xce
+	msgpackUint64  byte = 0xcf
+	msgpackInt8    byte = 0xd0
+	msgpackInt16   byte = 0xd1
+	msgpackInt32   byte = 0xd2
+	msgpackInt64   byte = 0xd3
+	msgpackStr8    byte = 0xd9
+	msgpackStr16   byte = 0xda
+	msgpackStr32   byte = 0xdb
+	msgpackArr16   byte = 0xdc
+	msgpackArr32   byte = 0xdd
+	msgpackMap16   byte = 0xde
+	msgpackMap32   byte = 0xdf
+	msgpackExt8    byte = 0xc7
+	msgpackExt16   byte = 0xc8
+	msgpackExt32   byte = 0xc9
+	msgpackFixExt1 byte = 0xd4
+	msgpackFixExt2 byte = 0xd5
+	msgpackFixExt4 byte = 0xd6
+	msgpackFixExt8 byte = 0xd7
+	msgpackFixExt  byte = 0xd8
+
+	msgpackFixStr byte = 0xa0
+	msgpackFixArr byte = 0x90
+	msgpackFixMap byte = 0x80
+
+	msgpackFixStrMax = 31
+	msgpackFixColMax = 15
+	msgpackFixIntMax = 127
+	msgpackNegIntMin = -32
+
+	// Application defined extensions, carrying the text form of the value.
+	msgpackExtBigInt   int8 = 1
+	msgpackExtBigFloat int8 = 2
+	msgpackExtTime     int8 = -1
+)
+
+// datumMsgpackSerde encodes ints beyond 64 bits and floats which are not
+// exactly representable as float64 as extension types 1 and 2 respectively.
+type datumMsgpackSerde struct{}
+
+func (s datumMsgpackSerde) Write(v Datum) ([]byte, error) {
+	return v.msgpack(nil)
+}
+
+func (s datumMsgpackSerde) Read(b []byte) (Datum, error) {
+	r := &serdeReader{b: b, at: 0}
+
+	d, err := r.msgpack(0)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	if err := r.end(); err != nil {
+		return OfErr(), err
+	}
+
+	return d, nil
+}
+
+func (s datumMsgpackSerde) StreamTo(w io.Writer, v Datum) error {
+	b, err := s.Write(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return E(err)
+}
+
+func (s datumMsgpackSerde) StreamFrom(r io.Reader) (Datum, error) {
+	payload := new(bytes.Buffer)
+	if _, err := io.Copy(payload, r); err != nil {
+		return OfErr(), E(err)
+	}
+
+	return s.Read(payload.Bytes())
+}
+
+// =====================================.
+
+func msgpackLen(
+	buf []byte,
+	n int,
+	fix byte,
+	fixMax int,
+	b8 byte,
+	b16 byte,
+	b32 byte,
+) []byte {
+	switch {
+	case n <= fixMax:
+		return append(buf, fix|byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		return appendUint(append(buf, b8), uint64(n), 1)
+	case n <= math.MaxUint16:
+		return appendUint(append(buf, b16), uint64(n), 2)
+	default:
+		return appendUint(append(buf, b32), uint64(n), 4)
+	}
+}
+
+func msgpackExt(
+	buf []byte,
+	typ int8,
+	payload []byte,
+) []byte {
+	switch n := len(payload); n {
+	case 1:
+		buf = append(buf, msgpackFixExt1)
+	case 2:
+		buf = append(buf, msgpackFixExt2)
+	case 4:
+		buf = append(buf, msgpackFixExt4)
+	case 8:
+		buf = append(buf, msgpackFixExt8)
+	case 16:
+		buf = append(buf, msgpackFixExt)
+	default:
+		buf = msgpackLen(buf, n, 0, -1, msgpackExt8, msgpackExt16, msgpackExt32)
+	}
+
+	return append(append(buf, byte(typ)), payload...)
+}
+
+func msgpackInt(
+	buf []byte,
+	i *big.Int,
+) []byte {
+	switch {
+	case i.IsInt64() && i.Sign() < 0:
+		v := i.Int64()
+
+		switch {
+		case v >= msgpackNegIntMin:
+			return append(buf, byte(v))
+		case v >= math.MinInt8:
+			return appendUint(append(buf, msgpackInt8), uint64(v), 1)
+		case v >= math.MinInt16:
+			return appendUint(append(buf, msgpackInt16), uint64(v), 2)
+		case v >= math.MinInt32:
+			return appendUint(append(buf, msgpackInt32), uint64(v), 4)
+		default:
+			return appendUint(append(buf, msgpackInt64), uint64(v), 8)
+		}
+
+	case i.IsUint64():
+		v := i.Uint64()
+
+		switch {
+		case v <= msgpackFixIntMax:
+			return append(buf, byte(v))
+		case v <= math.MaxUint8:
+			return appendUint(append(buf, msgpackUint8), v, 1)
+		case v <= math.MaxUint16:
+			return appendUint(append(buf, msgpackUint16), v, 2)
+		case v <= math.MaxUint32:
+			return appendUint(append(buf, msgpackUint32), v, 4)
+		default:
+			return appendUint(append(buf, msgpackUint64), v, 8)
+		}
+
+	default:
+		return msgpackExt(buf, msgpackExtBigInt, []byte(i.String()))
+	}
+}
+
+func (d Datum) msgpack(
+	buf []byte,
+) ([]byte, error) {
+	switch {
+	case d.typ.IsErr():
+		return nil, newInvalidDatumError()
+
+	case d.typ.IsNil():
+		return append(buf, msgpackNil), nil
+
+	case d.typ.IsBln():
+		if M(d.Bln()) {
+			return append(buf, msgpackTrue), nil
+		}
+
+		return append(buf, msgpackFalse), nil
+
+	case d.typ.IsInt():
+		return msgpackInt(buf, M(d.Int())), nil
+
+	case d.typ.IsFlt():
+		f := M(d.Flt())
+		if f64, acc := f.Float64(); acc == big.Exact {
+			return appendUint(append(buf, msgpackFloat64), math.Float64bits(f64), 8), nil
+		}
+
+		return msgpackExt(buf, msgpackExtBigFloat, []byte(f.Text('p', 0))), nil
+
+	case d.typ.IsStr():
+		s := M(d.Str())
+		buf = msgpackLen(buf, len(s), msgpackFixStr, msgpackFixStrMax, msgpackStr8, msgpackStr16, msgpackStr32)
+
+		return append(buf, s...), nil
+
+	case d.typ.IsArr():
+		buf = msgpackLen(buf, len(d.arr()), msgpackFixArr, msgpackFixColMax, 0, msgpackArr16, msgpackArr32)
+		for _, it := range d.arr() {
+			var err error
+			if buf, err = it.msgpack(buf); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	case d.typ.IsObj():
+		obj := d.obj()
+		buf = msgpackLen(buf, len(obj), msgpackFixMap, msgpackFixColMax, 0, msgpackMap16, msgpackMap32)
+		for _, k := range d.keysInOrder() {
+			buf = msgpackLen(buf, len(k), msgpackFixStr, msgpackFixStrMax, msgpackStr8, msgpackStr16, msgpackStr32)
+			buf = append(buf, k...)
+
+			var err error
+			if buf, err = obj[k].msgpack(buf); err != nil {
+				return nil, err
+			}
+		}
+
+		return buf, nil
+
+	default:
+		panic(EF("unreachable, unknown datum type: %s", d.typ.String()))
+	}
+}
+
+// =====================================.
+
+func (r *serdeReader) msgpack(
+	depth int,
+) (Datum, error) {
+	if depth > serdeMaxDepth {
+		return OfErr(), newDataMakeUnmarshalError(EF("nesting too deep"))
+	}
+
+	b, err := r.byte()
+	if err != nil {
+		return OfErr(), err
+	}
+
+	switch {
+	case b <= msgpackFixIntMax:
+		return _newDatum(Int, big.NewInt(int64(b))), nil
+
+	case b >= 0xe0:
+		return _newDatum(Int, big.NewInt(int64(int8(b)))), nil
+
+	case b&0xf0 == msgpackFixMap:
+		return r.msgpackMap(uint64(b&0x0f), depth)
+
+	case b&0xf0 == msgpackFixArr:
+		return r.msgpackArr(uint64(b&0x0f), depth)
+
+	case b&0xe0 == msgpackFixStr:
+		return r.msgpackStr(uint64(b & 0x1f))
+	}
+
+	switch b {
+	case msgpackNil:
+		return _newDatum(Nil, nil), nil
+
+	case msgpackFalse:
+		return _newDatum(Bln, false), nil
+
+	case msgpackTrue:
+		return _newDatum(Bln, true), nil
+
+	case msgpackUint8, msgpackUint16, msgpackUint32, msgpackUint64:
+		n, err := r.uint(1 << (b - msgpackUint8))
+		if err != nil {
+			return OfErr(), err
+		}
+
+		return ofBigInt(new(big.Int).SetUint64(n)), nil
+
+	case msgpackInt8, msgpackInt16, msgpackInt32, msgpackInt64:
+		size := 1 << (b - msgpackInt8)
+
+		n, err := r.uint(size)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		// Sign extend.
+		shift := 64 - 8*size
+
+		return _newDatum(Int, big.NewInt(int64(n<<shift)>>shift)), nil
+
+	case msgpackFloat32, msgpackFloat64:
+		var f float64
+
+		if b == msgpackFloat32 {
+			n, err := r.uint(4)
+			if err != nil {
+				return OfErr(), err
+			}
+			f = float64(math.Float32frombits(uint32(n)))
+		} else {
+			n, err := r.uint(8)
+			if err != nil {
+				return OfErr(), err
+			}
+			f = math.Float64frombits(n)
+		}
+
+		if math.IsNaN(f) {
+			return OfErr(), newDataMakeUnmarshalError(EF("nan is not supported"))
+		}
+
+		return _newDatum(Flt, big.NewFloat(f)), nil
+
+	case msgpackStr8, msgpackStr16, msgpackStr32:
+		n, err := r.uint(1 << (b - msgpackStr8))
+		if err != nil {
+			return OfErr(), err
+		}
+
+		return r.msgpackStr(n)
+
+	case msgpackArr16, msgpackArr32:
+		n, err := r.uint(2 << (b - msgpackArr16))
+		if err != nil {
+			return OfErr(), err
+		}
+
+		return r.msgpackArr(n, depth)
+
+	case msgpackMap16, msgpackMap32:
+		n, err := r.uint(2 << (b - msgpackMap16))
+		if err != nil {
+			return OfErr(), err
+		}
+
+		return r.msgpackMap(n, depth)
+
+	case msgpackFixExt1, msgpackFixExt2, msgpackFixExt4, msgpackFixExt8, msgpackFixExt:
+		return r.msgpackExt(uint64(1) << (b - msgpackFixExt1))
+
+	case msgpackExt8, msgpackExt16, msgpackExt32:
+		n, err := r.uint(1 << (b - msgpackExt8))
+		if err != nil {
+			return OfErr(), err
+		}
+
+		return r.msgpackExt(n)
+
+	default:
+		return OfErr(), newDataMakeUnmarshalError(EF("unsupported msgpack format: 0x%02x", b))
+	}
+}
+
+func (r *serdeReader) msgpackStr(
+	n uint64,
+) (Datum, error) {
+	b, err := r.take(n)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	if !utf8.Valid(b) {
+		return OfErr(), newDataMakeUnmarshalError(EF("invalid utf-8 in msgpack str"))
+	}
+
+	return _newDatum(Str, string(b)), nil
+}
+
+func (r *serdeReader) msgpackArr(
+	n uint64,
+	depth int,
+) (Datum, error) {
+	// Every item takes at least one byte.
+	if n > uint64(r.remaining()) {
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	arr := make([]Datum, n)
+	for i := range arr {
+		var err error
+		if arr[i], err = r.msgpack(depth + 1); err != nil {
+			return OfErr(), err
+		}
+	}
+
+	return _newDatum(Arr, arr), nil
+}
+
+func (r *serdeReader) msgpackMap(
+	n uint64,
+	depth int,
+) (Datum, error) {
+	if n > uint64(r.remaining()) {
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected end of input"))
+	}
+
+	obj := newObjOrdered(int(n))
+	for range n {
+		key, err := r.msgpack(depth + 1)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		if !key.typ.IsStr() {
+			return OfErr(), newDataMakeUnmarshalError(EF("non-string msgpack map key: %s", key.typ.String()))
+		}
+
+		k := M(key.Str())
+		if _, ok := obj.m[k]; ok {
+			return OfErr(), newDataMakeDuplicatedObjKeyError(k)
+		}
+
+		v, err := r.msgpack(depth + 1)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		obj.put(k, v)
+	}
+
+	return obj.datum(IsOrderedKeys()), nil
+}
+
+func (r *serdeReader) msgpackExt(
+	n uint64,
+) (Datum, error) {
+	typ, err := r.byte()
+	if err != nil {
+		return OfErr(), err
+	}
+
+	payload, err := r.take(n)
+	if err != nil {
+		return OfErr(), err
+	}
+
+	switch int8(typ) {
+	case msgpackExtBigInt:
+		i, ok := new(big.Int).SetString(string(payload), 10)
+		if !ok {
+			return OfErr(), newDataMakeUnmarshalError(EF("invalid msgpack big int"))
+		}
+
+		return ofBigInt(i), nil
+
+	case msgpackExtBigFloat:
+		// Each hex digit of the mantissa carries at most 4 bits.
+		f, _, err := big.ParseFloat(string(payload), 0, max(53, uint(4*len(payload))), big.ToNearestEven)
+		if err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		return _newDatum(Flt, fltOf(f)), nil
+
+	case msgpackExtTime:
+		return msgpackTime(payload)
+
+	default:
+		return OfErr(), newDataMakeUnmarshalError(EF("unsupported msgpack extension: %d", int8(typ)))
+	}
+}
+
+func msgpackTime(
+	payload []byte,
+) (Datum, error) {
+	var sec int64
+	var nsec int64
+
+	switch len(payload) {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(payload))
+
+	case 8:
+		v := binary.BigEndian.Uint64(payload)
+		nsec = int64(v >> 34)
+		sec = int64(v & (1<<34 - 1))
+
+	case 12:
+		nsec = int64(binary.BigEndian.Uint32(payload))
+		sec = int64(binary.BigEndian.Uint64(payload[4:]))
+
+	default:
+		return OfErr(), newDataMakeUnmarshalError(EF("invalid msgpack timestamp"))
+	}
+
+	return _newDatum(Str, time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano)), nil
+}
@@ -0,0 +1,174 @@
+package giraffe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// datumTomlSerde only supports objects at the root, as TOML documents are
+// tables. TOML has no null, and its integers are limited to 64 bits.
+// Date and time values are read as RFC 3339 strings. The decoder reads tables
+// into plain maps and the encoder writes maps sorted, so the order of keys is
+// lost both ways.
+type datumTomlSerde struct{}
+
+func (s datumTomlSerde) Write(v Datum) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := s.StreamTo(buf, v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s datumTomlSerde) Read(b []byte) (Datum, error) {
+	return s.StreamFrom(bytes.NewReader(b))
+}
+
+func (s datumTomlSerde) StreamTo(w io.Writer, v Datum) error {
+	if !v.typ.IsObj() {
+		return newDataMakeMarshalError(EF("toml root must be an object: %s", v.typ.String()))
+	}
+
+	plain, err := v.tomlPlain()
+	if err != nil {
+		return err
+	}
+
+	if err := toml.NewEncoder(w).Encode(plain); err != nil {
+		return newDataMakeMarshalError(err)
+	}
+
+	return nil
+}
+
+func (s datumTomlSerde) StreamFrom(r io.Reader) (Datum, error) {
+	var plain map[string]any
+	if err := toml.NewDecoder(r).Decode(&plain); err != nil {
+		return OfErr(), newDataMakeUnmarshalError(err)
+	}
+
+	return ofToml(plain)
+}
+
+// =====================================.
+
+func (d Datum) tomlPlain() (any, error) {
+	switch {
+	case d.typ.IsErr():
+		return nil, newInvalidDatumError()
+
+	case d.typ.IsNil():
+		return nil, newDataMakeMarshalError(EF("toml does not support null"))
+
+	case d.typ.IsBln():
+		return d.Bln()
+
+	case d.typ.IsStr():
+		return d.Str()
+
+	case d.typ.IsInt():
+		i := M(d.Int())
+		if !i.IsInt64() {
+			return nil, newDataMakeMarshalError(EF("toml int out of range: %s", i.String()))
+		}
+
+		return i.Int64(), nil
+
+	case d.typ.IsFlt():
+		flt := M(d.Flt())
+		f, acc := flt.Float64()
+		if acc != big.Exact {
+			return nil, newDataMakeMarshalError(EF("toml float out of range: %s", flt.Text('g', -1)))
+		}
+
+		return f, nil
+
+	case d.typ.IsArr():
+		arr := make([]any, len(d.arr()))
+		for i, it := range d.arr() {
+			var err error
+			if arr[i], err = it.tomlPlain(); err != nil {
+				return nil, err
+			}
+		}
+
+		return arr, nil
+
+	case d.typ.IsObj():
+		obj := make(map[string]any, len(d.obj()))
+		for k, it := range d.obj() {
+			var err error
+			if obj[k], err = it.tomlPlain(); err != nil {
+				return nil, err
+			}
+		}
+
+		return obj, nil
+
+	default:
+		panic(EF("unreachable, unknown datum type: %s", d.typ.String()))
+	}
+}
+
+func ofToml(
+	v any,
+) (Datum, error) {
+	switch t := v.(type) {
+	case map[string]any:
+		obj := make(map[string]Datum, len(t))
+		for k, it := range t {
+			var err error
+			if obj[k], err = ofToml(it); err != nil {
+				return OfErr(), err
+			}
+		}
+
+		return _newDatum(Obj, obj), nil
+
+	case []any:
+		arr := make([]Datum, len(t))
+		for i, it := range t {
+			var err error
+			if arr[i], err = ofToml(it); err != nil {
+				return OfErr(), err
+			}
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	case int64:
+		return _newDatum(Int, big.NewInt(t)), nil
+
+	case float64:
+		if math.IsNaN(t) {
+			return OfErr(), newDataMakeUnmarshalError(EF("nan is not supported"))
+		}
+
+		return _newDatum(Flt, big.NewFloat(t)), nil
+
+	case bool:
+		return _newDatum(Bln, t), nil
+
+	case string:
+		return _newDatum(Str, t), nil
+
+	case time.Time:
+		return _newDatum(Str, t.Format(time.RFC3339Nano)), nil
+
+	case fmt.Stringer:
+		// Local date, time and date-time.
+		return _newDatum(Str, t.String()), nil
+
+	default:
+		return OfErr(), newDataMakeUnmarshalError(EF("unexpected toml value: %T", v))
+	}
+}
@@ -0,0 +1,310 @@
+package giraffe
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+const (
+	yamlTagNull  = "!!null"
+	yamlTagBool  = "!!bool"
+	yamlTagInt   = "!!int"
+	yamlTagFloat = "!!float"
+	yamlTagStr   = "!!str"
+	yamlTagMerge = "!!merge"
+	yamlIndent   = 2
+)
+
+type datumYamlSerde struct{}
+
+func (s datumYamlSerde) Write(v Datum) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := s.StreamTo(buf, v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s datumYamlSerde) Read(b []byte) (Datum, error) {
+	return s.StreamFrom(bytes.NewReader(b))
+}
+
+func (s datumYamlSerde) StreamTo(w io.Writer, v Datum) error {
+	node, err := v.yamlNode()
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(yamlIndent)
+
+	if err := enc.Encode(node); err != nil {
+		return newDataMakeMarshalError(err)
+	}
+
+	return E(enc.Close())
+}
+
+// StreamFrom reads the first document of the stream.
+func (s datumYamlSerde) StreamFrom(r io.Reader) (Datum, error) {
+	var node yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&node); err != nil {
+		return OfErr(), newDataMakeUnmarshalError(err)
+	}
+
+	return ofYaml(&node, 0)
+}
+
+// =====================================.
+
+func (d Datum) yamlNode() (*yaml.Node, error) {
+	scalar := func(tag string, value string) *yaml.Node {
+		//nolint:exhaustruct
+		return &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   tag,
+			Value: value,
+		}
+	}
+
+	switch {
+	case d.typ.IsErr():
+		return nil, newInvalidDatumError()
+
+	case d.typ.IsNil():
+		return scalar(yamlTagNull, "null"), nil
+
+	case d.typ.IsBln():
+		return scalar(yamlTagBool, strconv.FormatBool(M(d.Bln()))), nil
+
+	case d.typ.IsInt():
+		return scalar(yamlTagInt, M(d.Int()).String()), nil
+
+	case d.typ.IsFlt():
+		f := M(d.Flt())
+		if f.IsInf() {
+			return scalar(yamlTagFloat, map[bool]string{true: "-.inf", false: ".inf"}[f.Signbit()]), nil
+		}
+
+		return scalar(yamlTagFloat, fltText(f)), nil
+
+	case d.typ.IsStr():
+		return scalar(yamlTagStr, M(d.Str())), nil
+
+	case d.typ.IsArr():
+		//nolint:exhaustruct
+		node := &yaml.Node{
+			Kind: yaml.SequenceNode,
+		}
+
+		for _, it := range d.arr() {
+			child, err := it.yamlNode()
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+
+		return node, nil
+
+	case d.typ.IsObj():
+		//nolint:exhaustruct
+		node := &yaml.Node{
+			Kind: yaml.MappingNode,
+		}
+
+		obj := d.obj()
+		for _, k := range d.keysInOrder() {
+			child, err := obj[k].yamlNode()
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, scalar(yamlTagStr, k), child)
+		}
+
+		return node, nil
+
+	default:
+		panic(EF("unreachable, unknown datum type: %s", d.typ.String()))
+	}
+}
+
+func ofYaml(
+	node *yaml.Node,
+	depth int,
+) (Datum, error) {
+	if depth > serdeMaxDepth {
+		return OfErr(), newDataMakeUnmarshalError(EF("nesting too deep"))
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return _newDatum(Nil, nil), nil
+		}
+
+		return ofYaml(node.Content[0], depth+1)
+
+	case yaml.AliasNode:
+		return ofYaml(node.Alias, depth+1)
+
+	case yaml.SequenceNode:
+		arr := make([]Datum, len(node.Content))
+		for i, it := range node.Content {
+			var err error
+			if arr[i], err = ofYaml(it, depth+1); err != nil {
+				return OfErr(), err
+			}
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	case yaml.MappingNode:
+		obj := newObjOrdered(len(node.Content) / 2)
+		if err := ofYamlMapping(node, obj, depth); err != nil {
+			return OfErr(), err
+		}
+
+		return obj.datum(IsOrderedKeys()), nil
+
+	case yaml.ScalarNode:
+		return ofYamlScalar(node)
+
+	default:
+		return OfErr(), newDataMakeUnmarshalError(EF("unsupported node kind: %d", node.Kind))
+	}
+}
+
+func ofYamlMapping(
+	node *yaml.Node,
+	obj *objOrdered,
+	depth int,
+) error {
+	var merged []*yaml.Node
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		if key.Kind != yaml.ScalarNode {
+			return newDataMakeUnmarshalError(EF("non-scalar mapping key at line %d", key.Line))
+		}
+
+		if key.ShortTag() == yamlTagMerge {
+			merged = append(merged, value)
+
+			continue
+		}
+
+		if _, ok := obj.m[key.Value]; ok {
+			return newDataMakeDuplicatedObjKeyError(key.Value)
+		}
+
+		v, err := ofYaml(value, depth+1)
+		if err != nil {
+			return err
+		}
+
+		obj.put(key.Value, v)
+	}
+
+	// Merged mappings never override the explicit keys.
+	for _, it := range merged {
+		for it.Kind == yaml.AliasNode {
+			it = it.Alias
+		}
+
+		sources := []*yaml.Node{it}
+		if it.Kind == yaml.SequenceNode {
+			sources = it.Content
+		}
+
+		for _, src := range sources {
+			for src.Kind == yaml.AliasNode {
+				src = src.Alias
+			}
+
+			if src.Kind != yaml.MappingNode {
+				return newDataMakeUnmarshalError(EF("merge of non-mapping at line %d", src.Line))
+			}
+
+			tmp := newObjOrdered(len(src.Content) / 2)
+			if err := ofYamlMapping(src, tmp, depth+1); err != nil {
+				return err
+			}
+
+			for _, k := range tmp.keys {
+				if _, ok := obj.m[k]; !ok {
+					obj.put(k, tmp.m[k])
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func ofYamlScalar(
+	node *yaml.Node,
+) (Datum, error) {
+	value := node.Value
+
+	switch node.ShortTag() {
+	case yamlTagNull:
+		return _newDatum(Nil, nil), nil
+
+	case yamlTagBool:
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		return _newDatum(Bln, b), nil
+
+	case yamlTagInt:
+		i, ok := new(big.Int).SetString(strings.ReplaceAll(value, "_", ""), 0)
+		if !ok {
+			return OfErr(), newDataMakeUnmarshalError(EF("invalid int: %s", value))
+		}
+
+		return ofBigInt(i), nil
+
+	case yamlTagFloat:
+		plain := strings.ReplaceAll(value, "_", "")
+
+		// Integers too large for int64 are resolved as floats by yaml.
+		if node.Style&yaml.TaggedStyle == 0 {
+			if i, ok := new(big.Int).SetString(plain, 10); ok {
+				return ofBigInt(i), nil
+			}
+		}
+
+		switch strings.ToLower(strings.TrimLeft(plain, "+")) {
+		case ".inf":
+			return _newDatum(Flt, big.NewFloat(0).SetInf(false)), nil
+		case "-.inf":
+			return _newDatum(Flt, big.NewFloat(0).SetInf(true)), nil
+		case ".nan":
+			return OfErr(), newDataMakeUnmarshalError(EF("nan is not supported"))
+		}
+
+		f, err := parseFlt(plain)
+		if err != nil {
+			return OfErr(), newDataMakeUnmarshalError(err)
+		}
+
+		return _newDatum(Flt, f), nil
+
+	default:
+		// Strings, timestamps and custom tags are kept verbatim.
+		return _newDatum(Str, value), nil
+	}
+}
@@ -0,0 +1,302 @@
+package giraffe
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+)
+
+type streamSeg struct {
+	key   string
+	idx   int
+	isArr bool
+}
+
+func (s streamSeg) matches(
+	o streamSeg,
+) bool {
+	if s.isArr && o.isArr {
+		return s.idx == o.idx
+	}
+
+	return !o.isArr && s.key == o.key
+}
+
+func streamSegsOf(
+	q Query,
+) ([]streamSeg, error) {
+	impl, err := internal.Parse(q.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var segs []streamSeg
+	for at := queryT(impl); ; at = at.Next() {
+		switch {
+		case at.Flags().IsPattern():
+			return nil, newStreamUnsupportedQueryError(q)
+
+		case at.Flags().IsArr():
+			segs = append(segs, streamSeg{key: at.Attr(), idx: at.Index(), isArr: true})
+
+		case at.Flags().IsObj():
+			segs = append(segs, streamSeg{key: at.Attr(), idx: -1, isArr: false})
+
+		default:
+			return nil, newStreamUnsupportedQueryError(q)
+		}
+
+		if at.Flags().IsLeaf() {
+			return segs, nil
+		}
+	}
+}
+
+func streamQuery(
+	path []streamSeg,
+) Query {
+	if len(path) == 0 {
+		return GQErr()
+	}
+
+	parts := make([]string, len(path))
+	for i, seg := range path {
+		if seg.isArr {
+			parts[i] = strconv.Itoa(seg.idx)
+		} else {
+			parts[i] = internal.Escaped(seg.key)
+		}
+	}
+
+	return Query(strings.Join(parts, cmd.Sep.String()))
+}
+
+// =====================================.
+
+func (s *DatumStream) next() (Datum, error) {
+	var raw json.RawMessage
+	if err := s.dec.Decode(&raw); err != nil {
+		return OfErr(), newStreamError(err)
+	}
+
+	return ofJson(raw)
+}
+
+type streamWalker struct {
+	dec        *json.Decoder
+	yield      func(Query, Datum) bool
+	projection [][]streamSeg
+	path       []streamSeg
+}
+
+type streamMatch uint8
+
+const (
+	streamMatchNone streamMatch = iota
+	streamMatchPrefix
+	streamMatchExact
+)
+
+func (w *streamWalker) match() streamMatch {
+	if w.projection == nil {
+		return streamMatchPrefix
+	}
+
+	fin := streamMatchNone
+
+	for _, p := range w.projection {
+		if len(p) < len(w.path) {
+			continue
+		}
+
+		matched := true
+		for i, seg := range w.path {
+			if !p[i].matches(seg) {
+				matched = false
+
+				break
+			}
+		}
+
+		switch {
+		case !matched:
+		case len(p) == len(w.path):
+			return streamMatchExact
+		default:
+			fin = streamMatchPrefix
+		}
+	}
+
+	return fin
+}
+
+// value walks the next value, returning false once the consumer stops.
+func (w *streamWalker) value() (bool, error) {
+	switch w.match() {
+	case streamMatchNone:
+		return true, w.skip()
+
+	case streamMatchExact:
+		var raw json.RawMessage
+		if err := w.dec.Decode(&raw); err != nil {
+			return false, newStreamError(err)
+		}
+
+		d, err := ofJson(raw)
+		if err != nil {
+			return false, err
+		}
+
+		return w.yield(streamQuery(w.path), d), nil
+
+	case streamMatchPrefix:
+	}
+
+	tok, err := w.dec.Token()
+	if err != nil {
+		return false, newStreamError(err)
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		return w.obj()
+
+	case json.Delim('['):
+		return w.arr()
+	}
+
+	if w.projection != nil {
+		return true, nil
+	}
+
+	d, err := ofToken(tok)
+	if err != nil {
+		return false, err
+	}
+
+	return w.yield(streamQuery(w.path), d), nil
+}
+
+func (w *streamWalker) obj() (bool, error) {
+	empty := true
+
+	for w.dec.More() {
+		empty = false
+
+		tok, err := w.dec.Token()
+		if err != nil {
+			return false, newStreamError(err)
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			panic(EF("unreachable, non-string object key: %v", tok))
+		}
+
+		w.path = append(w.path, streamSeg{key: key, idx: -1, isArr: false})
+		cont, err := w.value()
+		w.path = w.path[:len(w.path)-1]
+
+		if !cont || err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := w.dec.Token(); err != nil {
+		return false, newStreamError(err)
+	}
+
+	if empty && w.projection == nil {
+		return w.yield(streamQuery(w.path), OfEmpty()), nil
+	}
+
+	return true, nil
+}
+
+func (w *streamWalker) arr() (bool, error) {
+	i := 0
+
+	for ; w.dec.More(); i++ {
+		w.path = append(w.path, streamSeg{key: "", idx: i, isArr: true})
+		cont, err := w.value()
+		w.path = w.path[:len(w.path)-1]
+
+		if !cont || err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := w.dec.Token(); err != nil {
+		return false, newStreamError(err)
+	}
+
+	if i == 0 && w.projection == nil {
+		return w.yield(streamQuery(w.path), OfEmptyArr()), nil
+	}
+
+	return true, nil
+}
+
+// skip consumes the next value without materialising it.
+func (w *streamWalker) skip() error {
+	depth := 0
+
+	for {
+		tok, err := w.dec.Token()
+		if err != nil {
+			return newStreamError(err)
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func ofToken(
+	tok json.Token,
+) (Datum, error) {
+	if tok == nil {
+		return _newDatum(Nil, nil), nil
+	}
+
+	val, typ, err := _ofAny(tok, reflect.ValueOf(tok))
+	if err != nil {
+		return OfErr(), err
+	}
+
+	return _newDatum(typ, val), nil
+}
+
+// =============================================================================.
+
+func newStreamError(
+	err error,
+) error {
+	return E(err, newGiraffeError(
+		ErrCodeDataMakeDeserializationFailure,
+		"failed to stream datum",
+	))
+}
+
+func newStreamUnsupportedQueryError(
+	q Query,
+) error {
+	return newGiraffeError(
+		ErrCodeDataReadUnexpectedType,
+		"query not supported in stream projection: "+q.String(),
+	)
+}
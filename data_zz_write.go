@@ -44,10 +44,10 @@ func del(
 	dt := d.typ
 
 	switch {
-	case dt.IsObj() != qf.IsObj():
+	case dt.IsObj() != isKeyIn(q, dt):
 		return newQueryTypeCastError(dt, qf)
 
-	case dt.IsArr() && q.Index() >= d.len():
+	case dt.IsArr() && indexIn(q, d.len()) >= d.len():
 		return newDataWriteMissingKeyError(q)
 	}
 
@@ -55,15 +55,38 @@ func del(
 	case !d.hasShallow(q):
 		// Do nothing.
 
+	case !qf.IsLeaf() && dt.IsArr():
+		i := indexIn(q, d.len())
+		dd := d.arrAt(i)
+		if d.isPersistent() {
+			dd = dd.persistentShallow()
+		}
+
+		if err := del(&dd, q.Next()); err != nil {
+			return err
+		}
+
+		d.putArr(i, dd)
+
+	case !qf.IsLeaf():
+		dd, _ := d.objAt(q.Attr())
+		if d.isPersistent() {
+			dd = dd.persistentShallow()
+		}
+
+		if err := del(&dd, q.Next()); err != nil {
+			return err
+		}
+
+		d.putObj(q.Attr(), dd)
+
 	case dt.IsArr():
-		arr := any(append(d.arr()[:q.Index()], d.arr()[q.Index()+1:]...))
-		d.val = &arr
+		i := indexIn(q, d.len())
+		arr := d.arr()
+		d.setArr(append(arr[:i], arr[i+1:]...))
 
 	default:
-		obj := d.obj()
-		delete(obj, q.Attr())
-		a := any(obj)
-		d.val = &a
+		d.delObj(q.Attr())
 	}
 
 	return nil
@@ -77,7 +100,7 @@ func set(
 	value Datum,
 ) error {
 	switch {
-	case q.Flags().IsObj():
+	case isKeyIn(q, d.typ):
 		return setObj(d, q, value)
 
 	default:
@@ -99,7 +122,7 @@ func setObj(
 	case qf.IsMaybe():
 		panic(EF("TODO: implement maybe for set obj"))
 
-	case !dt.isZero() && dt.IsObj() != q.Flags().IsObj():
+	case !dt.isZero() && !dt.IsObj():
 		return newQueryTypeCastError(dt, q.Flags())
 
 	case !qf.IsLeaf() && !qf.IsMake() && !has:
@@ -111,24 +134,35 @@ func setObj(
 
 	switch {
 	case qf.IsLeaf() && dt.IsObj():
-		d.obj()[q.Attr()] = item
+		d.putObj(q.Attr(), item)
 
 	case qf.IsLeaf() && dt.isZero():
-		*d = _newDatum(
-			Obj, map[string]Datum{
-				q.Attr(): item,
-			},
-		)
+		*d = newObj(false)
+		d.putObj(q.Attr(), item)
 
 	case !qf.IsLeaf() && dt.IsObj():
-		dd := d.obj()
-		ddI := dd[q.Attr()]
+		ddI, _ := d.objAt(q.Attr())
+		created := ddI.typ.isZero()
+
+		if d.isPersistent() {
+			ddI = ddI.persistentShallow()
+		}
 
 		if err := set(&ddI, q.Next(), item); err != nil {
 			return err
 		}
 
-		dd[q.Attr()] = ddI
+		// The path made on the way inherits the order, or the persistence, of
+		// its parent.
+		switch {
+		case created && d.isOrdered():
+			ddI = ddI.ordered(true)
+
+		case created && d.isPersistent():
+			ddI = ddI.persistent(true)
+		}
+
+		d.putObj(q.Attr(), ddI)
 
 	case !qf.IsLeaf() && dt.isZero():
 		dd := _newDatum(Type(0), nil)
@@ -136,11 +170,8 @@ func setObj(
 			return err
 		}
 
-		*d = _newDatum(
-			Obj, map[string]Datum{
-				q.Attr(): dd,
-			},
-		)
+		*d = newObj(false)
+		d.putObj(q.Attr(), dd)
 
 	default:
 		panic(EF("unreachable: unknown case for set obj"))
@@ -167,15 +198,32 @@ func arrSet(
 	case !qf.IsMake() && dt.isZero():
 		return newDataWriteMissingKeyError(q)
 
-	case !qf.IsAppend() && d.len() <= q.Index():
+	case dt.isZero():
+		// Made on the way, below.
+
+	case !qf.IsAppend() && d.len() <= indexIn(q, d.len()),
+		!qf.IsAppend() && indexIn(q, d.len()) < 0:
 		return newDataWriteMissingKeyError(q)
 	}
 
 	Assert(!dt.isZero() || qf.IsMake() || qf.Val() == 0)
 
 	switch {
+	case d.hasShallow(q) && qf.IsLeaf():
+		d.putArr(indexIn(q, d.len()), item)
+
 	case d.hasShallow(q):
-		d.arr()[q.Index()] = item
+		i := indexIn(q, d.len())
+		dd := d.arrAt(i)
+		if d.isPersistent() {
+			dd = dd.persistentShallow()
+		}
+
+		if err := set(&dd, q.Next(), item); err != nil {
+			return err
+		}
+
+		d.putArr(i, dd)
 
 	case dt.isZero() && !qf.IsLeaf():
 		dd := _newDatum(Type(0), nil)
@@ -189,10 +237,7 @@ func arrSet(
 		*d = _newDatum(Arr, []Datum{item})
 
 	case qf.IsAppend():
-		dd := d.arr()
-		dd = append(dd, item)
-		a := any(dd)
-		d.val = &a
+		d.appendArr(item)
 
 	default:
 		panic(EF("unreachable: unknown case for set arr"))
@@ -10,9 +10,21 @@ import (
 const (
 	Giraffe1v1 Dialect = "giraffe1v1"
 
+	// JsonPointer is RFC 6901, as in "~jsonpointer./users/0/name".
+	JsonPointer Dialect = "jsonpointer"
+
+	// JsonPath is RFC 9535, as in "~jsonpath.$.users[0].name".
+	JsonPath Dialect = "jsonpath"
+
 	Unknown Dialect = ""
 )
 
+var all = []Dialect{
+	Giraffe1v1,
+	JsonPointer,
+	JsonPath,
+}
+
 var (
 	errUnknown  = errors.New("dialect unknown")
 	errMismatch = errors.New("dialect mismatch")
@@ -38,7 +50,7 @@ func (d Dialect) matches(
 
 	default:
 		to := len(d) + 1
-		matched = len(spec) >= to && spec[1:to] == string(d)
+		matched = len(spec) > to && spec[1:to] == string(d) && spec[to] == cmd.Sep.Byte()
 		explicit = matched
 	}
 
@@ -49,8 +61,10 @@ func (d Dialect) matches(
 func dialectOf(
 	spec string,
 ) (_ Dialect, explicit bool, _ error) {
-	if ex, ok := Giraffe1v1.matches(spec); ok {
-		return Giraffe1v1, ex, nil
+	for _, d := range all {
+		if ex, ok := d.matches(spec); ok {
+			return d, ex, nil
+		}
 	}
 
 	return Unknown, false, ErrUnknown()
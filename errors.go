@@ -44,16 +44,43 @@ const (
 	ErrCodeDataWriteUnsegmentedQuery
 
 	ErrCodeDataModifyOperationTakesNoValue
+
+	// New codes go last, the codes above are kept as they are published.
+
+	ErrCodeDataMergeMissingIdentity
+	ErrCodeDataMergeUnknownPolicy
+
+	ErrCodeDataPatchInvalid
+	ErrCodeDataPatchMissingPath
+	ErrCodeDataPatchTestFailed
+
+	ErrCodeSchemaViolation
+	ErrCodeSchemaInvalid
+
+	ErrCodePipeStageFailed
+	ErrCodePipeFnInvalid
+
+	ErrCodeDataWriteIndeterministicQuery
+
+	ErrCodeDataDecodeFailed
+	ErrCodeDataEncodeFailed
+	ErrCodeDataCodecInvalidTag
+
+	ErrCodeTemplateInvalid
+	ErrCodeTemplateRender
 )
 
 //goland:noinspection GoUnusedConst
 const (
-	ErrCodeQueryParseEmptyQuery      = queryerrors.ErrCodeEmpty
-	ErrCodeQueryParseDuplicatedCmd   = queryerrors.ErrCodeDuplicatedCmd
-	ErrCodeQueryParseConflictingCmd  = queryerrors.ErrCodeConflictingCmd
-	ErrCodeQueryParseUnexpectedToken = queryerrors.ErrCodeUnexpectedToken
-	ErrCodeQueryParseNestingTooDeep  = queryerrors.ErrCodeNestingTooDeep
-	ErrCodeQueryParseNotWritable     = queryerrors.ErrCodeNotWritable
+	ErrCodeQueryParseEmptyQuery       = queryerrors.ErrCodeEmpty
+	ErrCodeQueryParseDuplicatedCmd    = queryerrors.ErrCodeDuplicatedCmd
+	ErrCodeQueryParseConflictingCmd   = queryerrors.ErrCodeConflictingCmd
+	ErrCodeQueryParseUnexpectedToken  = queryerrors.ErrCodeUnexpectedToken
+	ErrCodeQueryParseNestingTooDeep   = queryerrors.ErrCodeNestingTooDeep
+	ErrCodeQueryParseNotWritable      = queryerrors.ErrCodeNotWritable
+	ErrCodeQueryParseInvalidPredicate = queryerrors.ErrCodeInvalidPredicate
+	ErrCodeQueryParseUnsupported      = queryerrors.ErrCodeUnsupported
+	ErrCodeQueryNotExportable         = queryerrors.ErrCodeNotExportable
 )
 
 func init() {
@@ -0,0 +1,151 @@
+package giraffe_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+const compileDoc = `{
+	"user": {"id": 7, "name": "ann", "address": {"city": "x", "zip": "1"}},
+	"items": [{"id": 1}, {"id": 2}, {"id": 3}],
+	"key": "user",
+	"a.b": true
+}`
+
+func TestCompile(t *testing.T) {
+	t.Run("get", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, compileDoc)
+
+		queries := []giraffe.Query{
+			Q("user.name"),
+			Q("items.-1.id"),
+			Q("user.address.city"),
+			Q("#"),
+			Q("user.address"),
+			Q(`a\.b`),
+			Q("user.name"),
+			Q("items.0.id"),
+			Q("[key].id"),
+			Q("#.user.address.zip"),
+		}
+
+		c, err := giraffe.Compile(queries...)
+		require.NoError(t, err)
+		assert.Equal(t, queries, c.Queries())
+
+		values, err := c.Get(d)
+		require.NoError(t, err)
+		require.Len(t, values, len(queries))
+
+		for i, q := range queries {
+			assert.True(t, M(d.Get(q)).Eq(values[i]), q)
+		}
+
+		values, err = c.Get(mkDatum(t, compileDoc))
+		require.NoError(t, err)
+		assert.Equal(t, `"ann"`, jsonOf(t, values[0]))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, compileDoc)
+
+		for _, queries := range [][]giraffe.Query{
+			{Q("user.name"), Q("user.missing.x")},
+			{Q("items.5.id"), Q("items.0.id")},
+			{Q("user.0"), Q("user.name")},
+			{Q("user.name.x"), Q("items.x")},
+			{Q("items.0.id"), Q("[missing].x")},
+		} {
+			c, err := giraffe.Compile(queries...)
+			require.NoError(t, err)
+
+			_, err = c.Get(d)
+			require.Error(t, err)
+
+			var expected error
+			for _, q := range queries {
+				if _, expected = d.Get(q); expected != nil {
+					break
+				}
+			}
+			assert.Equal(t, expected.Error(), err.Error())
+		}
+
+		for _, q := range []giraffe.Query{
+			Q("items.*.id"),
+			Q("items.+"),
+			Q("!user.name"),
+		} {
+			_, err := giraffe.Compile(q)
+			require.Error(t, err, q)
+		}
+	})
+}
+
+func benchDatum(
+	b *testing.B,
+) (giraffe.Datum, []giraffe.Query) {
+	b.Helper()
+
+	users := make([]any, 64)
+	var queries []giraffe.Query
+	for i := range users {
+		users[i] = map[string]any{
+			"id":      i,
+			"name":    "user" + strconv.Itoa(i),
+			"address": map[string]any{"city": "c", "zip": strconv.Itoa(i)},
+		}
+
+		if i%8 == 0 {
+			at := "users." + strconv.Itoa(i)
+			queries = append(queries,
+				Q(at+".id"),
+				Q(at+".name"),
+				Q(at+".address.city"),
+				Q(at+".address.zip"),
+			)
+		}
+	}
+
+	return giraffe.OfJsonable(map[string]any{"users": users}), queries
+}
+
+func BenchmarkGet(b *testing.B) {
+	d, queries := benchDatum(b)
+
+	b.ResetTimer()
+	for b.Loop() {
+		for _, q := range queries {
+			if _, err := d.Get(q); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	d, queries := benchDatum(b)
+
+	c, err := giraffe.Compile(queries...)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := c.Get(d); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
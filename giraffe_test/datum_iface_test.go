@@ -22,6 +22,7 @@ var (
 	_ Formatted      = d
 	_ Queried        = d
 	_ Modified       = d
+	_ Diffed         = d
 	_ DatumPub       = d
 	_ Dyn            = d
 )
@@ -111,9 +112,18 @@ type Queried interface {
 
 type Modified interface {
 	Merge(...Datum) (Datum, error)
+	MergeWith(giraffe.Merging, ...Datum) (Datum, error)
 	Append(any) (Datum, error)
 }
 
+type Diffed interface {
+	Diff(Datum) ([]giraffe.Change, error)
+	PatchTo(Datum) (giraffe.JsonPatch, error)
+	Patched(giraffe.JsonPatch) (Datum, error)
+	MergePatchTo(Datum) (Datum, error)
+	MergePatched(Datum) (Datum, error)
+}
+
 type Formatted interface {
 	fmt.Stringer
 	String() string
@@ -150,6 +160,7 @@ type DatumPub interface {
 	CastArray
 	Queried
 	Modified
+	Diffed
 	Formatted
 	Eq
 	Rel
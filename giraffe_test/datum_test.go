@@ -4,9 +4,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hkoosha/giraffe"
 	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/dot"
 )
 
 func TestMerge(t *testing.T) {
@@ -29,3 +31,253 @@ func TestMerge(t *testing.T) {
 		assert.Equal(t, d0.Pretty(), dm.Pretty())
 	})
 }
+
+func TestMerge_Arr(t *testing.T) {
+	q := giraffe.Q("a.b")
+
+	t.Run("index by default", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d0 := giraffe.Of1(q, []int{1, 2})
+		d1 := giraffe.Of1(q, []int{1, 2, 3})
+
+		dm, err := d0.Merge(d1)
+		gtesting.NoError(t, err)
+
+		actual, err := dm.QISzs(q)
+		gtesting.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3}, actual)
+	})
+
+	t.Run("index clashing", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d0 := giraffe.Of1(q, []int{1, 2})
+		d1 := giraffe.Of1(q, []int{1, 5})
+
+		_, err := d0.Merge(d1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "clashing keys: [a.b.1]")
+	})
+
+	t.Run("concat", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d0 := giraffe.Of1(q, []int{1, 2})
+		d1 := giraffe.Of1(q, []int{2, 3})
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithConcat(), d1)
+		gtesting.NoError(t, err)
+
+		actual, err := dm.QISzs(q)
+		gtesting.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 2, 3}, actual)
+	})
+
+	t.Run("union", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d0 := giraffe.Of1(q, []int{1, 2, 2})
+		d1 := giraffe.Of1(q, []int{3, 2, 1, 4})
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithUnion(), d1)
+		gtesting.NoError(t, err)
+
+		actual, err := dm.QISzs(q)
+		gtesting.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3, 4}, actual)
+	})
+
+	t.Run("keyed", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d0 := giraffe.Of1(q, []giraffe.Datum{
+			giraffe.Of(map[string]int{"id": 1, "x": 10}),
+			giraffe.Of(map[string]int{"id": 2, "x": 20}),
+		})
+		d1 := giraffe.Of1(q, []giraffe.Datum{
+			giraffe.Of(map[string]int{"id": 2, "y": 21}),
+			giraffe.Of(map[string]int{"id": 3, "y": 31}),
+		})
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithKeyed(giraffe.Q("id")), d1)
+		gtesting.NoError(t, err)
+
+		l, err := dm.Get(q)
+		gtesting.NoError(t, err)
+		n, err := l.Len()
+		gtesting.NoError(t, err)
+		assert.Equal(t, 3, n)
+
+		y, err := dm.QISz(giraffe.Q("a.b.1.y"))
+		gtesting.NoError(t, err)
+		assert.Equal(t, 21, y)
+
+		x, err := dm.QISz(giraffe.Q("a.b.1.x"))
+		gtesting.NoError(t, err)
+		assert.Equal(t, 20, x)
+	})
+
+	t.Run("keyed repeated new key", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		keyed := giraffe.MergingOf().WithKeyed(giraffe.Q("id"))
+		d0 := giraffe.Of1(q, []giraffe.Datum{
+			giraffe.Of(map[string]int{"id": 1, "x": 10}),
+		})
+		d1 := giraffe.Of1(q, []giraffe.Datum{
+			giraffe.Of(map[string]int{"id": 2, "y": 21}),
+			giraffe.Of(map[string]int{"id": 2, "z": 22}),
+		})
+
+		dm, err := d0.MergeWith(keyed, d1)
+		gtesting.NoError(t, err)
+
+		l, err := dm.Get(q)
+		gtesting.NoError(t, err)
+		n, err := l.Len()
+		gtesting.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		y, err := dm.QISz(giraffe.Q("a.b.1.y"))
+		gtesting.NoError(t, err)
+		assert.Equal(t, 21, y)
+
+		z, err := dm.QISz(giraffe.Q("a.b.1.z"))
+		gtesting.NoError(t, err)
+		assert.Equal(t, 22, z)
+
+		d2 := giraffe.Of1(q, []giraffe.Datum{
+			giraffe.Of(map[string]int{"id": 2, "y": 21}),
+			giraffe.Of(map[string]int{"id": 2, "y": 22}),
+		})
+
+		_, err = d0.MergeWith(keyed, d2)
+		require.ErrorContains(t, err, "clashing keys: [a.b.1.y]")
+	})
+
+	t.Run("keyed missing identity", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d0 := giraffe.Of1(q, []int{1})
+		d1 := giraffe.Of1(q, []int{2})
+
+		_, err := d0.MergeWith(giraffe.MergingOf().WithKeyed(giraffe.Q("id")), d1)
+		require.Error(t, err)
+	})
+}
+
+func TestMerge_Policy(t *testing.T) {
+	q := giraffe.Q("a.b")
+
+	d0 := giraffe.Of1(q, 1)
+	d1 := giraffe.Of1(q, 2)
+
+	t.Run("error by default", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := d0.Merge(d1)
+		require.Error(t, err)
+	})
+
+	t.Run("left wins", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithLeftWins(), d1)
+		gtesting.NoError(t, err)
+		assert.Equal(t, 1, M(dm.QISz(q)))
+	})
+
+	t.Run("right wins", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithRightWins(), d1)
+		gtesting.NoError(t, err)
+		assert.Equal(t, 2, M(dm.QISz(q)))
+	})
+
+	t.Run("right wins on type mismatch", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d2 := giraffe.Of1(q, "two")
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithRightWins(), d2)
+		gtesting.NoError(t, err)
+		assert.Equal(t, "two", M(dm.QStr(q)))
+	})
+
+	t.Run("collect", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithCollect(), d1)
+		gtesting.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, M(dm.QISzs(q)))
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var seen giraffe.Query
+		sum := func(
+			path giraffe.Query,
+			left giraffe.Datum,
+			right giraffe.Datum,
+		) (giraffe.Datum, error) {
+			seen = path
+			return giraffe.Of(M(left.ISz()) + M(right.ISz())), nil
+		}
+
+		dm, err := d0.MergeWith(giraffe.MergingOf().WithResolver(sum), d1)
+		gtesting.NoError(t, err)
+		assert.Equal(t, 3, M(dm.QISz(q)))
+		assert.Equal(t, "a.b", seen.String())
+	})
+
+	t.Run("resolver path of command characters", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		keys := []string{"a.b", "x[0]", "*", "#", "?k", "=", "+", "!", "$", "@1", `back\slash`, "~j", "p|q"}
+
+		lObj := make(map[string]giraffe.Datum)
+		rObj := make(map[string]giraffe.Datum)
+		for i, k := range keys {
+			lObj[k] = giraffe.Of(map[string]giraffe.Datum{"v": giraffe.Of(i)})
+			rObj[k] = giraffe.Of(map[string]giraffe.Datum{"v": giraffe.Of(-i)})
+		}
+
+		left := giraffe.Of(lObj)
+		right := giraffe.Of(rObj)
+
+		var seen []giraffe.Query
+		keepLeft := func(
+			path giraffe.Query,
+			l giraffe.Datum,
+			_ giraffe.Datum,
+		) (giraffe.Datum, error) {
+			seen = append(seen, path)
+			return l, nil
+		}
+
+		dm, err := left.MergeWith(giraffe.MergingOf().WithResolver(keepLeft), right)
+		gtesting.NoError(t, err)
+		assert.True(t, left.Eq(dm))
+		require.Len(t, seen, len(keys)-1)
+
+		for _, path := range seen {
+			reparsed, err := giraffe.GQParse(path.String())
+			require.NoError(t, err, path.String())
+			assert.True(t, M(left.Get(path)).Eq(M(left.Get(reparsed))), path.String())
+			assert.NotEqual(t, 0, M(left.QISz(path)))
+		}
+	})
+
+	t.Run("policy text", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var p giraffe.MergePolicy
+		gtesting.NoError(t, p.UnmarshalText([]byte("right")))
+		assert.Equal(t, giraffe.MergePolicyRightWins, p)
+
+		require.Error(t, p.UnmarshalText([]byte("resolve")))
+	})
+}
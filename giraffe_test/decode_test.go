@@ -0,0 +1,199 @@
+package giraffe_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+type decodeAudit struct {
+	By string `giraffe:"meta.by"`
+}
+
+type decodeUser struct {
+	decodeAudit
+
+	Name    string                   `giraffe:"profile.name"`
+	Email   *string                  `giraffe:"profile.email"`
+	Port    uint16                   `giraffe:"net.ports.0"`
+	Ratio   float64                  `giraffe:"net.ratio"`
+	Big     *big.Int                 `giraffe:"net.big"`
+	Tags    []string                 `giraffe:"tags,optional"`
+	Limits  map[string]int32         `giraffe:"limits"`
+	Extra   giraffe.Datum            `giraffe:"extra"`
+	Any     any                      `giraffe:"any"`
+	Items   []decodeItem             `giraffe:"items"`
+	Note    string                   `giraffe:"note,optional,omitempty"`
+	Skipped int                      `giraffe:"-"`
+	Plain   bool                     `json:"plain_flag"`
+	Nested  map[string]giraffe.Datum `giraffe:"nested,optional"`
+}
+
+type decodeItem struct {
+	ID int `giraffe:"id"`
+}
+
+func TestDecode(t *testing.T) {
+	doc := `{
+		"profile": {"name": "ann"},
+		"net": {"ports": [8080, 9090], "ratio": 2, "big": 1234567890123},
+		"limits": {"cpu": 4, "mem": 512},
+		"extra": {"x": [1]},
+		"any": {"k": "v"},
+		"items": [{"id": 1}, {"id": 2}],
+		"meta": {"by": "bob"},
+		"plain_flag": true
+	}`
+
+	t.Run("decode", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		u, err := giraffe.Decode[decodeUser](mkDatum(t, doc))
+		require.NoError(t, err)
+
+		assert.Equal(t, "ann", u.Name)
+		assert.Nil(t, u.Email)
+		assert.Equal(t, uint16(8080), u.Port)
+		assert.InDelta(t, 2.0, u.Ratio, 0)
+		assert.Equal(t, "1234567890123", u.Big.String())
+		assert.Nil(t, u.Tags)
+		assert.Equal(t, map[string]int32{"cpu": 4, "mem": 512}, u.Limits)
+		assert.JSONEq(t, `{"x":[1]}`, jsonOf(t, u.Extra))
+		assert.Equal(t, map[string]any{"k": "v"}, u.Any)
+		assert.Equal(t, []decodeItem{{ID: 1}, {ID: 2}}, u.Items)
+		assert.Equal(t, "bob", u.By)
+		assert.True(t, u.Plain)
+	})
+
+	t.Run("every failing path", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, `{
+			"profile": {"name": 1},
+			"net": {"ports": [70000], "ratio": "x", "big": 1},
+			"limits": {"cpu": 4294967296},
+			"extra": null,
+			"any": 1,
+			"items": [{"id": 1}, {}],
+			"meta": {"by": "bob"},
+			"plain_flag": true
+		}`)
+
+		_, err := giraffe.Decode[decodeUser](d)
+		require.Error(t, err)
+
+		var dErr *giraffe.DecodeError
+		require.ErrorAs(t, err, &dErr)
+		assert.Equal(t, giraffe.ErrCodeDataDecodeFailed, dErr.Code())
+
+		paths := make([]giraffe.Query, 0, len(dErr.Violations()))
+		for _, v := range dErr.Violations() {
+			paths = append(paths, v.Query)
+		}
+
+		assert.ElementsMatch(t, []giraffe.Query{
+			"profile.name",
+			"net.ports.0",
+			"net.ratio",
+			"limits.cpu",
+			"items.1.id",
+		}, paths, err.Error())
+	})
+
+	t.Run("invalid tag", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		type bad struct {
+			A int `giraffe:"a.+"`
+		}
+
+		_, err := giraffe.Decode[bad](mkDatum(t, `{"a": 1}`))
+		require.ErrorContains(t, err, "invalid giraffe tag")
+
+		type badOpt struct {
+			A int `giraffe:"a,required"`
+		}
+
+		_, err = giraffe.Encode(badOpt{A: 1})
+		require.ErrorContains(t, err, "unknown option")
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		v, err := giraffe.Decode[[]int8](mkDatum(t, `[1, 2, 300]`))
+		require.Error(t, err)
+		assert.Len(t, v, 3)
+
+		var dErr *giraffe.DecodeError
+		require.True(t, errors.As(err, &dErr))
+		require.Len(t, dErr.Violations(), 1)
+		assert.Equal(t, giraffe.Query("2"), dErr.Violations()[0].Query)
+	})
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		email := "ann@example.com"
+		u := decodeUser{
+			decodeAudit: decodeAudit{By: "bob"},
+			Name:        "ann",
+			Email:       &email,
+			Port:        8080,
+			Ratio:       1.5,
+			Big:         big.NewInt(7),
+			Tags:        []string{"a"},
+			Limits:      map[string]int32{"cpu": 4},
+			Extra:       mkDatum(t, `{"x": 1}`),
+			Any:         "v",
+			Items:       []decodeItem{{ID: 1}},
+			Note:        "",
+			Skipped:     9,
+			Plain:       true,
+			Nested:      nil,
+		}
+
+		d, err := giraffe.Encode(u)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"profile": {"name": "ann", "email": "ann@example.com"},
+			"net": {"ports": [8080], "ratio": "1.5", "big": 7},
+			"tags": ["a"],
+			"limits": {"cpu": 4},
+			"extra": {"x": 1},
+			"any": "v",
+			"items": [{"id": 1}],
+			"meta": {"by": "bob"},
+			"plain_flag": true,
+			"nested": null
+		}`, jsonOf(t, d))
+
+		back, err := giraffe.Decode[decodeUser](d)
+		require.NoError(t, err)
+
+		u.Skipped = 0
+		assert.Equal(t, jsonOf(t, M(giraffe.Encode(u))), jsonOf(t, M(giraffe.Encode(back))))
+	})
+
+	t.Run("conflicting paths", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		type conflict struct {
+			A int `giraffe:"a.b"`
+			B int `giraffe:"a.b"`
+		}
+
+		_, err := giraffe.Encode(conflict{A: 1, B: 2})
+		require.ErrorContains(t, err, "cannot encode field: a.b")
+	})
+}
@@ -0,0 +1,254 @@
+package giraffe_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+)
+
+func mkDatum(t *testing.T, j string) giraffe.Datum {
+	t.Helper()
+
+	dat, err := giraffe.DatumSerde().Read([]byte(j))
+	require.NoError(t, err)
+
+	return dat
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("no changes", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		left := mkDatum(t, `{"a": {"b": [1, 2]}}`)
+		changes, err := left.Diff(mkDatum(t, `{"a": {"b": [1, 2]}}`))
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("nested changes", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		left := mkDatum(t, `{"a": {"b": [1, 2, 3], "c": "x"}, "d": true}`)
+		right := mkDatum(t, `{"a": {"b": [1, 5], "c": 1}, "e": null}`)
+
+		changes, err := left.Diff(right)
+		require.NoError(t, err)
+
+		kinds := make(map[string]giraffe.ChangeKind, len(changes))
+		for _, c := range changes {
+			kinds[c.Query.String()] = c.Kind
+		}
+
+		assert.Equal(t, map[string]giraffe.ChangeKind{
+			"a.b.1": giraffe.ChangeChanged,
+			"a.b.2": giraffe.ChangeRemoved,
+			"a.c":   giraffe.ChangeChanged,
+			"d":     giraffe.ChangeRemoved,
+			"e":     giraffe.ChangeAdded,
+		}, kinds)
+	})
+
+	t.Run("root change", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		changes, err := giraffe.Of(1).Diff(giraffe.Of("x"))
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, giraffe.ChangeChanged, changes[0].Kind)
+		assert.Equal(t, giraffe.GQErr(), changes[0].Query)
+		assert.Equal(t, "Change[changed, <root>]", changes[0].String())
+
+		changes, err = mkDatum(t, `{"a": 1}`).Diff(mkDatum(t, `[1]`))
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "Change[changed, <root>]", changes[0].String())
+
+		changes, err = mkDatum(t, `{"a": 1}`).Diff(mkDatum(t, `{"a": 2}`))
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, "Change[changed, a]", changes[0].String())
+	})
+
+	t.Run("escaped keys", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		left := mkDatum(t, `{"a.b": {"c/d~e": 1}}`)
+		right := mkDatum(t, `{"a.b": {"c/d~e": 2}}`)
+
+		changes, err := left.Diff(right)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+
+		v, err := right.Get(changes[0].Query)
+		require.NoError(t, err)
+		assert.True(t, v.Eq(giraffe.Of(2)))
+
+		patch, err := left.PatchTo(right)
+		require.NoError(t, err)
+		require.Len(t, patch, 1)
+		assert.Equal(t, "/a.b/c~1d~0e", patch[0].Path)
+	})
+
+	t.Run("digit keys", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		left := mkDatum(t, `{"a": {"0": 1, "-1": [1]}, "l": [{"0": 1}]}`)
+		right := mkDatum(t, `{"a": {"0": 2, "-1": [1, 2]}, "l": [{"0": 3}]}`)
+
+		changes, err := left.Diff(right)
+		require.NoError(t, err)
+		require.Len(t, changes, 3)
+
+		for _, c := range changes {
+			v, err := right.Get(c.Query)
+			require.NoError(t, err, c.String())
+			assert.True(t, v.Eq(c.New), c.String())
+
+			reparsed, err := giraffe.GQParse(c.Query.String())
+			require.NoError(t, err, c.String())
+			v, err = right.Get(reparsed)
+			require.NoError(t, err, c.String())
+			assert.True(t, v.Eq(c.New), c.String())
+		}
+		assert.Equal(t, `a.\0`, changes[1].Query.String())
+
+		patch, err := left.PatchTo(right)
+		require.NoError(t, err)
+
+		patched, err := left.Patched(patch)
+		require.NoError(t, err)
+		assert.True(t, right.Eq(patched), patched.Pretty())
+	})
+}
+
+func TestJsonPatch(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		left := mkDatum(t, `{"a": {"b": [1, 2, 3], "c": "x"}, "d": true}`)
+		right := mkDatum(t, `{"a": {"b": [1, 5], "c": 1}, "e": null, "f": [{"g": 1}]}`)
+
+		patch, err := left.PatchTo(right)
+		require.NoError(t, err)
+
+		encoded, err := json.Marshal(patch)
+		require.NoError(t, err)
+
+		var decoded giraffe.JsonPatch
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+		patched, err := left.Patched(decoded)
+		require.NoError(t, err)
+		assert.True(t, right.Eq(patched), patched.Pretty())
+	})
+
+	t.Run("all ops", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		left := mkDatum(t, `{"a": [1, 2], "b": {"c": 1}}`)
+
+		var patch giraffe.JsonPatch
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"op": "test", "path": "/b/c", "value": 1},
+			{"op": "add", "path": "/a/-", "value": 3},
+			{"op": "add", "path": "/a/0", "value": 0},
+			{"op": "copy", "from": "/b", "path": "/d"},
+			{"op": "move", "from": "/b/c", "path": "/e"},
+			{"op": "replace", "path": "/d/c", "value": "x"},
+			{"op": "remove", "path": "/a/1"}
+		]`), &patch))
+
+		patched, err := left.Patched(patch)
+		require.NoError(t, err)
+
+		expected := mkDatum(t, `{"a": [0, 2, 3], "b": {}, "d": {"c": "x"}, "e": 1}`)
+		assert.True(t, expected.Eq(patched), patched.Pretty())
+
+		// Original is untouched.
+		assert.True(t, mkDatum(t, `{"a": [1, 2], "b": {"c": 1}}`).Eq(left), left.Pretty())
+	})
+
+	t.Run("failed test", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var patch giraffe.JsonPatch
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"op": "remove", "path": "/a"},
+			{"op": "test", "path": "/b", "value": 2}
+		]`), &patch))
+
+		_, err := mkDatum(t, `{"a": 1, "b": 1}`).Patched(patch)
+		require.Error(t, err)
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var patch giraffe.JsonPatch
+		require.NoError(t, json.Unmarshal([]byte(`[{"op": "remove", "path": "/a/b"}]`), &patch))
+
+		_, err := mkDatum(t, `{"a": {}}`).Patched(patch)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid op", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var patch giraffe.JsonPatch
+		require.Error(t, json.Unmarshal([]byte(`[{"op": "nope", "path": "/a"}]`), &patch))
+		require.Error(t, json.Unmarshal([]byte(`[{"op": "add", "path": "/a"}]`), &patch))
+		require.Error(t, json.Unmarshal([]byte(`[{"op": "move", "path": "/a"}]`), &patch))
+	})
+}
+
+func TestMergePatch(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		left := mkDatum(t, `{"a": {"b": 1, "c": 2}, "d": [1, 2], "e": "x"}`)
+		right := mkDatum(t, `{"a": {"b": 1, "c": 3, "z": {"y": 1}}, "d": [3], "f": false}`)
+
+		patch, err := left.MergePatchTo(right)
+		require.NoError(t, err)
+
+		expected := mkDatum(t, `{"a": {"c": 3, "z": {"y": 1}}, "d": [3], "e": null, "f": false}`)
+		assert.True(t, expected.Eq(patch), patch.Pretty())
+
+		patched, err := left.MergePatched(patch)
+		require.NoError(t, err)
+		assert.True(t, right.Eq(patched), patched.Pretty())
+	})
+
+	t.Run("rfc example", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		target := mkDatum(t, `{
+			"title": "Goodbye!",
+			"author": {"givenName": "John", "familyName": "Doe"},
+			"tags": ["example", "sample"],
+			"content": "This will be unchanged"
+		}`)
+		patch := mkDatum(t, `{
+			"title": "Hello!",
+			"phoneNumber": "+01-123-456-7890",
+			"author": {"familyName": null},
+			"tags": ["example"]
+		}`)
+		expected := mkDatum(t, `{
+			"title": "Hello!",
+			"author": {"givenName": "John"},
+			"tags": ["example"],
+			"content": "This will be unchanged",
+			"phoneNumber": "+01-123-456-7890"
+		}`)
+
+		patched, err := target.MergePatched(patch)
+		require.NoError(t, err)
+		assert.True(t, expected.Eq(patched), patched.Pretty())
+	})
+}
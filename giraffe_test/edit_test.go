@@ -0,0 +1,188 @@
+package giraffe_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+func TestEdit(t *testing.T) {
+	doc := `{
+		"user": {"name": "ann", "tags": ["a"], "legacy": 1},
+		"items": [{"id": 1, "status": "on"}, {"id": 2, "status": "off"}]
+	}`
+
+	t.Run("edit", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		edited, err := d.Edit(func(tx *giraffe.Tx) error {
+			require.NoError(t, tx.Set(Q("user.=name"), "bob"))
+			require.NoError(t, tx.Append(Q("user.tags"), "b"))
+			require.NoError(t, tx.Set(Q("user.tags.+"), "c"))
+			require.NoError(t, tx.Delete(Q("user.legacy")))
+			require.NoError(t, tx.Make(Q("meta.seen.at"), 1))
+			require.NoError(t, tx.Set(Q(`items[?status=="on"].=status`), "done"))
+
+			name, err := tx.Get(Q("user.name"))
+			require.NoError(t, err)
+			assert.Equal(t, `"bob"`, jsonOf(t, name))
+
+			ok, err := tx.Has(Q("user.legacy"))
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t,
+			`{"items":[{"id":1,"status":"done"},{"id":2,"status":"off"}],`+
+				`"meta":{"seen":{"at":1}},"user":{"name":"bob","tags":["a","b","c"]}}`,
+			jsonOf(t, edited))
+
+		assert.Equal(t, jsonOf(t, mkDatum(t, doc)), jsonOf(t, d))
+	})
+
+	t.Run("all or nothing", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		_, err := d.Edit(func(tx *giraffe.Tx) error {
+			require.NoError(t, tx.Set(Q("user.=name"), "bob"))
+			require.Error(t, tx.Set(Q("user.name"), "again"))
+			require.Error(t, tx.Set(Q("user.=name"), "after failing"))
+
+			_, err := tx.Get(Q("user.name"))
+			require.Error(t, err)
+
+			return nil
+		})
+
+		var gErr *giraffe.GiraffeError
+		require.ErrorAs(t, err, &gErr)
+		assert.Equal(t, giraffe.ErrCodeDataWriteImplicitOverwrite, gErr.Code())
+
+		sentinel := errors.New("sentinel")
+		_, err = d.Edit(func(tx *giraffe.Tx) error {
+			require.NoError(t, tx.Delete(Q("user")))
+			return sentinel
+		})
+		require.ErrorIs(t, err, sentinel)
+
+		assert.Equal(t, jsonOf(t, mkDatum(t, doc)), jsonOf(t, d))
+	})
+
+	t.Run("scalars", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := M(giraffe.From(42))
+
+		_, err := d.Edit(func(tx *giraffe.Tx) error {
+			return tx.Set(Q("=x"), 1)
+		})
+
+		var gErr *giraffe.GiraffeError
+		require.ErrorAs(t, err, &gErr)
+		assert.Equal(t, giraffe.ErrCodeCastError, gErr.Code())
+
+		_, err = d.Edit(func(tx *giraffe.Tx) error {
+			return tx.Append(Q("x"), 1)
+		})
+		require.ErrorAs(t, err, &gErr)
+
+		same, err := d.Edit(func(*giraffe.Tx) error {
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, d.Eq(same))
+	})
+
+	t.Run("patterns", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		value := mkDatum(t, `{"x": 1}`)
+
+		edited := M(mkDatum(t, doc).Edit(func(tx *giraffe.Tx) error {
+			if err := tx.Set(Q("items.*.meta"), value); err != nil {
+				return err
+			}
+
+			if err := tx.Set(Q("items.0.meta.=x"), 2); err != nil {
+				return err
+			}
+
+			return tx.Set(Q(`items[?id>=2].=status`), "gone")
+		}))
+
+		assert.Equal(t,
+			`[{"id":1,"meta":{"x":2},"status":"on"},{"id":2,"meta":{"x":1},"status":"gone"}]`,
+			jsonOf(t, M(edited.Get(Q("items")))))
+		assert.Equal(t, `{"x":1}`, jsonOf(t, value))
+
+		_, err := mkDatum(t, doc).Edit(func(tx *giraffe.Tx) error {
+			return tx.Set(Q("items.*.status"), "x")
+		})
+
+		var gErr *giraffe.GiraffeError
+		require.ErrorAs(t, err, &gErr)
+		assert.Equal(t, giraffe.ErrCodeDataWriteImplicitOverwrite, gErr.Code())
+	})
+
+	t.Run("values are copied", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		value := mkDatum(t, `{"x": {"y": 1}}`)
+
+		edited := M(mkDatum(t, doc).Edit(func(tx *giraffe.Tx) error {
+			if err := tx.Set(Q("value"), value); err != nil {
+				return err
+			}
+
+			return tx.Set(Q("value.x.=y"), 2)
+		}))
+
+		assert.Equal(t, `{"x":{"y":2}}`, jsonOf(t, M(edited.Get(Q("value")))))
+		assert.Equal(t, `{"x":{"y":1}}`, jsonOf(t, value))
+	})
+}
+
+func BenchmarkSet(b *testing.B) {
+	for b.Loop() {
+		d := giraffe.OfEmpty()
+		for _, q := range benchFields {
+			d = M(d.Set(q, 1))
+		}
+	}
+}
+
+func BenchmarkEdit(b *testing.B) {
+	for b.Loop() {
+		M(giraffe.OfEmpty().Edit(func(tx *giraffe.Tx) error {
+			for _, q := range benchFields {
+				if err := tx.Set(q, 1); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}))
+	}
+}
+
+var benchFields = func() []giraffe.Query {
+	fields := make([]giraffe.Query, 50)
+	for i := range fields {
+		fields[i] = Q("f" + string(rune('a'+i/26)) + string(rune('a'+i%26)))
+	}
+
+	return fields
+}()
@@ -0,0 +1,68 @@
+package giraffe_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hkoosha/giraffe"
+)
+
+// The codes are published, so that a code once given is never renumbered.
+func TestErrCodes(t *testing.T) {
+	for code, expecting := range map[uint64]uint64{
+		giraffe.ErrCodeUnexpectedNil:                   1,
+		giraffe.ErrCodeInvalidDatum:                    2,
+		giraffe.ErrCodeInvalidJsonable:                 3,
+		giraffe.ErrCodeCastError:                       4,
+		giraffe.ErrCodeOverflowError:                   5,
+		giraffe.ErrCodeDataMakeUnexpectedType:          6,
+		giraffe.ErrCodeDataMakeUnimplementedType:       7,
+		giraffe.ErrCodeDataMakeProhibitedType:          8,
+		giraffe.ErrCodeDataMakeInvalidType:             9,
+		giraffe.ErrCodeDataMakeSerializationFailure:    10,
+		giraffe.ErrCodeDataMakeDeserializationFailure:  11,
+		giraffe.ErrCodeDataMakeDuplicateKey:            12,
+		giraffe.ErrCodeDataMergeIncompatibleTypes:      13,
+		giraffe.ErrCodeDataMergeClashingKeys:           14,
+		giraffe.ErrCodeTypeParseError:                  15,
+		giraffe.ErrCodeDataReadIndeterministicQuery:    16,
+		giraffe.ErrCodeDataReadIndexOutOfBounds:        17,
+		giraffe.ErrCodeDataReadMissingKey:              18,
+		giraffe.ErrCodeDataReadUnexpectedType:          19,
+		giraffe.ErrCodeDataReadOnly:                    20,
+		giraffe.ErrCodeDataWriteMissingKey:             21,
+		giraffe.ErrCodeDataWriteImplicitOverwrite:      22,
+		giraffe.ErrCodeDataWriteUnexpectedValue:        23,
+		giraffe.ErrCodeDataWriteUnsegmentedQuery:       24,
+		giraffe.ErrCodeDataModifyOperationTakesNoValue: 25,
+		giraffe.ErrCodeDataMergeMissingIdentity:        26,
+		giraffe.ErrCodeDataMergeUnknownPolicy:          27,
+		giraffe.ErrCodeDataPatchInvalid:                28,
+		giraffe.ErrCodeDataPatchMissingPath:            29,
+		giraffe.ErrCodeDataPatchTestFailed:             30,
+		giraffe.ErrCodeSchemaViolation:                 31,
+		giraffe.ErrCodeSchemaInvalid:                   32,
+		giraffe.ErrCodePipeStageFailed:                 33,
+		giraffe.ErrCodePipeFnInvalid:                   34,
+		giraffe.ErrCodeDataWriteIndeterministicQuery:   35,
+		giraffe.ErrCodeDataDecodeFailed:                36,
+		giraffe.ErrCodeDataEncodeFailed:                37,
+		giraffe.ErrCodeDataCodecInvalidTag:             38,
+		giraffe.ErrCodeTemplateInvalid:                 39,
+		giraffe.ErrCodeTemplateRender:                  40,
+
+		giraffe.ErrCodeQueryParseEmptyQuery:       math.MaxInt32,
+		giraffe.ErrCodeQueryParseDuplicatedCmd:    math.MaxInt32 + 1,
+		giraffe.ErrCodeQueryParseConflictingCmd:   math.MaxInt32 + 2,
+		giraffe.ErrCodeQueryParseUnexpectedToken:  math.MaxInt32 + 3,
+		giraffe.ErrCodeQueryParseNestingTooDeep:   math.MaxInt32 + 4,
+		giraffe.ErrCodeQueryParseNotWritable:      math.MaxInt32 + 5,
+		giraffe.ErrCodeQueryParseInvalidPredicate: math.MaxInt32 + 6,
+		giraffe.ErrCodeQueryParseUnsupported:      math.MaxInt32 + 7,
+		giraffe.ErrCodeQueryNotExportable:         math.MaxInt32 + 8,
+	} {
+		assert.Equal(t, expecting, code)
+	}
+}
@@ -0,0 +1,163 @@
+package giraffe_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/serdes"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+func enableOrderedKeys(t *testing.T) {
+	t.Helper()
+
+	v := giraffe.IsOrderedKeys()
+	giraffe.EnableOrderedKeys()
+	t.Cleanup(func() {
+		if !v {
+			giraffe.DisableOrderedKeys()
+		}
+	})
+}
+
+func TestOrderedKeys(t *testing.T) {
+	doc := `{"z":1,"a":{"y":true,"b":null},"m":[{"q":1,"c":2}]}`
+
+	ordered := func(t *testing.T) giraffe.Datum {
+		t.Helper()
+
+		d, err := giraffe.DatumOrderedSerde().Read([]byte(doc))
+		require.NoError(t, err)
+
+		return d
+	}
+
+	t.Run("parse and serialize", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := ordered(t)
+		assert.True(t, d.IsOrdered())
+		assert.Equal(t, doc, jsonOf(t, d))
+		assert.Equal(t, []string{"z", "a", "m"}, M(d.Keys()))
+		assert.Equal(t, "{\n  \"z\": 1,\n  \"a\": {\n    \"y\": true,\n    \"b\": null\n  },"+
+			"\n  \"m\": [\n    {\n      \"q\": 1,\n      \"c\": 2\n    }\n  ]\n}", d.Pretty())
+
+		var keys []string
+		for k := range M(d.Iter2()) {
+			keys = append(keys, k)
+		}
+		assert.Equal(t, []string{"z", "a", "m"}, keys)
+	})
+
+	t.Run("unordered by default", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+		assert.False(t, d.IsOrdered())
+		assert.Equal(t, []string{"a", "m", "z"}, M(d.Keys()))
+		assert.True(t, d.Eq(ordered(t)))
+	})
+
+	t.Run("set", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := M(ordered(t).Set(Q("d"), 4))
+		d = M(d.Set(Q("a.x"), 5))
+		d = M(d.Set(Q("new.k2.k1").WithMake(), 6))
+		d = M(d.Set(Q("new.k0"), 7))
+
+		assert.Equal(t,
+			`{"z":1,"a":{"y":true,"b":null,"x":5},"m":[{"q":1,"c":2}],"d":4,"new":{"k2":{"k1":6},"k0":7}}`,
+			jsonOf(t, d))
+
+		d = M(d.Set(Q("!a"), nil))
+		d = M(d.Set(Q("a"), 8))
+		assert.Equal(t, []string{"z", "m", "d", "new", "a"}, M(d.Keys()))
+	})
+
+	t.Run("set leaves the original untouched", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := ordered(t)
+		_ = M(d.Set(Q("d"), 4))
+
+		assert.Equal(t, doc, jsonOf(t, d))
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		right := M(giraffe.DatumOrderedSerde().Read([]byte(`{"n":1,"a":{"e":2,"d":3},"b":4}`)))
+
+		merged := M(ordered(t).Merge(right))
+		assert.Equal(t,
+			`{"z":1,"a":{"y":true,"b":null,"e":2,"d":3},"m":[{"q":1,"c":2}],"n":1,"b":4}`,
+			jsonOf(t, merged))
+	})
+
+	t.Run("nest", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		nested := M(ordered(t).Nest(Q("root")))
+		assert.True(t, nested.IsOrdered())
+		assert.Equal(t, `{"root":`+doc+`}`, jsonOf(t, nested))
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := ordered(t)
+		patched := M(d.MergePatched(M(giraffe.DatumOrderedSerde().Read([]byte(`{"k":1,"z":null}`)))))
+		assert.Equal(t, `{"a":{"y":true,"b":null},"m":[{"q":1,"c":2}],"k":1}`, jsonOf(t, patched))
+	})
+
+	t.Run("ordered and unordered", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, `{"b":{"d":1,"c":2},"a":3}`).Ordered()
+		assert.True(t, d.IsOrdered())
+
+		d = M(d.Set(Q("aa"), 1))
+		assert.Equal(t, `{"a":3,"b":{"c":2,"d":1},"aa":1}`, jsonOf(t, d))
+
+		assert.False(t, d.Unordered().IsOrdered())
+		assert.Equal(t, `{"a":3,"aa":1,"b":{"c":2,"d":1}}`, jsonOf(t, d.Unordered()))
+	})
+
+	t.Run("serdes", func(t *testing.T) {
+		gtesting.Preamble(t)
+		enableOrderedKeys(t)
+
+		for name, serde := range map[string]serdes.Serde[giraffe.Datum]{
+			"json":    giraffe.DatumSerde(),
+			"yaml":    giraffe.DatumYamlSerde(),
+			"cbor":    giraffe.DatumCborSerde(),
+			"msgpack": giraffe.DatumMsgpackSerde(),
+		} {
+			b, err := serde.Write(ordered(t))
+			require.NoError(t, err, name)
+
+			read, err := serde.Read(b)
+			require.NoError(t, err, name)
+			assert.Equal(t, doc, jsonOf(t, read), name)
+		}
+	})
+
+	t.Run("global", func(t *testing.T) {
+		gtesting.Preamble(t)
+		enableOrderedKeys(t)
+
+		var d giraffe.Datum
+		require.NoError(t, d.UnmarshalJSON([]byte(doc)))
+		assert.True(t, d.IsOrdered())
+		assert.Equal(t, doc, jsonOf(t, d))
+
+		made := M(giraffe.OfEmpty().Set(Q("x.y").WithMake(), 1))
+		made = M(made.Set(Q("x.a"), 2))
+		assert.Equal(t, `{"x":{"y":1,"a":2}}`, jsonOf(t, made))
+	})
+}
@@ -0,0 +1,247 @@
+package giraffe_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+func TestPersistent(t *testing.T) {
+	doc := `{
+		"user": {"name": "ann", "tags": ["a", "b"], "legacy": 1},
+		"items": [{"id": 1, "status": "on"}, {"id": 2, "status": "off"}],
+		"meta": {"version": 3}
+	}`
+
+	t.Run("conversion", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+		p := d.Persistent()
+
+		assert.True(t, p.IsPersistent())
+		assert.False(t, d.IsPersistent())
+		assert.Equal(t, jsonOf(t, d), jsonOf(t, p))
+		assert.True(t, p.Eq(d))
+
+		m := p.Materialized()
+		assert.False(t, m.IsPersistent())
+		assert.Equal(t, jsonOf(t, d), jsonOf(t, m))
+	})
+
+	t.Run("reads", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+		p := d.Persistent()
+
+		for _, q := range []string{
+			"user.name",
+			"user.tags.1",
+			"user.tags.-1",
+			"items.0.status",
+			"meta",
+		} {
+			want, err := d.Get(Q(q))
+			require.NoError(t, err, q)
+
+			got, err := p.Get(Q(q))
+			require.NoError(t, err, q)
+
+			assert.Equal(t, jsonOf(t, want), jsonOf(t, got), q)
+		}
+
+		_, err := p.Get(Q("user.tags.9"))
+		require.Error(t, err)
+
+		ok, err := p.Has(Q("items.1.id"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("writes match", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		writes := []func(giraffe.Datum) (giraffe.Datum, error){
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Set(Q("user.=name"), "bob")
+			},
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Set(Q("user.tags.+"), "c")
+			},
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Set(Q("items.1.=status"), "on")
+			},
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Set(Q("!user.legacy"), nil)
+			},
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Set(Q("!user.tags.0"), nil)
+			},
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Set(Q(`items[?status=="on"].=status`), "done")
+			},
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Merge(mkDatum(t, `{"meta": {"author": "ann"}, "extra": [1]}`))
+			},
+			func(d giraffe.Datum) (giraffe.Datum, error) {
+				return d.Edit(func(tx *giraffe.Tx) error {
+					if err := tx.Make(Q("a.b.c"), 1); err != nil {
+						return err
+					}
+
+					return tx.Append(Q("user.tags"), "d")
+				})
+			},
+		}
+
+		for i, write := range writes {
+			d := mkDatum(t, doc)
+			p := d.Persistent()
+
+			want, err := write(d)
+			require.NoError(t, err, i)
+
+			got, err := write(p)
+			require.NoError(t, err, i)
+
+			assert.True(t, got.IsPersistent(), i)
+			assert.Equal(t, jsonOf(t, want), jsonOf(t, got), i)
+			assert.Equal(t, jsonOf(t, mkDatum(t, doc)), jsonOf(t, p), i)
+		}
+	})
+
+	t.Run("patches", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var patch giraffe.JsonPatch
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"op": "add", "path": "/user/tags/-", "value": "c"},
+			{"op": "add", "path": "/user/tags/0", "value": "z"},
+			{"op": "remove", "path": "/user/tags/1"},
+			{"op": "replace", "path": "/items/1/status", "value": "on"},
+			{"op": "add", "path": "/items/2", "value": {"id": 3}},
+			{"op": "remove", "path": "/meta/version"}
+		]`), &patch))
+
+		d := mkDatum(t, doc)
+		p := d.Persistent()
+
+		want, err := d.Patched(patch)
+		require.NoError(t, err)
+
+		got, err := p.Patched(patch)
+		require.NoError(t, err)
+		assert.Equal(t, jsonOf(t, want), jsonOf(t, got))
+		assert.Equal(t, jsonOf(t, mkDatum(t, doc)), jsonOf(t, p))
+
+		for _, q := range []string{"#", "user", "user.tags", "items", "items.1", "meta"} {
+			assert.True(t, M(got.Get(Q(q))).IsPersistent(), q)
+		}
+	})
+
+	t.Run("patterns", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+		p := d.Persistent()
+
+		for _, spec := range []string{"**", "items.*.id", "user.tags[1:]", `items[?id>1]`} {
+			want := map[giraffe.Query]string{}
+			for q, v := range M(d.GetAll(Q(spec))) {
+				want[q] = jsonOf(t, v)
+			}
+
+			got := map[giraffe.Query]string{}
+			for q, v := range M(p.GetAll(Q(spec))) {
+				got[q] = jsonOf(t, v)
+			}
+
+			assert.Equal(t, want, got, spec)
+		}
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		versions := []giraffe.Datum{giraffe.OfEmpty().Persistent()}
+		for i := range 100 {
+			last := versions[len(versions)-1]
+			versions = append(versions, M(last.Set(Q("k"+strconv.Itoa(i)), i)))
+		}
+
+		for i, v := range versions {
+			n, err := v.Len()
+			require.NoError(t, err)
+			assert.Equal(t, i, n)
+		}
+
+		arr := giraffe.Of([]giraffe.Datum{}).Persistent()
+		prev := arr
+		for i := range 100 {
+			arr = M(arr.Set(Q("+"), i))
+		}
+
+		n, err := arr.Len()
+		require.NoError(t, err)
+		assert.Equal(t, 100, n)
+
+		n, err = prev.Len()
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		last, err := arr.Get(Q("99"))
+		require.NoError(t, err)
+		assert.Equal(t, "99", jsonOf(t, last))
+	})
+}
+
+func BenchmarkSetLarge(b *testing.B) {
+	d := benchLarge()
+
+	for b.Loop() {
+		M(d.Set(Q("f500.=v"), 1))
+	}
+}
+
+func BenchmarkSetLargePersistent(b *testing.B) {
+	d := benchLarge().Persistent()
+
+	for b.Loop() {
+		M(d.Set(Q("f500.=v"), 1))
+	}
+}
+
+func BenchmarkMergeLarge(b *testing.B) {
+	d := benchLarge()
+	right := giraffe.OfJsonable(map[string]any{"f500": map[string]any{"w": 1}})
+
+	for b.Loop() {
+		M(d.Merge(right))
+	}
+}
+
+func BenchmarkMergeLargePersistent(b *testing.B) {
+	d := benchLarge().Persistent()
+	right := giraffe.OfJsonable(map[string]any{"f500": map[string]any{"w": 1}})
+
+	for b.Loop() {
+		M(d.Merge(right))
+	}
+}
+
+func benchLarge() giraffe.Datum {
+	fields := make(map[string]any, 1000)
+	for i := range 1000 {
+		fields["f"+strconv.Itoa(i)] = map[string]any{"v": i, "tags": []any{"a", "b"}}
+	}
+
+	return giraffe.OfJsonable(fields)
+}
@@ -0,0 +1,86 @@
+package giraffe_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+)
+
+func TestPipe(t *testing.T) {
+	doc := `{
+		"users": [
+			{"name": "ann", "tags": ["a", ["b", ["c"]]]},
+			{"name": "bob", "tags": []}
+		],
+		"keys": {"x": 1, "y": 2},
+		"a|b": "piped"
+	}`
+
+	cases := map[string]string{
+		"#":                           jsonOf(t, mkDatum(t, doc)),
+		"users | 0 | name":            `"ann"`,
+		"users.1 | #":                 `{"name":"bob","tags":[]}`,
+		"users | len":                 "2",
+		"users | keys":                "[0,1]",
+		"users.0 | keys":              `["name","tags"]`,
+		"users.0.tags | flatten":      `["a","b","c"]`,
+		"users.0.tags | flatten|len":  "3",
+		"users.0.name | len":          "3",
+		`\keys | values`:              "[1,2]",
+		"#.keys | keys":               `["x","y"]`,
+		`a\|b`:                        `"piped"`,
+		"users.1 | #.tags | values":   "[]",
+		"users | 0 | # | # | tags.0 ": `"a"`,
+	}
+
+	for spec, expected := range cases {
+		t.Run(spec, func(t *testing.T) {
+			gtesting.Preamble(t)
+
+			d, err := mkDatum(t, doc).Pipe(spec)
+			require.NoError(t, err)
+			assert.Equal(t, expected, jsonOf(t, d))
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		for _, spec := range []string{
+			"users | missing",
+			"users | 0 | name | flatten",
+			"users || len",
+			"users | keys | 7",
+		} {
+			_, err := d.Pipe(spec)
+			require.Error(t, err, spec)
+		}
+
+		_, err := d.Pipe("users | 5")
+		var gErr *giraffe.GiraffeError
+		require.ErrorAs(t, err, &gErr)
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		require.NoError(t, giraffe.RegisterPipeFn("pipe_test_first", func(d giraffe.Datum) (giraffe.Datum, error) {
+			return d.At(0)
+		}))
+
+		require.Error(t, giraffe.RegisterPipeFn("pipe_test_first", nil))
+		require.Error(t, giraffe.RegisterPipeFn("keys", nil))
+		require.Error(t, giraffe.RegisterPipeFn("a.b", nil))
+		require.Error(t, giraffe.RegisterPipeFn("", nil))
+
+		d, err := mkDatum(t, doc).Pipe("users | pipe_test_first | name")
+		require.NoError(t, err)
+		assert.Equal(t, `"ann"`, jsonOf(t, d))
+	})
+}
@@ -0,0 +1,476 @@
+package giraffe_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/dialects"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+func TestQuery_Dynamic(t *testing.T) {
+	doc := `{
+		"selected_id": "u2",
+		"selected_ix": 1,
+		"alias": {"u2": "selected_id"},
+		"users": {"u1": {"name": "ann"}, "u2": {"name": "bob"}},
+		"list": ["x", "y"],
+		"odd.key": "dotted",
+		"ref": "odd.key"
+	}`
+
+	t.Run("get", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		for spec, expected := range map[string]string{
+			"users[.selected_id].name":    `"bob"`,
+			"users.[selected_id].name":    `"bob"`,
+			"list[selected_ix]":           `"y"`,
+			"[ref]":                       `"dotted"`,
+			"users[[alias[selected_id]]]": `{"name":"bob"}`,
+			"users[.[alias.u2]].name":     `"bob"`,
+		} {
+			got, err := d.Get(Q(spec))
+			require.NoError(t, err, spec)
+			assert.Equal(t, expected, jsonOf(t, got), spec)
+		}
+
+		ok, err := d.Has(Q("users[selected_id].name"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := M(mkDatum(t, doc).Set(Q("users[selected_id].=name"), "bea"))
+		assert.Equal(t, `"bea"`, jsonOf(t, M(d.Get(Q("users.u2.name")))))
+
+		d = M(d.Set(Q("list.=[selected_ix]"), "z"))
+		assert.Equal(t, `["x","z"]`, jsonOf(t, M(d.Get(Q("list")))))
+
+		d = M(d.Set(Q("fresh[selected_id].v").WithMake(), 1))
+		assert.Equal(t, `{"u2":{"v":1}}`, jsonOf(t, M(d.Get(Q("fresh")))))
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		q, err := Q("users[selected_id].name").Resolved(func(string) (string, error) {
+			return "u1", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, Q("users.u1.name"), q)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		for _, spec := range []string{
+			"users[missing].name",
+			"users[users].name",
+			"alias[users.[selected_id].name]",
+		} {
+			_, err := d.Get(Q(spec))
+			require.Error(t, err, spec)
+		}
+
+		_, err := giraffe.GQParse("users[selected_id")
+		require.Error(t, err)
+	})
+}
+
+func TestQuery_Patterns(t *testing.T) {
+	doc := `{
+		"items": [
+			{"id": 1, "tags": {"id": "t1"}},
+			{"id": 2},
+			{"name": "no id"},
+			{"id": 4}
+		],
+		"meta": {"id": "m"}
+	}`
+
+	all := func(t *testing.T, d giraffe.Datum, spec string) map[giraffe.Query]string {
+		t.Helper()
+
+		seq, err := d.GetAll(Q(spec))
+		require.NoError(t, err, spec)
+
+		fin := map[giraffe.Query]string{}
+		for q, v := range seq {
+			fin[q] = jsonOf(t, v)
+		}
+
+		return fin
+	}
+
+	t.Run("get all", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		assert.Equal(t, map[giraffe.Query]string{
+			"items.0.id": "1",
+			"items.1.id": "2",
+			"items.3.id": "4",
+		}, all(t, d, "items.*.id"))
+
+		assert.Equal(t, map[giraffe.Query]string{
+			"items.0.id":      "1",
+			"items.0.tags.id": `"t1"`,
+			"items.1.id":      "2",
+			"items.3.id":      "4",
+			"meta.id":         `"m"`,
+		}, all(t, d, "**.id"))
+
+		assert.Equal(t, map[giraffe.Query]string{
+			"items.1.id": "2",
+			"items.3.id": "4",
+		}, all(t, d, "items[1:].id"))
+
+		assert.Equal(t, map[giraffe.Query]string{
+			"items.0.id": "1",
+		}, all(t, d, "items[:-3].id"))
+
+		assert.Equal(t, map[giraffe.Query]string{
+			"items.3": `{"id":4}`,
+		}, all(t, d, "items.-1"))
+
+		assert.Equal(t, map[giraffe.Query]string{
+			"meta.id": `"m"`,
+		}, all(t, d, "meta.id"))
+
+		assert.Empty(t, all(t, d, "missing.*"))
+		assert.Len(t, all(t, d, "**"), 14)
+	})
+
+	t.Run("stops early", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		seq, err := mkDatum(t, doc).GetAll(Q("**"))
+		require.NoError(t, err)
+
+		n := 0
+		for range seq {
+			n++
+			if n == 2 {
+				break
+			}
+		}
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run("negative index", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		assert.Equal(t, `{"name":"no id"}`, jsonOf(t, M(d.Get(Q("items.-2")))))
+
+		ok, err := d.Has(Q("items.-4.id"))
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = d.Has(Q("items.-5"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = d.Get(Q("items.-5"))
+		require.Error(t, err)
+
+		d = M(d.Set(Q("items.-1.=id"), 5))
+		assert.Equal(t, "5", jsonOf(t, M(d.Get(Q("items.3.id")))))
+		assert.Equal(t, `{"id":2}`, jsonOf(t, M(d.Get(Q("items.1")))))
+	})
+
+	t.Run("digit keys", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, `{"a": {"-1": 3, "0": 4, "01": 5}, "l": [6, 7]}`)
+
+		for spec, expected := range map[string]string{
+			"a.-1":  "3",
+			"a.0":   "4",
+			`a.\-1`: "3",
+			`a.\0`:  "4",
+			`a.\01`: "5",
+			"l.-1":  "7",
+			"l.0":   "6",
+		} {
+			got, err := d.Get(Q(spec))
+			require.NoError(t, err, spec)
+			assert.Equal(t, expected, jsonOf(t, got), spec)
+
+			ok, err := d.Has(Q(spec))
+			require.NoError(t, err, spec)
+			assert.True(t, ok, spec)
+		}
+
+		_, err := d.Get(Q(`l.\0`))
+		require.Error(t, err)
+
+		d = M(d.Set(Q("a.-2"), 8))
+		assert.Equal(t, "8", jsonOf(t, M(d.Get(Q(`a.\-2`)))))
+
+		d = M(d.Set(Q("l.=-1"), 9))
+		assert.Equal(t, "[6,9]", jsonOf(t, M(d.Get(Q("l")))))
+
+		d = M(d.Set(Q(`!a.\0`), nil))
+		assert.Equal(t, `{"-1":3,"-2":8,"01":5}`, jsonOf(t, M(d.Get(Q("a")))))
+	})
+
+	t.Run("paths round trip", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, `{"a": {"-1": [1, {"0": 2}], "0": {"x.y": 3}}, "1": [[4]]}`)
+
+		seq, err := d.GetAll(Q("**"))
+		require.NoError(t, err)
+
+		n := 0
+		for q, v := range seq {
+			n++
+			got, err := d.Get(q)
+			require.NoError(t, err, q.String())
+			assert.True(t, v.Eq(got), q.String())
+		}
+		assert.Equal(t, 11, n)
+
+		d = M(d.Set(Q("a.*.=0"), 5))
+		assert.Equal(t, `{"-1":[5,{"0":2}],"0":{"0":5,"x.y":3}}`, jsonOf(t, M(d.Get(Q("a")))))
+	})
+
+	t.Run("get rejects patterns", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := mkDatum(t, doc).Get(Q("items.*.id"))
+
+		var gErr *giraffe.GiraffeError
+		require.ErrorAs(t, err, &gErr)
+		assert.Equal(t, giraffe.ErrCodeDataReadIndeterministicQuery, gErr.Code())
+	})
+}
+
+func TestQuery_Predicates(t *testing.T) {
+	doc := `{
+		"items": [
+			{"id": 1, "status": "active", "price": 5, "tags": ["a"]},
+			{"id": 2, "status": "done", "price": 12.5},
+			{"id": 3, "status": "active", "price": 20, "flag": true},
+			{"id": 4, "price": "n/a"}
+		]
+	}`
+
+	ids := func(t *testing.T, d giraffe.Datum, spec string) []string {
+		t.Helper()
+
+		seq, err := d.GetAll(Q(spec))
+		require.NoError(t, err, spec)
+
+		var fin []string
+		for _, v := range seq {
+			fin = append(fin, jsonOf(t, v))
+		}
+
+		return fin
+	}
+
+	t.Run("get all", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		for spec, expected := range map[string][]string{
+			`items[?status=="active"].id`: {"1", "3"},
+			`items[?status!="active"].id`: {"2"},
+			`items[?price>10].id`:         {"2", "3"},
+			`items[?price<=12.5].id`:      {"1", "2"},
+			`items[?price==20.0].id`:      {"3"},
+			`items[?status>="b"].id`:      {"2"},
+			`items[?flag==true].id`:       {"3"},
+			`items[?flag].id`:             {"3"},
+			`items[?tags.0=="a"].id`:      {"1"},
+			`items.*.id[?#>=3]`:           nil,
+			`items[?id>=3].id`:            {"3", "4"},
+			`items[?missing==1].id`:       nil,
+		} {
+			assert.Equal(t, expected, ids(t, d, spec), spec)
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := M(mkDatum(t, doc).Set(Q(`items[?status=="active"].=status`), "archived"))
+		assert.Equal(t,
+			[]string{`"archived"`, `"done"`, `"archived"`},
+			ids(t, d, "items.*.status"))
+
+		d = M(d.Set(Q(`items[?price>10].$seen.at`), 1))
+		assert.Equal(t, []string{"2", "3"}, ids(t, d, `items[?seen.at==1].id`))
+
+		d = M(d.Set(Q(`items[?tags].tags.+`), "b"))
+		assert.Equal(t, `["a","b"]`, jsonOf(t, M(d.Get(Q("items.0.tags")))))
+
+		same := M(d.Set(Q(`items[?id==9].=status`), "x"))
+		assert.True(t, same.Eq(d))
+	})
+
+	t.Run("write errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		_, err := d.Set(Q(`items[?id==1].status`), "x")
+		require.Error(t, err)
+
+		_, err = d.Set(Q(`!items[?id==1]`), nil)
+
+		var gErr *giraffe.GiraffeError
+		require.ErrorAs(t, err, &gErr)
+		assert.Equal(t, giraffe.ErrCodeDataWriteIndeterministicQuery, gErr.Code())
+	})
+}
+
+func TestQuery_Dialects(t *testing.T) {
+	doc := `{
+		"users": [
+			{"id": 1, "name": "ann", "a/b": "slashed"},
+			{"id": 2, "name": "bob"}
+		],
+		"list": ["x"]
+	}`
+
+	t.Run("get", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		for spec, expected := range map[string]string{
+			"~jsonpointer./users/0/name":     `"ann"`,
+			"~jsonpointer./users/0/a~1b":     `"slashed"`,
+			"~jsonpath.$.users[1].name":      `"bob"`,
+			"~jsonpath.$['users'][-1].id":    "2",
+			`~jsonpath.$["users"][0]["a/b"]`: `"slashed"`,
+		} {
+			got, err := d.Get(Q(spec))
+			require.NoError(t, err, spec)
+			assert.Equal(t, expected, jsonOf(t, got), spec)
+		}
+
+		ok, err := d.Has(Q("~jsonpointer./users/2"))
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("keys of digits and the empty key", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, `{"": {"0": 1, "-1": 2}, "01": 3, "l": [4, 5]}`)
+
+		for spec, expected := range map[string]string{
+			"~jsonpointer.//0":      "1",
+			"~jsonpointer.//-1":     "2",
+			"~jsonpointer./01":      "3",
+			"~jsonpointer./l/1":     "5",
+			`~jsonpath.$[''][0]`:    "1",
+			`~jsonpath.$['']['0']`:  "1",
+			`~jsonpath.$[""]['-1']`: "2",
+			`~jsonpath.$['01']`:     "3",
+			`~jsonpath.$.l[-1]`:     "5",
+			`[""].\-1`:              "2",
+		} {
+			got, err := d.Get(Q(spec))
+			require.NoError(t, err, spec)
+			assert.Equal(t, expected, jsonOf(t, got), spec)
+		}
+
+		_, err := d.Get(Q("~jsonpointer./l/-1"))
+		require.Error(t, err)
+
+		d = M(d.Set(Q("~jsonpointer.//1"), 6))
+		assert.Equal(t, `{"-1":2,"0":1,"1":6}`, jsonOf(t, M(d.Get(Q(`[""]`)))))
+	})
+
+	t.Run("get all", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		seq, err := mkDatum(t, doc).GetAll(Q(`~jsonpath.$.users[?(@.name == 'bob')].id`))
+		require.NoError(t, err)
+
+		fin := map[giraffe.Query]string{}
+		for q, v := range seq {
+			fin[q] = jsonOf(t, v)
+		}
+		assert.Equal(t, map[giraffe.Query]string{"users.1.id": "2"}, fin)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := M(mkDatum(t, doc).Set(Q("~jsonpointer./list/-"), "y"))
+		assert.Equal(t, `["x","y"]`, jsonOf(t, M(d.Get(Q("list")))))
+	})
+
+	t.Run("export", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string][3]string{
+			"users.0.name":                  {"users.0.name", "/users/0/name", "$.users[0].name"},
+			"~jsonpointer./users/0/a~1b":    {"users.0.a/b", "/users/0/a~1b", `$.users[0]["a/b"]`},
+			"~jsonpath.$..users[?(@.id>1)]": {"**.users.[?id>1]", "", `$..users[?@.id > 1]`},
+			"list.+":                        {"list.+0", "", ""},
+		} {
+			for i, dialect := range []dialects.Dialect{
+				dialects.Giraffe1v1,
+				dialects.JsonPointer,
+				dialects.JsonPath,
+			} {
+				actual, err := Q(spec).ExportAs(dialect)
+				if expected[i] == "" {
+					require.Error(t, err, spec)
+					continue
+				}
+
+				require.NoError(t, err, spec)
+				assert.Equal(t, expected[i], actual, spec)
+
+				back := Q("~" + dialect.String() + "." + actual)
+				assert.Equal(t, Q(spec).String(), back.String(), spec)
+			}
+		}
+
+		_, err := Q("=users.0.name").ExportAs(dialects.JsonPath)
+		require.ErrorContains(t, err, "not exportable")
+
+		_, err = Q("users").ExportAs(dialects.Unknown)
+		require.Error(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, spec := range []string{
+			"~nope.a",
+			"~jsonpointer.users",
+			"~jsonpath.users",
+			"~jsonpath.$.users[0,1]",
+			"~jsonpath.$.users[?(@.id > 1 && @.id < 3)]",
+		} {
+			_, err := giraffe.GQParse(spec)
+			require.Error(t, err, spec)
+		}
+
+		_, err := giraffe.GQParse("~jsonpath.$[::2]")
+		require.ErrorContains(t, err, "unsupported by the dialect")
+	})
+}
@@ -0,0 +1,145 @@
+package giraffe_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+)
+
+func TestInferSchema(t *testing.T) {
+	samples := []giraffe.Datum{
+		mkDatum(t, `{
+			"id": 1,
+			"name": "a",
+			"score": 1,
+			"tags": ["x"],
+			"items": [{"sku": "s1", "qty": 1}, {"sku": "s2"}],
+			"meta": {"created_at": "2020"}
+		}`),
+		mkDatum(t, `{
+			"id": 2,
+			"name": null,
+			"score": 1.5,
+			"tags": [],
+			"items": [{"sku": "s3", "qty": 2}],
+			"meta": {"created_at": "2021", "by": "x"}
+		}`),
+		mkDatum(t, `{
+			"id": 3,
+			"score": 2,
+			"tags": ["y", "z"],
+			"items": [],
+			"meta": {"created_at": "2022"}
+		}`),
+	}
+
+	inferred, err := giraffe.InferSchema(samples...)
+	require.NoError(t, err)
+
+	t.Run("paths", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		assert.Equal(t, 3, inferred.Samples())
+
+		var paths []string
+		for _, p := range inferred.Paths() {
+			paths = append(paths, p.String())
+		}
+
+		assert.Equal(t, []string{
+			"InferredPath[id, int, 3/3]",
+			"InferredPath[items, obj[], 3/3]",
+			"InferredPath[items.*.qty, int?, 2/3]",
+			"InferredPath[items.*.sku, str, 3/3]",
+			"InferredPath[meta, obj, 3/3]",
+			"InferredPath[meta.by, str?, 1/3]",
+			"InferredPath[meta.created_at, str, 3/3]",
+			"InferredPath[name, str?, 2/3]",
+			"InferredPath[score, int|flt, 3/3]",
+			"InferredPath[tags, str[], 3/3]",
+		}, paths)
+	})
+
+	t.Run("validates samples", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		schema := inferred.Schema()
+		for _, it := range samples {
+			require.NoError(t, schema.Validate(it))
+		}
+
+		require.Error(t, schema.Validate(mkDatum(t, `{
+			"id": "4",
+			"score": 1,
+			"tags": [],
+			"items": [{"qty": 1}],
+			"meta": {"created_at": "2023"}
+		}`)))
+	})
+
+	t.Run("json schema", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		exported, err := inferred.JsonSchema()
+		require.NoError(t, err)
+
+		typ, err := exported.QStr(giraffe.Q("properties.items.items.properties.sku.type"))
+		require.NoError(t, err)
+		assert.Equal(t, "string", typ)
+	})
+
+	t.Run("go struct", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		src, err := inferred.GoStruct("order")
+		require.NoError(t, err)
+
+		assert.Equal(t, "type Order struct {\n"+
+			"\tId    int64        `json:\"id\"`\n"+
+			"\tItems []OrderItems `json:\"items\"`\n"+
+			"\tMeta  OrderMeta    `json:\"meta\"`\n"+
+			"\tName  *string      `json:\"name,omitempty\"`\n"+
+			"\tScore float64      `json:\"score\"`\n"+
+			"\tTags  []string     `json:\"tags\"`\n"+
+			"}\n\n"+
+			"type OrderItems struct {\n"+
+			"\tQty *int64 `json:\"qty,omitempty\"`\n"+
+			"\tSku string `json:\"sku\"`\n"+
+			"}\n\n"+
+			"type OrderMeta struct {\n"+
+			"\tBy        *string `json:\"by,omitempty\"`\n"+
+			"\tCreatedAt string  `json:\"created_at\"`\n"+
+			"}\n", src)
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		mixed, err := giraffe.InferSchema(
+			mkDatum(t, `{"a": [1], "b": [[1]]}`),
+			mkDatum(t, `{"a": "x", "b": [[2]]}`),
+		)
+		require.NoError(t, err)
+
+		var paths []string
+		for _, p := range mixed.Paths() {
+			paths = append(paths, p.String())
+		}
+
+		assert.Equal(t, []string{
+			"InferredPath[a, any, 2/2]",
+			"InferredPath[b, any[], 2/2]",
+		}, paths)
+	})
+
+	t.Run("non obj sample", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := giraffe.InferSchema(giraffe.Of(1))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,218 @@
+package giraffe_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+func violations(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	var sErr *giraffe.SchemaError
+	require.ErrorAs(t, err, &sErr)
+
+	found := make(map[string]string)
+	for _, v := range sErr.Violations() {
+		found[v.Query.String()] = v.Reason
+	}
+
+	return found
+}
+
+func TestSchema_Validate(t *testing.T) {
+	item := giraffe.
+		SchemaOf(map[giraffe.Query]giraffe.Type{
+			Q("id"): giraffe.Int,
+		}).
+		WithField(Q("status"), giraffe.FieldOf(giraffe.Str).WithEnum(
+			giraffe.Of("active"),
+			giraffe.Of("closed"),
+		))
+
+	schema := giraffe.
+		SchemaOf(map[giraffe.Query]giraffe.Type{
+			Q("user.name"):  giraffe.Str,
+			Q("user.email"): giraffe.Str.WithNil(),
+			Q("tags"):       giraffe.Str.WithArr(),
+			Q("score"):      giraffe.Int | giraffe.Flt,
+		}).
+		WithField(Q("user.age"), giraffe.FieldOf(giraffe.Int).WithRange(
+			big.NewFloat(0),
+			big.NewFloat(150),
+		)).
+		WithField(Q("user.id"), giraffe.FieldOf(giraffe.Str).WithPattern(
+			regexp.MustCompile(`^u-[0-9]+$`),
+		)).
+		WithField(Q("items"), giraffe.FieldOf(giraffe.Obj.WithArr()).WithSchema(item))
+
+	t.Run("valid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		dat := mkDatum(t, `{
+			"user": {"name": "x", "age": 30, "id": "u-1", "email": null},
+			"tags": ["a", "b"],
+			"score": 1.5,
+			"items": [{"id": 1, "status": "active"}]
+		}`)
+
+		require.NoError(t, schema.Validate(dat))
+	})
+
+	t.Run("all violations reported", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		dat := mkDatum(t, `{
+			"user": {"age": 200, "id": "x-1"},
+			"tags": ["a", 1],
+			"score": "high",
+			"items": [{"id": 1, "status": "active"}, {"id": "2", "status": "gone"}]
+		}`)
+
+		err := schema.Validate(dat)
+		require.Error(t, err)
+
+		assert.Equal(t, map[string]string{
+			"items.1.id":     "expected int, got str",
+			"items.1.status": "not in enum: gone",
+			"score":          "expected int|flt, got str",
+			"tags.1":         "expected str, got int",
+			"user.age":       "above maximum: 150",
+			"user.id":        "does not match pattern: ^u-[0-9]+$",
+			"user.name":      "missing",
+		}, violations(t, err))
+	})
+
+	t.Run("root must be obj", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		err := schema.Validate(giraffe.Of(1))
+		require.Error(t, err)
+
+		var sErr *giraffe.SchemaError
+		require.True(t, errors.As(err, &sErr))
+		require.Len(t, sErr.Violations(), 1)
+		assert.Equal(t, giraffe.GQErr(), sErr.Violations()[0].Query)
+	})
+
+	t.Run("null on required", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		err := giraffe.
+			SchemaOf(map[giraffe.Query]giraffe.Type{Q("a"): giraffe.Int}).
+			Validate(mkDatum(t, `{"a": null}`))
+
+		assert.Equal(t, map[string]string{"a": "unexpected null"}, violations(t, err))
+	})
+}
+
+func TestSchema_JsonSchema(t *testing.T) {
+	t.Run("export", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		schema := giraffe.
+			SchemaOf(map[giraffe.Query]giraffe.Type{
+				Q("a.b"): giraffe.Int,
+				Q("a.c"): giraffe.Str.WithNil(),
+				Q("d"):   giraffe.Bln.WithArr(),
+			}).
+			WithField(Q("e"), giraffe.FieldOf(giraffe.Str).WithEnum(giraffe.Of("x")))
+
+		exported, err := json.Marshal(schema)
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"type": "object",
+			"required": ["a", "d", "e"],
+			"properties": {
+				"a": {
+					"type": "object",
+					"required": ["b"],
+					"properties": {
+						"b": {"type": "integer"},
+						"c": {"type": ["string", "null"]}
+					}
+				},
+				"d": {"type": "array", "items": {"type": "boolean"}},
+				"e": {"type": "string", "enum": ["x"]}
+			}
+		}`, string(exported))
+	})
+
+	t.Run("import", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		schema, err := giraffe.SchemaOfJson(mkDatum(t, `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"type": "object",
+			"required": ["id", "items"],
+			"properties": {
+				"id": {"type": "string", "pattern": "^[a-z]+$"},
+				"n": {"type": "number", "minimum": 1},
+				"items": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"required": ["k"],
+						"properties": {"k": {"type": "integer"}}
+					}
+				}
+			}
+		}`))
+		require.NoError(t, err)
+
+		require.NoError(t, schema.Validate(mkDatum(t, `{"id": "ab", "items": [{"k": 1}], "n": 2}`)))
+
+		err = schema.Validate(mkDatum(t, `{"id": "AB", "items": [{}], "n": 0.5}`))
+		assert.Equal(t, map[string]string{
+			"id":        "does not match pattern: ^[a-z]+$",
+			"items.0.k": "missing",
+			"n":         "below minimum: 1",
+		}, violations(t, err))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		schema := giraffe.
+			SchemaOf(map[giraffe.Query]giraffe.Type{
+				Q("a"): giraffe.Int,
+			}).
+			WithField(Q("b"), giraffe.FieldOf(giraffe.Obj.WithNil()).WithSchema(
+				giraffe.SchemaOf(map[giraffe.Query]giraffe.Type{
+					Q("c"): giraffe.Str.WithArr().WithNil(),
+				}),
+			))
+
+		exported, err := schema.JsonSchema()
+		require.NoError(t, err)
+
+		imported, err := giraffe.SchemaOfJson(exported)
+		require.NoError(t, err)
+
+		assert.Equal(t, schema.String(), imported.String())
+
+		reExported, err := imported.JsonSchema()
+		require.NoError(t, err)
+		assert.True(t, exported.Eq(reExported), reExported.Pretty())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := giraffe.SchemaOfJson(mkDatum(t, `{"properties": {"a": {"type": "nope"}}}`))
+		require.Error(t, err)
+
+		_, err = giraffe.SchemaOfJson(mkDatum(t, `{"properties": {"a": {"type": ["array", "string"]}}}`))
+		require.Error(t, err)
+	})
+}
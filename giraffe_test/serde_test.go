@@ -0,0 +1,499 @@
+package giraffe_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/serdes"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+func TestSerdes(t *testing.T) {
+	doc := `{
+		"a": {"b": 1, "c": [true, "x", -7]},
+		"d": "text",
+		"e": [],
+		"f": {},
+		"g": 1.5,
+		"h": -300000
+	}`
+
+	withNull := `{"a": null, "b": [null, 1]}`
+
+	all := map[string]serdes.Serde[giraffe.Datum]{
+		"yaml":    giraffe.DatumYamlSerde(),
+		"toml":    giraffe.DatumTomlSerde(),
+		"cbor":    giraffe.DatumCborSerde(),
+		"msgpack": giraffe.DatumMsgpackSerde(),
+		"binary":  giraffe.DatumBinarySerde(),
+	}
+
+	for name, serde := range all {
+		t.Run(name+" round trip", func(t *testing.T) {
+			gtesting.Preamble(t)
+
+			d := mkDatum(t, doc)
+
+			b, err := serde.Write(d)
+			require.NoError(t, err)
+
+			read, err := serde.Read(b)
+			require.NoError(t, err)
+			assert.Equal(t, jsonOf(t, d), jsonOf(t, read))
+
+			buf := new(bytes.Buffer)
+			require.NoError(t, serde.StreamTo(buf, d))
+
+			streamed, err := serde.StreamFrom(buf)
+			require.NoError(t, err)
+			assert.Equal(t, jsonOf(t, d), jsonOf(t, streamed))
+		})
+
+		if name == "toml" {
+			continue
+		}
+
+		t.Run(name+" null", func(t *testing.T) {
+			gtesting.Preamble(t)
+
+			d := mkDatum(t, withNull)
+
+			b, err := serde.Write(d)
+			require.NoError(t, err)
+
+			read, err := serde.Read(b)
+			require.NoError(t, err)
+			assert.Equal(t, jsonOf(t, d), jsonOf(t, read))
+		})
+
+		t.Run(name+" big values", func(t *testing.T) {
+			gtesting.Preamble(t)
+
+			d := giraffe.Of(map[string]giraffe.Datum{
+				"i": giraffe.Of(bigInt(t, "-123456789012345678901234567890")),
+				"u": giraffe.Of(bigInt(t, "18446744073709551615")),
+				"f": M(giraffe.From(bigFlt(t, "1.000000000000000000000000000001", 200))),
+				"p": M(giraffe.From(bigFlt(t, "18446744073709551616", 53))),
+				"s": M(giraffe.From(bigFlt(t, "1e-300", 53))),
+				"t": M(giraffe.From(bigFlt(t, "0.1", 53))),
+				"w": M(giraffe.From(bigFlt(t, "-9.999999999999999999e+999", 100))),
+			})
+
+			b, err := serde.Write(d)
+			require.NoError(t, err)
+
+			read, err := serde.Read(b)
+			require.NoError(t, err)
+			assert.True(t, d.Eq(read), string(b))
+
+			for _, q := range []string{"i", "u"} {
+				want := M(M(d.Get(Q(q))).Int())
+				got, err := M(read.Get(Q(q))).Int()
+				require.NoError(t, err, q)
+				assert.Zero(t, want.Cmp(got), got.String())
+			}
+
+			for _, q := range []string{"f", "p", "s", "t", "w"} {
+				want := M(M(d.Get(Q(q))).Flt())
+				got, err := M(read.Get(Q(q))).Flt()
+				require.NoError(t, err, q)
+				assert.Zero(t, want.Cmp(got), got.String())
+			}
+		})
+
+		t.Run(name+" high precision floats", func(t *testing.T) {
+			gtesting.Preamble(t)
+
+			for _, f := range []*big.Float{
+				bigFlt(t, "3.14159265358979323846264338327950288419716939937510582097494459", 300),
+				bigFlt(t, "123456789.123456789123456789", 90),
+				bigFlt(t, "-1.5e+5000", 100),
+				bigFlt(t, "1e-400", 200),
+				bigFlt(t, "0.1", 400),
+				bigFlt(t, "-2.5", 1000),
+			} {
+				d := giraffe.Of(map[string]giraffe.Datum{"f": M(giraffe.From(f))})
+
+				read, err := serde.Read(M(serde.Write(d)))
+				require.NoError(t, err, f.String())
+
+				got, err := M(read.Get(Q("f"))).Flt()
+				require.NoError(t, err, f.String())
+				assert.Zero(t, f.Cmp(got), got.Text('g', -1))
+
+				again, err := serde.Read(M(serde.Write(read)))
+				require.NoError(t, err, f.String())
+				assert.True(t, read.Eq(again), f.String())
+
+				if name == "binary" {
+					assert.True(t, d.Eq(read), f.String())
+				}
+			}
+		})
+	}
+
+	t.Run("yaml anchors and merge", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d, err := giraffe.DatumYamlSerde().Read([]byte(`
+base: &base
+  x: 1
+  y: 2
+derived:
+  <<: *base
+  y: 3
+ref: *base
+`))
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"base":{"x":1,"y":2},"derived":{"x":1,"y":3},"ref":{"x":1,"y":2}}`, jsonOf(t, d))
+	})
+
+	t.Run("yaml duplicated key", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := giraffe.DatumYamlSerde().Read([]byte("a: 1\na: 2\n"))
+		require.Error(t, err)
+	})
+
+	t.Run("toml rejects", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := giraffe.DatumTomlSerde().Write(mkDatum(t, withNull))
+		require.Error(t, err)
+
+		_, err = giraffe.DatumTomlSerde().Write(mkDatum(t, `[1]`))
+		require.Error(t, err)
+
+		i, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+		require.True(t, ok)
+
+		_, err = giraffe.DatumTomlSerde().Write(giraffe.Of(map[string]giraffe.Datum{"a": giraffe.Of(i)}))
+		require.Error(t, err)
+
+		f := bigFlt(t, "1.0000000000000000000000000001", 200)
+		_, err = giraffe.DatumTomlSerde().Write(giraffe.Of(map[string]giraffe.Datum{"a": M(giraffe.From(f))}))
+		require.ErrorContains(t, err, "toml float out of range: 1.0000000000000000000000000001")
+	})
+
+	t.Run("toml sorts keys", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d, err := giraffe.DatumOrderedSerde().Read([]byte(`{"b": 1, "a": {"d": 2, "c": 3}}`))
+		require.NoError(t, err)
+
+		b, err := giraffe.DatumTomlSerde().Write(d)
+		require.NoError(t, err)
+		assert.Equal(t, "b = 1\n\n[a]\nc = 3\nd = 2\n", string(b))
+	})
+
+	t.Run("binary malformed", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, serde := range []serdes.Serde[giraffe.Datum]{
+			giraffe.DatumCborSerde(),
+			giraffe.DatumMsgpackSerde(),
+			giraffe.DatumBinarySerde(),
+		} {
+			b, err := serde.Write(mkDatum(t, doc))
+			require.NoError(t, err)
+
+			_, err = serde.Read(b[:len(b)-1])
+			require.Error(t, err)
+
+			_, err = serde.Read(append(b, 0))
+			require.Error(t, err)
+		}
+	})
+	t.Run("binary keeps types", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		serde := giraffe.DatumBinarySerde()
+
+		f, _, err := big.ParseFloat("2.5", 10, 100, big.ToNearestEven)
+		require.NoError(t, err)
+
+		d := M(mkDatum(t, `{"z": 1, "y": 1.0, "x": null, "w": [1.5, -1, "s"]}`).
+			Ordered().
+			Set(Q("v"), f))
+
+		b, err := serde.Write(d)
+		require.NoError(t, err)
+
+		read, err := serde.Read(b)
+		require.NoError(t, err)
+
+		assert.True(t, read.IsOrdered())
+		assert.Equal(t, jsonOf(t, d), jsonOf(t, read))
+		assert.True(t, d.Eq(read))
+
+		for _, q := range []string{"z", "y", "x", "w.0", "w.1", "v"} {
+			assert.Equal(t, M(d.Get(Q(q))).Type(), M(read.Get(Q(q))).Type(), q)
+		}
+
+		readF := M(M(read.Get(Q("v"))).Flt())
+		assert.Equal(t, f.Prec(), readF.Prec())
+		assert.Zero(t, f.Cmp(readF))
+
+		persisted, err := serde.Write(d.Persistent())
+		require.NoError(t, err)
+		assert.Equal(t, jsonOf(t, d.Unordered()), jsonOf(t, M(serde.Read(persisted))))
+	})
+
+	t.Run("binary version", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		b, err := giraffe.DatumBinarySerde().Write(mkDatum(t, doc))
+		require.NoError(t, err)
+
+		b[2]++
+		_, err = giraffe.DatumBinarySerde().Read(b)
+		require.ErrorContains(t, err, "unsupported binary datum version")
+
+		_, err = giraffe.DatumBinarySerde().Read([]byte(doc))
+		require.ErrorContains(t, err, "not a binary datum")
+	})
+}
+
+// TestSerdes_CborVectors checks the CBOR serde against the examples of RFC
+// 8949, appendix A. Byte strings, tagged dates and NaN have no Datum, and are
+// left out.
+func TestSerdes_CborVectors(t *testing.T) {
+	gtesting.Preamble(t)
+
+	serde := giraffe.DatumCborSerde()
+
+	flt := func(f float64) giraffe.Datum {
+		return M(giraffe.From(big.NewFloat(f)))
+	}
+
+	bigI := func(s string) giraffe.Datum {
+		return giraffe.Of(bigInt(t, s))
+	}
+
+	// JSON documents may not be scalars.
+	of := func(j string) giraffe.Datum {
+		return M(mkDatum(t, "["+j+"]").At(0))
+	}
+
+	// Encoded the same way by the serde, as it prefers float64 for floats.
+	encoded := []struct {
+		want giraffe.Datum
+		hex  string
+	}{
+		{of(`0`), "00"},
+		{of(`1`), "01"},
+		{of(`10`), "0a"},
+		{of(`23`), "17"},
+		{of(`24`), "1818"},
+		{of(`25`), "1819"},
+		{of(`100`), "1864"},
+		{of(`1000`), "1903e8"},
+		{of(`1000000`), "1a000f4240"},
+		{of(`1000000000000`), "1b000000e8d4a51000"},
+		{bigI("18446744073709551615"), "1bffffffffffffffff"},
+		{bigI("18446744073709551616"), "c249010000000000000000"},
+		{bigI("-18446744073709551616"), "3bffffffffffffffff"},
+		{bigI("-18446744073709551617"), "c349010000000000000000"},
+		{of(`-1`), "20"},
+		{of(`-10`), "29"},
+		{of(`-100`), "3863"},
+		{of(`-1000`), "3903e7"},
+		{flt(1.1), "fb3ff199999999999a"},
+		{flt(1.0e+300), "fb7e37e43c8800759c"},
+		{flt(-4.1), "fbc010666666666666"},
+		{of(`false`), "f4"},
+		{of(`true`), "f5"},
+		{of(`null`), "f6"},
+		{of(`""`), "60"},
+		{of(`"a"`), "6161"},
+		{of(`"IETF"`), "6449455446"},
+		{of(`"\"\\"`), "62225c"},
+		{of(`"\u00fc"`), "62c3bc"},
+		{of(`"\u6c34"`), "63e6b0b4"},
+		{of(`"\ud800\udd51"`), "64f0908591"},
+		{of(`[]`), "80"},
+		{of(`[1, 2, 3]`), "83010203"},
+		{of(`[1, [2, 3], [4, 5]]`), "8301820203820405"},
+		{
+			of(`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25]`),
+			"98190102030405060708090a0b0c0d0e0f101112131415161718181819",
+		},
+		{of(`{}`), "a0"},
+		{of(`{"a": 1, "b": [2, 3]}`), "a26161016162820203"},
+		{of(`["a", {"b": "c"}]`), "826161a161626163"},
+		{
+			of(`{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}`),
+			"a56161614161626142616361436164614461656145",
+		},
+	}
+
+	// Read only, the serde writing them otherwise.
+	decoded := []struct {
+		want giraffe.Datum
+		hex  string
+	}{
+		{flt(0.0), "f90000"},
+		{flt(math.Copysign(0, -1)), "f98000"},
+		{flt(1.0), "f93c00"},
+		{flt(1.5), "f93e00"},
+		{flt(65504.0), "f97bff"},
+		{flt(100000.0), "fa47c35000"},
+		{flt(3.4028234663852886e+38), "fa7f7fffff"},
+		{flt(5.960464477539063e-8), "f90001"},
+		{flt(0.00006103515625), "f90400"},
+		{flt(-4.0), "f9c400"},
+		{flt(math.Inf(1)), "f97c00"},
+		{flt(math.Inf(-1)), "f9fc00"},
+		{flt(math.Inf(1)), "fa7f800000"},
+		{flt(math.Inf(-1)), "faff800000"},
+		{flt(math.Inf(1)), "fb7ff0000000000000"},
+		{flt(math.Inf(-1)), "fbfff0000000000000"},
+		{of(`null`), "f7"},
+		{of(`"streaming"`), "7f657374726561646d696e67ff"},
+		{of(`[]`), "9fff"},
+		{of(`[1, [2, 3], [4, 5]]`), "9f018202039f0405ffff"},
+		{of(`[1, [2, 3], [4, 5]]`), "9f01820203820405ff"},
+		{of(`[1, [2, 3], [4, 5]]`), "83018202039f0405ff"},
+		{of(`[1, [2, 3], [4, 5]]`), "83019f0203ff820405"},
+		{of(`{"a": 1, "b": [2, 3]}`), "bf61610161629f0203ffff"},
+		{of(`["a", {"b": "c"}]`), "826161bf61626163ff"},
+		{of(`{"Fun": true, "Amt": -2}`), "bf6346756ef563416d7421ff"},
+	}
+
+	for _, v := range encoded {
+		b, err := hex.DecodeString(v.hex)
+		require.NoError(t, err)
+
+		read, err := serde.Read(b)
+		require.NoError(t, err, v.hex)
+		assert.True(t, v.want.Eq(read), v.hex)
+
+		written, err := serde.Write(v.want)
+		require.NoError(t, err, v.hex)
+		assert.Equal(t, v.hex, hex.EncodeToString(written))
+	}
+
+	for _, v := range decoded {
+		b, err := hex.DecodeString(v.hex)
+		require.NoError(t, err)
+
+		read, err := serde.Read(b)
+		require.NoError(t, err, v.hex)
+		assert.True(t, v.want.Eq(read), v.hex)
+	}
+
+	for _, unsupported := range []string{
+		"f97e00",
+		"fa7fc00000",
+		"fb7ff8000000000000",
+		"40",
+		"4401020304",
+		"f0",
+		"f818",
+	} {
+		b, err := hex.DecodeString(unsupported)
+		require.NoError(t, err)
+
+		_, err = serde.Read(b)
+		require.Error(t, err, unsupported)
+	}
+}
+
+func FuzzDatumCbor(f *testing.F) {
+	fuzzSerde(f, giraffe.DatumCborSerde())
+}
+
+func FuzzDatumMsgpack(f *testing.F) {
+	fuzzSerde(f, giraffe.DatumMsgpackSerde())
+}
+
+func FuzzDatumBinary(f *testing.F) {
+	fuzzSerde(f, giraffe.DatumBinarySerde())
+}
+
+// fuzzSerde checks that whatever serde reads, it writes, and reads back the
+// same, starting off the encodings of a few documents.
+func fuzzSerde(
+	f *testing.F,
+	serde serdes.Serde[giraffe.Datum],
+) {
+	for _, doc := range []string{
+		`{"a": {"b": 1, "c": [true, "x", -7]}, "d": "text", "e": [], "f": {}, "g": 1.5, "h": -300000}`,
+		`{"a": null, "b": [null, 1]}`,
+		`[18446744073709551615, -18446744073709551616, 1e300, 0.1, ""]`,
+	} {
+		d, err := giraffe.DatumSerde().Read([]byte(doc))
+		require.NoError(f, err)
+
+		f.Add(M(serde.Write(d)))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		gtesting.Preamble(t)
+
+		read, err := serde.Read(b)
+		if err != nil {
+			return
+		}
+
+		written, err := serde.Write(read)
+		require.NoError(t, err)
+
+		again, err := serde.Read(written)
+		require.NoError(t, err)
+		assert.True(t, read.Eq(again), hex.EncodeToString(b))
+	})
+}
+
+func bigInt(
+	t *testing.T,
+	s string,
+) *big.Int {
+	t.Helper()
+
+	i, ok := new(big.Int).SetString(s, 10)
+	require.True(t, ok, s)
+
+	return i
+}
+
+func bigFlt(
+	t *testing.T,
+	s string,
+	prec uint,
+) *big.Float {
+	t.Helper()
+
+	f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	require.NoError(t, err, s)
+
+	return f
+}
+
+func BenchmarkSerdeJson(b *testing.B) {
+	benchSerde(b, giraffe.DatumSerde())
+}
+
+func BenchmarkSerdeBinary(b *testing.B) {
+	benchSerde(b, giraffe.DatumBinarySerde())
+}
+
+func benchSerde(
+	b *testing.B,
+	serde serdes.Serde[giraffe.Datum],
+) {
+	d := benchLarge()
+
+	for b.Loop() {
+		M(serde.Read(M(serde.Write(d))))
+	}
+}
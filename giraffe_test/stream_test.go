@@ -0,0 +1,129 @@
+package giraffe_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/dot"
+)
+
+func jsonOf(t *testing.T, d giraffe.Datum) string {
+	t.Helper()
+
+	b, err := d.MarshalJSON()
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+func TestStream(t *testing.T) {
+	doc := `{
+		"a": {"b": 1, "c": [true, null, "x"]},
+		"d.e": {},
+		"f": [],
+		"g": [{"h": 1}, {"h": 2}]
+	}`
+
+	t.Run("leaves", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		stream := giraffe.StreamOf(strings.NewReader(doc))
+
+		var leaves []string
+		for q, d := range stream.Leaves() {
+			leaves = append(leaves, q.String()+"="+jsonOf(t, d))
+		}
+		require.NoError(t, stream.Err())
+
+		assert.Equal(t, []string{
+			"a.b=1",
+			"a.c.0=true",
+			"a.c.1=null",
+			`a.c.2="x"`,
+			`d\.e={}`,
+			"f=[]",
+			"g.0.h=1",
+			"g.1.h=2",
+		}, leaves)
+	})
+
+	t.Run("leaves are gettable", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		whole := mkDatum(t, doc)
+
+		stream := giraffe.StreamOf(strings.NewReader(doc))
+		for tuple := range stream.Tuples() {
+			d, err := whole.Get(tuple.Query)
+			require.NoError(t, err)
+			assert.True(t, d.Eq(tuple.Dat), tuple.String())
+		}
+		require.NoError(t, stream.Err())
+	})
+
+	t.Run("projection", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		stream, err := giraffe.
+			StreamOf(strings.NewReader(doc)).
+			WithProjection(Q("a.c"), Q("g.1"), Q("missing"))
+		require.NoError(t, err)
+
+		found := map[string]string{}
+		for q, d := range stream.Leaves() {
+			found[q.String()] = jsonOf(t, d)
+		}
+		require.NoError(t, stream.Err())
+
+		assert.Equal(t, map[string]string{
+			"a.c": `[true,null,"x"]`,
+			"g.1": `{"h":2}`,
+		}, found)
+	})
+
+	t.Run("early stop", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		stream := giraffe.StreamOf(strings.NewReader(doc))
+
+		n := 0
+		for range stream.Leaves() {
+			n++
+			if n == 2 {
+				break
+			}
+		}
+
+		require.NoError(t, stream.Err())
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		stream := giraffe.StreamOf(strings.NewReader("{\"a\": 1}\n{\"a\": 2}\n\n3\n"))
+
+		var values []string
+		for d := range stream.Values() {
+			values = append(values, jsonOf(t, d))
+		}
+		require.NoError(t, stream.Err())
+
+		assert.Equal(t, []string{`{"a":1}`, `{"a":2}`, "3"}, values)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		stream := giraffe.StreamOf(strings.NewReader(`{"a": [1, }`))
+		for range stream.Leaves() {
+		}
+
+		require.Error(t, stream.Err())
+	})
+}
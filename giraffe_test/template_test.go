@@ -0,0 +1,157 @@
+package giraffe_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+)
+
+func TestTemplate(t *testing.T) {
+	doc := `{
+		"user": {"name": "Ann", "id": 7, "tags": ["a", "b"], "nick": null},
+		"ratio": 1.5
+	}`
+
+	t.Run("render", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		d := mkDatum(t, doc)
+
+		for spec, expecting := range map[string]string{
+			"plain":                                    "plain",
+			"":                                         "",
+			"Hello {{ .user.name }}!":                  "Hello Ann!",
+			"{{user.name}}{{ .user.id }}":              "Ann7",
+			"{{ .user.name | upper }}":                 "ANN",
+			"{{ .user.name | lower }}":                 "ann",
+			"{{ .user.tags }}":                         `["a","b"]`,
+			"{{ .user.name | json }}":                  `"Ann"`,
+			"{{ .user.nick }}":                         "null",
+			"{{ .user.nick | default \"x\" }}":         "x",
+			"{{ .user.mail | default \"a|b\" }}":       "a|b",
+			"{{ .inbox | default 0 }} messages":        "0 messages",
+			"{{ .user.mail | upper | default \"-\" }}": "-",
+			"{{ .ratio }}":                             "1.5",
+		} {
+			tpl, err := giraffe.ParseTemplate(spec)
+			require.NoError(t, err, spec)
+
+			rendered, err := tpl.Render(d)
+			require.NoError(t, err, spec)
+			assert.Equal(t, expecting, rendered, spec)
+		}
+	})
+
+	t.Run("self", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		tpl, err := giraffe.ParseTemplate("v={{ . }}")
+		require.NoError(t, err)
+		assert.Empty(t, tpl.Queries())
+
+		rendered, err := tpl.Render(giraffe.Of("x"))
+		require.NoError(t, err)
+		assert.Equal(t, "v=x", rendered)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		tpl, err := giraffe.ParseTemplate("Hello {{ .user.mail | upper }}")
+		require.NoError(t, err)
+
+		_, err = tpl.Render(mkDatum(t, doc))
+		require.ErrorContains(t, err, "cannot render: {{ .user.mail | upper }}")
+
+		tpl, err = giraffe.ParseTemplate("{{ .user.id | upper }}")
+		require.NoError(t, err)
+
+		_, err = tpl.Render(mkDatum(t, doc))
+		require.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, spec := range []string{
+			"{{ .a",
+			"{{ .a | shout }}",
+			"{{ .a | upper 1 }}",
+			"{{ .a | default }}",
+			"{{ .a | default nope }}",
+			"{{ .a.+ }}",
+			"{{ !a }}",
+		} {
+			_, err := giraffe.ParseTemplate(spec)
+			require.Error(t, err, spec)
+		}
+	})
+
+	t.Run("queries", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		tpl, err := giraffe.ParseTemplate("{{ .a.b }}-{{ c | upper }}")
+		require.NoError(t, err)
+		assert.Equal(t, []giraffe.Query{"a.b", "c"}, tpl.Queries())
+		assert.Equal(t, "{{ .a.b }}-{{ c | upper }}", tpl.String())
+		assert.True(t, giraffe.IsTemplate(tpl.String()))
+		assert.False(t, giraffe.IsTemplate("a.b"))
+	})
+}
+
+func TestDocTemplate(t *testing.T) {
+	t.Run("render", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		tpl, err := giraffe.ParseDocTemplate(mkDatum(t, `{
+			"id": {"$q": ".user.id"},
+			"tags": {"$q": ".user.tags", "$default": []},
+			"mail": {"$q": ".user.mail | default \"none\""},
+			"greeting": "Hello {{ .user.name }}",
+			"static": {"a": [1, {"b": true}]},
+			"list": [{"$q": "."}, "{{ .user.name | upper }}", 3]
+		}`))
+		require.NoError(t, err)
+
+		rendered, err := tpl.Render(mkDatum(t, `{"user": {"name": "ann", "id": 7}}`))
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `{
+			"id": 7,
+			"tags": [],
+			"mail": "none",
+			"greeting": "Hello ann",
+			"static": {"a": [1, {"b": true}]},
+			"list": [{"user": {"name": "ann", "id": 7}}, "ANN", 3]
+		}`, jsonOf(t, rendered))
+
+		assert.Equal(t, []giraffe.Query{"user.name", "user.id", "user.name", "user.mail", "user.tags"}, tpl.Queries())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		tpl, err := giraffe.ParseDocTemplate(mkDatum(t, `{"a": [{"$q": ".b"}]}`))
+		require.NoError(t, err)
+
+		_, err = tpl.Render(mkDatum(t, `{}`))
+		require.ErrorContains(t, err, "cannot render: {{ .b }}")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, doc := range []string{
+			`{"$q": 1}`,
+			`{"$q": ".a", "b": 1}`,
+			`{"x": ["{{ .a | shout }}"]}`,
+		} {
+			_, err := giraffe.ParseDocTemplate(mkDatum(t, doc))
+			require.Error(t, err, doc)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package hippo
+
+import (
+	"os"
+
+	"github.com/hkoosha/giraffe"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+)
+
+// Checkpoint is the progress of a run of a pipeline: the data and the steps
+// history as they are once the first Done steps of the plan are done.
+type Checkpoint struct {
+	Dat   giraffe.Datum
+	Steps giraffe.Datum
+	RunID string
+	Done  int
+}
+
+// CheckpointOf reads a checkpoint off its Datum, see Checkpoint.Datum.
+func CheckpointOf(
+	dat giraffe.Datum,
+) (*Checkpoint, error) {
+	runID, err := dat.QStr(qRun)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := dat.QISz(qDone)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := dat.Get(qState)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := dat.Get(qSteps)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkpoint{
+		Dat:   state,
+		Steps: steps,
+		RunID: runID,
+		Done:  done,
+	}, nil
+}
+
+// Datum is the checkpoint as a Datum, for stores to keep, for instance in
+// the encoding of giraffe.DatumBinarySerde, which keeps the types of the data
+// exactly.
+func (c *Checkpoint) Datum() (giraffe.Datum, error) {
+	return giraffe.OfN(
+		giraffe.TupleOf(qRun, c.RunID),
+		giraffe.TupleOf(qDone, c.Done),
+		giraffe.TupleOf(qState, c.Dat),
+		giraffe.TupleOf(qSteps, c.Steps),
+	)
+}
+
+// CheckpointStore keeps the latest checkpoint of each run. Implementations
+// must be safe to use concurrently.
+type CheckpointStore interface {
+	Save(ctx gtx.Context, c *Checkpoint) error
+
+	// Load returns nil, and no error, if there is no checkpoint of the run.
+	Load(ctx gtx.Context, runID string) (*Checkpoint, error)
+
+	Delete(ctx gtx.Context, runID string) error
+}
+
+// MemCheckpoints keeps the checkpoints in memory, for tests and for runs
+// resumed by the same process.
+func MemCheckpoints() CheckpointStore {
+	return &memCheckpoints{
+		runs: make(map[string]Checkpoint),
+	}
+}
+
+// FsCheckpoints keeps the checkpoints in dir, which is created if missing,
+// each in a file of its own named after the run, written atomically.
+func FsCheckpoints(
+	dir string,
+) (CheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, E(err)
+	}
+
+	return &fsCheckpoints{
+		dir: dir,
+	}, nil
+}
+
+// ValidateRunID fails unless runID is a non-empty run of letters, digits,
+// dots, dashes and underscores, not starting with a dot, so that stores may
+// use it as is for file names and keys.
+func ValidateRunID(
+	runID string,
+) error {
+	if !runIDRe.MatchString(runID) {
+		return EF("invalid run id: %s", runID)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package hippo
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/hkoosha/giraffe"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+)
+
+const checkpointExt = ".checkpoint"
+
+var (
+	qRun  = giraffe.Q("run")
+	qDone = giraffe.Q("done")
+
+	runIDRe = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+)
+
+type memCheckpoints struct {
+	runs map[string]Checkpoint
+	mu   sync.Mutex
+}
+
+func (m *memCheckpoints) Save(
+	_ gtx.Context,
+	c *Checkpoint,
+) error {
+	if err := ValidateRunID(c.RunID); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.runs[c.RunID] = *c
+
+	return nil
+}
+
+func (m *memCheckpoints) Load(
+	_ gtx.Context,
+	runID string,
+) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.runs[runID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &c, nil
+}
+
+func (m *memCheckpoints) Delete(
+	_ gtx.Context,
+	runID string,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.runs, runID)
+
+	return nil
+}
+
+// =====================================
+
+type fsCheckpoints struct {
+	dir string
+}
+
+func (f *fsCheckpoints) path(
+	runID string,
+) (string, error) {
+	if err := ValidateRunID(runID); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(f.dir, runID+checkpointExt), nil
+}
+
+func (f *fsCheckpoints) Save(
+	_ gtx.Context,
+	c *Checkpoint,
+) error {
+	path, err := f.path(c.RunID)
+	if err != nil {
+		return err
+	}
+
+	dat, err := c.Datum()
+	if err != nil {
+		return err
+	}
+
+	b, err := giraffe.DatumBinarySerde().Write(dat)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(f.dir, "."+c.RunID+"-*")
+	if err != nil {
+		return E(err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, wErr := tmp.Write(b)
+	sErr := tmp.Sync()
+	cErr := tmp.Close()
+	if err = errors.Join(wErr, sErr, cErr); err != nil {
+		return E(err)
+	}
+
+	return E(os.Rename(tmp.Name(), path))
+}
+
+func (f *fsCheckpoints) Load(
+	_ gtx.Context,
+	runID string,
+) (*Checkpoint, error) {
+	path, err := f.path(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+
+	case err != nil:
+		return nil, E(err)
+	}
+
+	dat, err := giraffe.DatumBinarySerde().Read(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return CheckpointOf(dat)
+}
+
+func (f *fsCheckpoints) Delete(
+	_ gtx.Context,
+	runID string,
+) error {
+	path, err := f.path(runID)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return E(err)
+	}
+
+	return nil
+}
+
+// =====================================
+
+// checkResumable fails unless c is of a run of the plan of the pipeline, the
+// steps done being the first steps of the plan.
+func (n *PipelineFn) checkResumable(
+	c *Checkpoint,
+) error {
+	if c.Done < 0 || c.Done > len(n.plan.steps) {
+		return EF("checkpoint of run %s is past the plan: %d", c.RunID, c.Done)
+	}
+
+	steps, err := c.Steps.Iter()
+	if err != nil {
+		return E(err, EF("invalid steps history of run: %s", c.RunID))
+	}
+
+	names := make([]string, 0, c.Done)
+	for step := range steps {
+		name, qErr := step.QStr(qName)
+		if qErr != nil {
+			return E(qErr, EF("invalid steps history of run: %s", c.RunID))
+		}
+
+		names = append(names, name)
+	}
+
+	want := append([]string{stepInit}, n.plan.Names()[:c.Done]...)
+	if !slices.Equal(names, want) {
+		return EF("checkpoint of run %s is not of the plan: %v", c.RunID, names)
+	}
+
+	return nil
+}
+
+func (n *PipelineFn) checkpoint(
+	ctx gtx.Context,
+	runID string,
+	done int,
+	dat giraffe.Datum,
+	hist giraffe.Datum,
+) error {
+	if runID == "" {
+		return nil
+	}
+
+	err := n.store.Save(ctx, &Checkpoint{
+		Dat:   dat,
+		Steps: hist,
+		RunID: runID,
+		Done:  done,
+	})
+	if err != nil {
+		return E(err, EF("cannot save checkpoint of run: %s", runID))
+	}
+
+	return nil
+}
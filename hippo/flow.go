@@ -0,0 +1,243 @@
+package hippo
+
+import (
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+)
+
+// Control flow steps run plans of their own, each to the end, against the data
+// of the step. Their result is the state the plan finishes with, merged into
+// the data with the right side winning, so that the plans may change values
+// already present. The steps of the inner plans are not recorded in the steps
+// history.
+//
+// A cond is a predicate on the data, with the syntax of the predicates of
+// queries: `status == "active"`, `retries < 3`, or a bare query, as in
+// `user.email`, which holds if the value exists.
+
+// If runs then if cond holds, or otherwise if it does not. A nil otherwise
+// does nothing.
+func If(
+	cond string,
+	then *Plan,
+	otherwise *Plan,
+) (*Fn, error) {
+	c, err := mkCond(cond)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkFlowPlan(then, false); err != nil {
+		return nil, err
+	}
+
+	if err = checkFlowPlan(otherwise, true); err != nil {
+		return nil, err
+	}
+
+	return flowFn(func(
+		ctx gtx.Context,
+		c0 Call,
+	) (giraffe.Datum, error) {
+		holds, hErr := c.holds(c0.Data())
+		if hErr != nil {
+			return dErr, hErr
+		}
+
+		if holds {
+			return runFlowPlan(ctx, then, c0.Data())
+		}
+
+		return runFlowPlan(ctx, otherwise, c0.Data())
+	}), nil
+}
+
+// Switch runs the plan of the case the value at on is, as a string, see
+// giraffe.Datum.SimpleString, null being "null", or otherwise if no case
+// matches or the value is missing. A nil otherwise does nothing.
+func Switch(
+	on giraffe.Query,
+	cases map[string]*Plan,
+	otherwise *Plan,
+) (*Fn, error) {
+	if _, err := giraffe.GQParse(on.String()); err != nil {
+		return nil, err
+	}
+
+	for _, plan := range cases {
+		if err := checkFlowPlan(plan, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkFlowPlan(otherwise, true); err != nil {
+		return nil, err
+	}
+
+	return flowFn(func(
+		ctx gtx.Context,
+		c Call,
+	) (giraffe.Datum, error) {
+		plan, err := switchCase(c.Data(), on, cases)
+		if err != nil {
+			return dErr, err
+		}
+
+		if plan == nil {
+			plan = otherwise
+		}
+
+		return runFlowPlan(ctx, plan, c.Data())
+	}), nil
+}
+
+// ForEach runs each for every element of the array at over, one after
+// another, with the element set at as. The value at as each run finishes with
+// is collected, in order, into an array at into.
+func ForEach(
+	over giraffe.Query,
+	as giraffe.Query,
+	into giraffe.Query,
+	each *Plan,
+) (*Fn, error) {
+	for _, q := range []giraffe.Query{over, as, into} {
+		if _, err := giraffe.GQParse(q.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkFlowPlan(each, false); err != nil {
+		return nil, err
+	}
+
+	return flowFn(func(
+		ctx gtx.Context,
+		c Call,
+	) (giraffe.Datum, error) {
+		return forEach(ctx, c.Data(), over, as.WithMake().WithOverwrite(), into, each)
+	}), nil
+}
+
+// While runs body again and again, for as long as cond holds, each run given
+// the state the previous one finished with. It fails if cond still holds
+// after most runs.
+func While(
+	cond string,
+	most int,
+	body *Plan,
+) (*Fn, error) {
+	c, err := mkCond(cond)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkFlowLoop(most, body); err != nil {
+		return nil, err
+	}
+
+	return flowFn(func(
+		ctx gtx.Context,
+		c0 Call,
+	) (giraffe.Datum, error) {
+		return while(ctx, c0.Data(), c, most, body)
+	}), nil
+}
+
+// Until runs body until cond holds on the state it finishes with, at most
+// most times, retrying failed runs as well. Each run is given the state the
+// last successful one finished with. It fails with the last failure, if any,
+// once out of runs.
+func Until(
+	cond string,
+	most int,
+	body *Plan,
+) (*Fn, error) {
+	c, err := mkCond(cond)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkFlowLoop(most, body); err != nil {
+		return nil, err
+	}
+
+	return flowFn(func(
+		ctx gtx.Context,
+		c0 Call,
+	) (giraffe.Datum, error) {
+		return until(ctx, c0.Data(), c, most, body)
+	}), nil
+}
+
+// =====================================
+
+func (p *Plan) WithNextIf(
+	name string,
+	cond string,
+	then *Plan,
+	otherwise *Plan,
+) (*Plan, error) {
+	fn, err := If(cond, then, otherwise)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.WithNext(name, fn)
+}
+
+func (p *Plan) WithNextSwitch(
+	name string,
+	on giraffe.Query,
+	cases map[string]*Plan,
+	otherwise *Plan,
+) (*Plan, error) {
+	fn, err := Switch(on, cases, otherwise)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.WithNext(name, fn)
+}
+
+func (p *Plan) WithNextForEach(
+	name string,
+	over giraffe.Query,
+	as giraffe.Query,
+	into giraffe.Query,
+	each *Plan,
+) (*Plan, error) {
+	fn, err := ForEach(over, as, into, each)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.WithNext(name, fn)
+}
+
+func (p *Plan) WithNextWhile(
+	name string,
+	cond string,
+	most int,
+	body *Plan,
+) (*Plan, error) {
+	fn, err := While(cond, most, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.WithNext(name, fn)
+}
+
+func (p *Plan) WithNextUntil(
+	name string,
+	cond string,
+	most int,
+	body *Plan,
+) (*Plan, error) {
+	fn, err := Until(cond, most, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.WithNext(name, fn)
+}
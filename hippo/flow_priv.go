@@ -0,0 +1,404 @@
+package hippo
+
+import (
+	"github.com/hkoosha/giraffe"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+)
+
+// cond is a predicate on the data, tested as the predicate of a query on an
+// array holding the data alone.
+type cond struct {
+	spec  string
+	query giraffe.Query
+}
+
+func mkCond(
+	spec string,
+) (cond, error) {
+	q, err := giraffe.GQParse("[?" + spec + "]")
+	if err != nil {
+		return cond{}, E(err, EF("invalid cond: %s", spec))
+	}
+
+	return cond{
+		spec:  spec,
+		query: q,
+	}, nil
+}
+
+func (c cond) holds(
+	dat giraffe.Datum,
+) (bool, error) {
+	matches, err := giraffe.Of([]giraffe.Datum{dat}).GetAll(c.query)
+	if err != nil {
+		return false, E(err, EF("cannot test cond: %s", c.spec))
+	}
+
+	for range matches {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// =====================================
+
+func flowFn(
+	exe Exe,
+) *Fn {
+	return FnOf(exe).WithMerging(
+		giraffe.MergingOf().WithPolicy(giraffe.MergePolicyRightWins),
+	)
+}
+
+func checkFlowPlan(
+	plan *Plan,
+	optional bool,
+) error {
+	switch {
+	case plan == nil && optional:
+		return nil
+
+	case plan == nil:
+		return EF("nil plan")
+
+	case len(plan.steps) == 0:
+		return EF("empty plan")
+	}
+
+	return nil
+}
+
+func checkFlowLoop(
+	most int,
+	body *Plan,
+) error {
+	if most < 1 {
+		return EF("loop must run at least once, most=%d", most)
+	}
+
+	return checkFlowPlan(body, false)
+}
+
+// runFlowPlan runs plan against dat, returning the state it finishes with, or
+// nothing if plan is nil.
+func runFlowPlan(
+	ctx gtx.Context,
+	plan *Plan,
+	dat giraffe.Datum,
+) (giraffe.Datum, error) {
+	if plan == nil {
+		return giraffe.OfEmpty(), nil
+	}
+
+	pipeline, err := MkPipeline(plan)
+	if err != nil {
+		return dErr, err
+	}
+
+	state, err := pipeline.Ekran(ctx, dat)
+	if err != nil {
+		return dErr, err
+	}
+
+	return state.Get(qFin)
+}
+
+func switchCase(
+	dat giraffe.Datum,
+	on giraffe.Query,
+	cases map[string]*Plan,
+) (*Plan, error) {
+	if ok, err := dat.Has(on); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+
+	v, err := dat.Get(on)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type().IsNil() {
+		return cases["null"], nil
+	}
+
+	key, err := v.SimpleString()
+	if err != nil {
+		return nil, E(err, EF("cannot switch on: %s", on.String()))
+	}
+
+	return cases[key], nil
+}
+
+func forEach(
+	ctx gtx.Context,
+	dat giraffe.Datum,
+	over giraffe.Query,
+	as giraffe.Query,
+	into giraffe.Query,
+	each *Plan,
+) (giraffe.Datum, error) {
+	arr, err := dat.Get(over)
+	if err != nil {
+		return dErr, err
+	}
+
+	elements, err := arr.Iter()
+	if err != nil {
+		return dErr, E(err, EF("cannot iterate over: %s", over.String()))
+	}
+
+	results := make([]giraffe.Datum, 0)
+	for element := range elements {
+		in, sErr := dat.Set(as, element)
+		if sErr != nil {
+			return dErr, sErr
+		}
+
+		fin, rErr := runFlowPlan(ctx, each, in)
+		if rErr != nil {
+			return dErr, E(rErr, EF("iteration failed: %d", len(results)))
+		}
+
+		result, gErr := fin.Get(as)
+		if gErr != nil {
+			return dErr, E(gErr, EF("iteration failed: %d", len(results)))
+		}
+
+		results = append(results, result)
+	}
+
+	return giraffe.Of1(into, results), nil
+}
+
+func while(
+	ctx gtx.Context,
+	dat giraffe.Datum,
+	c cond,
+	most int,
+	body *Plan,
+) (giraffe.Datum, error) {
+	for range most {
+		holds, err := c.holds(dat)
+		if err != nil {
+			return dErr, err
+		} else if !holds {
+			return dat, nil
+		}
+
+		if dat, err = runFlowPlan(ctx, body, dat); err != nil {
+			return dErr, err
+		}
+	}
+
+	if holds, err := c.holds(dat); err != nil {
+		return dErr, err
+	} else if holds {
+		return dErr, EF("loop did not end in %d runs, while: %s", most, c.spec)
+	}
+
+	return dat, nil
+}
+
+func until(
+	ctx gtx.Context,
+	dat giraffe.Datum,
+	c cond,
+	most int,
+	body *Plan,
+) (giraffe.Datum, error) {
+	var last error
+
+	for range most {
+		next, err := runFlowPlan(ctx, body, dat)
+		if err != nil {
+			last = err
+			continue
+		}
+
+		dat, last = next, nil
+
+		holds, err := c.holds(dat)
+		if err != nil {
+			return dErr, err
+		} else if holds {
+			return dat, nil
+		}
+	}
+
+	err := EF("loop did not end in %d runs, until: %s", most, c.spec)
+	if last != nil {
+		err = E(last, err)
+	}
+
+	return dErr, err
+}
+
+// =====================================
+
+func (f *FnConfig) flows() int {
+	n := 0
+	for _, set := range []bool{
+		f.If != nil,
+		f.Switch != nil,
+		f.ForEach != nil,
+		f.While != nil,
+		f.Until != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+
+	return n
+}
+
+// checkShape fails unless the step is either an fn or a single control flow.
+func (f *FnConfig) checkShape() error {
+	switch n := f.flows(); {
+	case n > 1, n == 1 && f.Fn != "":
+		return EF("step must be either an fn or a single control flow")
+
+	case n == 0 && f.Fn == "":
+		return EF("step has neither an fn nor a control flow")
+	}
+
+	return nil
+}
+
+func (f *FnConfig) validateFlow() []error {
+	var errs []error
+
+	if err := f.checkShape(); err != nil {
+		return append(errs, err)
+	}
+
+	validateSteps := func(steps []FnConfig) {
+		for _, s := range steps {
+			errs = append(errs, s.Validate()...)
+		}
+	}
+
+	validateCond := func(spec string) {
+		if _, err := mkCond(spec); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	validateQuery := func(q giraffe.Query) {
+		if _, err := giraffe.GQParse(q.String()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	switch {
+	case f.If != nil:
+		validateCond(f.If.Cond)
+		validateSteps(f.If.Then)
+		validateSteps(f.If.Else)
+
+	case f.Switch != nil:
+		validateQuery(f.Switch.On)
+		for _, steps := range f.Switch.Cases {
+			validateSteps(steps)
+		}
+		validateSteps(f.Switch.Default)
+
+	case f.ForEach != nil:
+		validateQuery(f.ForEach.Over)
+		validateQuery(f.ForEach.As)
+		validateQuery(f.ForEach.Into)
+		validateSteps(f.ForEach.Do)
+
+	case f.While != nil:
+		validateCond(f.While.Cond)
+		validateSteps(f.While.Do)
+
+	case f.Until != nil:
+		validateCond(f.Until.Cond)
+		validateSteps(f.Until.Do)
+	}
+
+	return errs
+}
+
+// flow makes the control flow of the step, if any, its plans made off reg.
+func (f *FnConfig) flow(
+	reg *FnRegistry,
+) (*Fn, error) {
+	if err := f.checkShape(); err != nil {
+		return nil, err
+	}
+
+	plan := func(steps []FnConfig) (*Plan, error) {
+		if len(steps) == 0 {
+			return nil, nil
+		}
+
+		p := MkPlan()
+		p.registry = reg
+
+		return p.WithSteps(steps...)
+	}
+
+	switch {
+	case f.If != nil:
+		then, err := plan(f.If.Then)
+		if err != nil {
+			return nil, err
+		}
+
+		otherwise, err := plan(f.If.Else)
+		if err != nil {
+			return nil, err
+		}
+
+		return If(f.If.Cond, then, otherwise)
+
+	case f.Switch != nil:
+		cases := make(map[string]*Plan, len(f.Switch.Cases))
+		for k, steps := range f.Switch.Cases {
+			var err error
+			if cases[k], err = plan(steps); err != nil {
+				return nil, err
+			}
+		}
+
+		otherwise, err := plan(f.Switch.Default)
+		if err != nil {
+			return nil, err
+		}
+
+		return Switch(f.Switch.On, cases, otherwise)
+
+	case f.ForEach != nil:
+		each, err := plan(f.ForEach.Do)
+		if err != nil {
+			return nil, err
+		}
+
+		return ForEach(f.ForEach.Over, f.ForEach.As, f.ForEach.Into, each)
+
+	case f.While != nil:
+		body, err := plan(f.While.Do)
+		if err != nil {
+			return nil, err
+		}
+
+		return While(f.While.Cond, f.While.Most, body)
+
+	case f.Until != nil:
+		body, err := plan(f.Until.Do)
+		if err != nil {
+			return nil, err
+		}
+
+		return Until(f.Until.Cond, f.Until.Most, body)
+
+	default:
+		return nil, nil
+	}
+}
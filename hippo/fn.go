@@ -36,6 +36,10 @@ func FnOf(
 		skipOnExists: false,
 		skipped:      false,
 		skipWith:     nil,
+		merging:      giraffe.MergingOf(),
+		inSchema:     nil,
+		outSchema:    nil,
+		undo:         nil,
 		typ:          t,
 		name:         "#" + t.String(),
 		// args:      nil,
@@ -81,6 +85,10 @@ type Fn struct {
 	skipOnExists bool
 	skipped      bool
 	skipWith     *giraffe.Datum
+	merging      giraffe.Merging
+	inSchema     *giraffe.Schema
+	outSchema    *giraffe.Schema
+	undo         *Fn
 
 	// swapped      map[giraffe.Query]giraffe.Query
 	// args         []giraffe.Query
@@ -376,6 +384,108 @@ func (f *Fn) SetSkipped(v bool) *Fn {
 	return cp
 }
 
+// Merging is used to merge the output of this fn into the pipeline state,
+// and to merge the gathered inputs of WithCombine.
+func (f *Fn) Merging() giraffe.Merging {
+	return f.merging
+}
+
+func (f *Fn) WithMerging(
+	merging giraffe.Merging,
+) *Fn {
+	f.ensure()
+
+	clone := f.clone()
+	clone.merging = merging
+	return clone
+}
+
+func (f *Fn) WithoutMerging() *Fn {
+	return f.WithMerging(giraffe.MergingOf())
+}
+
+// InputSchema is validated against the data the fn is called with, after the
+// inputs are checked for presence.
+func (f *Fn) InputSchema() (giraffe.Schema, bool) {
+	if f.inSchema == nil {
+		return giraffe.SchemaOf(nil), false
+	}
+
+	return *f.inSchema, true
+}
+
+func (f *Fn) WithInputSchema(
+	schema giraffe.Schema,
+) *Fn {
+	f.ensure()
+
+	clone := f.clone()
+	clone.inSchema = &schema
+	return clone
+}
+
+func (f *Fn) WithoutInputSchema() *Fn {
+	f.ensure()
+
+	clone := f.clone()
+	clone.inSchema = nil
+	return clone
+}
+
+// OutputSchema is validated against the output of the fn, after the outputs
+// are checked for presence, and before select and scope are applied.
+func (f *Fn) OutputSchema() (giraffe.Schema, bool) {
+	if f.outSchema == nil {
+		return giraffe.SchemaOf(nil), false
+	}
+
+	return *f.outSchema, true
+}
+
+func (f *Fn) WithOutputSchema(
+	schema giraffe.Schema,
+) *Fn {
+	f.ensure()
+
+	clone := f.clone()
+	clone.outSchema = &schema
+	return clone
+}
+
+func (f *Fn) WithoutOutputSchema() *Fn {
+	f.ensure()
+
+	clone := f.clone()
+	clone.outSchema = nil
+	return clone
+}
+
+func (f *Fn) Undo() (*Fn, bool) {
+	return f.undo, f.undo != nil
+}
+
+// WithUndo sets the fn undoing what the fn did, run if the pipeline fails
+// later on, see Compensator. It is given the data of the step merged with its
+// result, and the args of the step; its own result is discarded.
+func (f *Fn) WithUndo(
+	undo *Fn,
+) *Fn {
+	f.ensure()
+	undo.ensure()
+
+	clone := f.clone()
+	clone.undo = undo
+	return clone
+}
+
+func (f *Fn) WithoutUndo() *Fn {
+	f.ensure()
+
+	clone := f.clone()
+	clone.undo = nil
+	return clone
+}
+
 func (f *Fn) Named(
 	name string,
 ) *Fn {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/hkoosha/giraffe"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
 )
 
 // FnConfig
@@ -23,12 +24,53 @@ type FnConfig struct {
 	Skipped      *bool                              `json:"skipped,omitempty"        yaml:"skipped,omitempty"`
 	SkippedWith  *giraffe.Datum                     `json:"skipped_with,omitempty" yaml:"skipped_with,omitempty"`
 	NoSkipWith   *giraffe.Datum                     `json:"no_skip_with,omitempty"   yaml:"no_skip_with,omitempty"`
+	MergePolicy  *giraffe.MergePolicy               `json:"merge_policy,omitempty"   yaml:"merge_policy,omitempty"`
+	MergeArr     *giraffe.ArrMerge                  `json:"merge_arr,omitempty"      yaml:"merge_arr,omitempty"`
+	MergeKey     *giraffe.Query                     `json:"merge_key,omitempty"      yaml:"merge_key,omitempty"`
+	InputSchema  *giraffe.Schema                    `json:"input_schema,omitempty"   yaml:"input_schema,omitempty"`
+	OutputSchema *giraffe.Schema                    `json:"output_schema,omitempty"  yaml:"output_schema,omitempty"`
 
-	Fn string `json:"fn"                       yaml:"fn"`
+	If      *IfConfig      `json:"if,omitempty"             yaml:"if,omitempty"`
+	Switch  *SwitchConfig  `json:"switch,omitempty"         yaml:"switch,omitempty"`
+	ForEach *ForEachConfig `json:"for_each,omitempty"       yaml:"for_each,omitempty"`
+	While   *LoopConfig    `json:"while,omitempty"          yaml:"while,omitempty"`
+	Until   *LoopConfig    `json:"until,omitempty"          yaml:"until,omitempty"`
+
+	Fn   string    `json:"fn,omitempty"             yaml:"fn,omitempty"`
+	Undo *FnConfig `json:"undo,omitempty"           yaml:"undo,omitempty"`
 
 	// Swap         *map[giraffe.Query]giraffe.Query `json:"swap,omitempty"           yaml:"swap,omitempty"`
 }
 
+// IfConfig configures If, the plans being the steps given.
+type IfConfig struct {
+	Cond string     `json:"cond"           yaml:"cond"`
+	Then []FnConfig `json:"then"           yaml:"then"`
+	Else []FnConfig `json:"else,omitempty" yaml:"else,omitempty"`
+}
+
+// SwitchConfig configures Switch, the plans being the steps given.
+type SwitchConfig struct {
+	On      giraffe.Query         `json:"on"                yaml:"on"`
+	Cases   map[string][]FnConfig `json:"cases"             yaml:"cases"`
+	Default []FnConfig            `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// ForEachConfig configures ForEach, the plan being the steps given.
+type ForEachConfig struct {
+	Over giraffe.Query `json:"over" yaml:"over"`
+	As   giraffe.Query `json:"as"   yaml:"as"`
+	Into giraffe.Query `json:"into" yaml:"into"`
+	Do   []FnConfig    `json:"do"   yaml:"do"`
+}
+
+// LoopConfig configures While and Until, the plan being the steps given.
+type LoopConfig struct {
+	Cond string     `json:"cond" yaml:"cond"`
+	Most int        `json:"most" yaml:"most"`
+	Do   []FnConfig `json:"do"   yaml:"do"`
+}
+
 func (f *FnConfig) Validate() []error {
 	var errs []error
 
@@ -89,6 +131,35 @@ func (f *FnConfig) Validate() []error {
 		}
 	}
 
+	if f.MergeKey != nil {
+		if _, err := giraffe.GQParse(f.MergeKey.String()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	isKeyed := f.MergeArr != nil && *f.MergeArr == giraffe.ArrMergeKeyed
+	switch {
+	case isKeyed && f.MergeKey == nil:
+		errs = append(errs, EF("keyed array merge without merge key"))
+
+	case !isKeyed && f.MergeKey != nil:
+		errs = append(errs, EF("merge key given without keyed array merge"))
+	}
+
+	if f.MergePolicy != nil && *f.MergePolicy == giraffe.MergePolicyResolve {
+		errs = append(errs, EF("resolve merge policy is not configurable"))
+	}
+
+	errs = append(errs, f.validateFlow()...)
+
+	if f.Undo != nil {
+		if f.Undo.Undo != nil {
+			errs = append(errs, EF("undo must not have an undo of its own"))
+		}
+
+		errs = append(errs, f.Undo.Validate()...)
+	}
+
 	if f.Args != nil {
 		b, err := f.Args.MarshalJSON()
 		if err != nil {
@@ -102,7 +173,59 @@ func (f *FnConfig) Validate() []error {
 		}
 	}
 
-	return nil
+	return errs
+}
+
+// Name is the name of the fn of the step, or of its control flow, as in
+// "for_each".
+func (f *FnConfig) Name() string {
+	switch {
+	case f.If != nil:
+		return "if"
+
+	case f.Switch != nil:
+		return "switch"
+
+	case f.ForEach != nil:
+		return "for_each"
+
+	case f.While != nil:
+		return "while"
+
+	case f.Until != nil:
+		return "until"
+
+	default:
+		return f.Fn
+	}
+}
+
+// Make makes the fn of the step, named in reg or a control flow whose plans
+// are made off reg as well, and configures it, along with its undo, if any.
+func (f *FnConfig) Make(
+	reg *FnRegistry,
+) (*Fn, error) {
+	fn, err := f.flow(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	if fn == nil {
+		if fn, err = reg.Named(f.Fn); err != nil {
+			return nil, err
+		}
+	}
+
+	if fn, err = f.Configure(fn); err != nil || f.Undo == nil {
+		return fn, err
+	}
+
+	undo, err := f.Undo.Make(reg)
+	if err != nil {
+		return nil, E(err, EF("invalid undo of: %s", f.Name()))
+	}
+
+	return fn.WithUndo(undo), nil
 }
 
 func (f *FnConfig) Configure(
@@ -154,5 +277,53 @@ func (f *FnConfig) Configure(
 		fn = fn.WithSkippedWith(*f.SkippedWith)
 	}
 
+	if f.InputSchema != nil {
+		fn = fn.WithInputSchema(*f.InputSchema)
+	}
+
+	if f.OutputSchema != nil {
+		fn = fn.WithOutputSchema(*f.OutputSchema)
+	}
+
+	if f.MergePolicy != nil || f.MergeArr != nil {
+		m, err := f.merging(fn.Merging())
+		if err != nil {
+			return nil, err
+		}
+
+		fn = fn.WithMerging(m)
+	}
+
 	return fn, nil
 }
+
+func (f *FnConfig) merging(
+	m giraffe.Merging,
+) (giraffe.Merging, error) {
+	if f.MergePolicy != nil {
+		if *f.MergePolicy == giraffe.MergePolicyResolve {
+			return m, EF("resolve merge policy is not configurable")
+		}
+
+		m = m.WithPolicy(*f.MergePolicy)
+	}
+
+	if f.MergeArr != nil {
+		switch *f.MergeArr {
+		case giraffe.ArrMergeIndex:
+			m = m.WithIndex()
+		case giraffe.ArrMergeConcat:
+			m = m.WithConcat()
+		case giraffe.ArrMergeUnion:
+			m = m.WithUnion()
+		case giraffe.ArrMergeKeyed:
+			if f.MergeKey == nil {
+				return m, EF("keyed array merge without merge key")
+			}
+
+			m = m.WithKeyed(*f.MergeKey)
+		}
+	}
+
+	return m, nil
+}
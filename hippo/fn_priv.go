@@ -153,6 +153,10 @@ func (f *Fn) clone() *Fn {
 		skipOnExists: f.skipOnExists,
 		skipped:      f.skipped,
 		skipWith:     f.skipWith,
+		merging:      f.merging,
+		inSchema:     f.inSchema,
+		outSchema:    f.outSchema,
+		undo:         f.undo,
 		typ:          f.typ.Clone(),
 		name:         f.name,
 
@@ -181,6 +185,12 @@ func (f *Fn) call(
 		return dErr, err
 	}
 
+	if f.inSchema != nil {
+		if err := f.inSchema.Validate(dat); err != nil {
+			return dErr, err
+		}
+	}
+
 	// if err := call.CheckPresent(call.Args(), f.args); err != nil {
 	// 	return dErr, err
 	// }
@@ -202,7 +212,7 @@ func (f *Fn) call(
 				case i == 0:
 					gathered, err = gathered.Set(into, d)
 				default:
-					gathered, err = gathered.Merge(d)
+					gathered, err = gathered.MergeWith(f.merging, d)
 				}
 
 				if err != nil {
@@ -211,7 +221,7 @@ func (f *Fn) call(
 			}
 
 			var err error
-			dat, err = dat.Merge(gathered)
+			dat, err = dat.MergeWith(f.merging, gathered)
 			if err != nil {
 				return dErr, err
 			}
@@ -232,6 +242,12 @@ func (f *Fn) call(
 		return dErr, cErr
 	}
 
+	if f.outSchema != nil {
+		if sErr := f.outSchema.Validate(ret1); sErr != nil {
+			return dErr, sErr
+		}
+	}
+
 	ret2, err := f.select_(ret1)
 	if err != nil {
 		return dErr, err
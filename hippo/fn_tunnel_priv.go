@@ -34,6 +34,23 @@ func simple(
 	return sss, nil
 }
 
+// render renders s against dat if it is a template, see giraffe.Template.
+func render(
+	dat giraffe.Datum,
+	s string,
+) (string, error) {
+	if !giraffe.IsTemplate(s) {
+		return s, nil
+	}
+
+	tpl, err := giraffe.ParseTemplate(s)
+	if err != nil {
+		return "", err
+	}
+
+	return tpl.Render(dat)
+}
+
 type datumTunnelPath struct {
 	fullPath         string
 	pathOnly         string
@@ -296,6 +313,12 @@ func (h *DatumTunnel) mkHeaders(
 		}
 	}
 
+	for k, v := range hh {
+		if hh[k], err = render(call.Data(), v); err != nil {
+			return nil, E(err, EF("cannot render header: %s", k))
+		}
+	}
+
 	return hh, nil
 }
 
@@ -307,6 +330,10 @@ func (h *DatumTunnel) mkPath(
 		return nil, err
 	}
 
+	if pathTpl, err = render(dat, pathTpl); err != nil {
+		return nil, err
+	}
+
 	tpl, err := h.template.withPath(pathTpl)
 	if err != nil {
 		return nil, err
@@ -37,12 +37,8 @@ func validateEndpoint(
 		return E(err)
 	}
 
-	ep := u.Scheme + "://" + u.Host
-	if p := u.Port(); p != "" {
-		ep += ":" + p
-	}
-
-	if ep != e {
+	// Host holds the port, if any.
+	if ep := u.Scheme + "://" + u.Host; ep != e {
 		return EF("endpoint must be only scheme, host and port: %s", e)
 	}
 
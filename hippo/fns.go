@@ -106,6 +106,24 @@ func StaticOf(
 	return Static(dat), nil
 }
 
+// StaticTemplate is a Static whose output is doc rendered against the data of
+// the call, see giraffe.DocTemplate.
+func StaticTemplate(
+	doc giraffe.Datum,
+) (*Fn, error) {
+	tpl, err := giraffe.ParseDocTemplate(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return FnOf(func(
+		_ gtx.Context,
+		c Call,
+	) (giraffe.Datum, error) {
+		return tpl.Render(c.Data())
+	}), nil
+}
+
 // =====================================
 
 func SelectRand() *Fn {
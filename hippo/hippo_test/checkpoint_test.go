@@ -0,0 +1,192 @@
+package hippo_test
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+// counted writes v at q, counting its calls in calls, failing the first fails
+// calls.
+func counted(
+	q string,
+	v any,
+	fails int,
+	calls *atomic.Int32,
+) *hippo.Fn {
+	return hippo.FnOf(func(
+		_ gtx.Context,
+		_ hippo.Call,
+	) (giraffe.Datum, error) {
+		if calls.Add(1) <= int32(fails) {
+			return giraffe.OfErr(), EF("failing on purpose")
+		}
+
+		return giraffe.Of1(Q(q), M(giraffe.From(v))), nil
+	}).WithOutput(Q(q))
+}
+
+func stepNames(
+	t *testing.T,
+	state giraffe.Datum,
+) []string {
+	t.Helper()
+
+	all, err := state.GetAll(Q("steps.*.name"))
+	require.NoError(t, err)
+
+	var names []string
+	for _, name := range all {
+		names = append(names, M(name.Str()))
+	}
+
+	return names
+}
+
+func TestPipeline_Checkpoints(t *testing.T) {
+	stores := map[string]func(t *testing.T) hippo.CheckpointStore{
+		"mem": func(*testing.T) hippo.CheckpointStore {
+			return hippo.MemCheckpoints()
+		},
+		"fs": func(t *testing.T) hippo.CheckpointStore {
+			return M(hippo.FsCheckpoints(t.TempDir()))
+		},
+	}
+
+	for kind, mkStore := range stores {
+		t.Run("resume "+kind, func(t *testing.T) {
+			gtesting.Preamble(t)
+
+			var aCalls, bCalls, cCalls atomic.Int32
+			plan := hippo.
+				MkPlan().
+				MustWithNext("a", counted("a", 1.5, 0, &aCalls)).
+				MustWithNext("b", counted("b", "x", 1, &bCalls)).
+				MustWithNext("c", counted("c", nil, 0, &cCalls))
+
+			store := mkStore(t)
+			pipeline := M(hippo.MkPipeline(plan)).WithCheckpoints(store)
+
+			_, err := pipeline.EkranRun(gtx.Of(t.Context()), "run-1", giraffe.Of1(Q("n"), 7))
+			require.ErrorContains(t, err, "failing on purpose")
+
+			c, err := store.Load(gtx.Of(t.Context()), "run-1")
+			require.NoError(t, err)
+			require.NotNil(t, c)
+			assert.Equal(t, 1, c.Done)
+			assert.JSONEq(t, `{"n": 7, "a": "1.5"}`, string(M(c.Dat.MarshalJSON())))
+
+			state, err := pipeline.Resume(gtx.Of(t.Context()), "run-1")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"init", "a", "b", "c"}, stepNames(t, state))
+			assert.Equal(t, int32(1), aCalls.Load())
+			assert.Equal(t, int32(2), bCalls.Load())
+			assert.Equal(t, int32(1), cCalls.Load())
+
+			fin := M(state.Get(Q("fin")))
+			assert.True(t, M(fin.Get(Q("a"))).Eq(M(giraffe.From(1.5))))
+			assert.True(t, M(fin.Get(Q("c"))).Type().IsNil())
+
+			// Done already.
+			again, err := pipeline.Resume(gtx.Of(t.Context()), "run-1")
+			require.NoError(t, err)
+			assert.True(t, state.Eq(again))
+			assert.Equal(t, int32(1), cCalls.Load())
+
+			require.NoError(t, store.Delete(gtx.Of(t.Context()), "run-1"))
+			_, err = pipeline.Resume(gtx.Of(t.Context()), "run-1")
+			require.ErrorContains(t, err, "no checkpoint of run: run-1")
+		})
+	}
+
+	t.Run("skip on exists", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var tokenCalls, flakyCalls, mkCalls atomic.Int32
+		plan := hippo.
+			MkPlan().
+			MustWithNext("token", counted("token", "t0", 0, &tokenCalls)).
+			MustWithNext("flaky", counted("flaky", true, 1, &flakyCalls)).
+			MustWithNext("mk_token", counted("token", "t1", 0, &mkCalls).WithSkipOnExists())
+
+		pipeline := M(hippo.MkPipeline(plan)).WithCheckpoints(hippo.MemCheckpoints())
+
+		_, err := pipeline.EkranRun(gtx.Of(t.Context()), "run", giraffe.OfEmpty())
+		require.Error(t, err)
+
+		state, err := pipeline.Resume(gtx.Of(t.Context()), "run")
+		require.NoError(t, err)
+		assert.Equal(t, "t0", M(state.QStr("fin.token")))
+		assert.Equal(t, int32(1), tokenCalls.Load())
+		assert.Equal(t, int32(0), mkCalls.Load())
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var calls atomic.Int32
+		failOnce := func() error {
+			if calls.Add(1) == 1 {
+				return EF("b failed")
+			}
+
+			return nil
+		}
+
+		noop := func() error {
+			return nil
+		}
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", parallelFn("x", "a", noop)).
+			MustWithNext("b", parallelFn("a", "b", failOnce)).
+			MustWithNext("c", parallelFn("x", "c", noop))
+
+		store := hippo.MemCheckpoints()
+		pipeline := M(hippo.MkPipeline(plan)).WithParallel(0).WithCheckpoints(store)
+
+		_, err := pipeline.EkranRun(gtx.Of(t.Context()), "run", giraffe.Of1(Q("x"), 0))
+		require.ErrorContains(t, err, "b failed")
+
+		c, err := store.Load(gtx.Of(t.Context()), "run")
+		require.NoError(t, err)
+		assert.Equal(t, 1, c.Done)
+
+		state, err := pipeline.Resume(gtx.Of(t.Context()), "run")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"init", "a", "b", "c"}, stepNames(t, state))
+		assert.JSONEq(t, `{"x": 0, "a": 1, "b": 2, "c": 1}`, string(M(M(state.Get(Q("fin"))).MarshalJSON())))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		plan := hippo.MkPlan().MustWithNext("a", hippo.Static(giraffe.Of1(Q("a"), 1)))
+		pipeline := M(hippo.MkPipeline(plan))
+
+		_, err := pipeline.EkranRun(gtx.Of(t.Context()), "run", giraffe.OfEmpty())
+		require.ErrorContains(t, err, "pipeline has no checkpoint store")
+
+		store := hippo.MemCheckpoints()
+		pipeline = pipeline.WithCheckpoints(store)
+
+		_, err = pipeline.EkranRun(gtx.Of(t.Context()), "../run", giraffe.OfEmpty())
+		require.ErrorContains(t, err, "invalid run id: ../run")
+
+		_, err = pipeline.EkranRun(gtx.Of(t.Context()), "run", giraffe.OfEmpty())
+		require.NoError(t, err)
+
+		other := hippo.MkPlan().MustWithNext("b", hippo.Static(giraffe.Of1(Q("b"), 1)))
+		_, err = M(hippo.MkPipeline(other)).WithCheckpoints(store).Resume(gtx.Of(t.Context()), "run")
+		require.ErrorContains(t, err, "checkpoint of run run is not of the plan")
+	})
+}
@@ -0,0 +1,256 @@
+package hippo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+// inc adds one to the int at q, failing the first fails calls.
+func inc(
+	q string,
+	fails int,
+) *hippo.Fn {
+	return hippo.FnOf(func(
+		_ gtx.Context,
+		c hippo.Call,
+	) (giraffe.Datum, error) {
+		if fails > 0 {
+			fails--
+			return giraffe.OfErr(), EF("failing on purpose")
+		}
+
+		v, err := c.Data().QISz(Q(q))
+		if err != nil {
+			return giraffe.OfErr(), err
+		}
+
+		return giraffe.Of1(Q(q), v+1), nil
+	}).WithMerging(giraffe.MergingOf().WithPolicy(giraffe.MergePolicyRightWins))
+}
+
+func mkDatum(t *testing.T, j string) giraffe.Datum {
+	t.Helper()
+
+	dat, err := giraffe.DatumSerde().Read([]byte(j))
+	require.NoError(t, err)
+
+	return dat
+}
+
+func ekranFlow(
+	t *testing.T,
+	plan *hippo.Plan,
+	ini string,
+) giraffe.Datum {
+	t.Helper()
+
+	pipeline, err := hippo.MkPipeline(plan)
+	require.NoError(t, err)
+
+	state, err := pipeline.Ekran(gtx.Of(t.Context()), mkDatum(t, ini))
+	require.NoError(t, err)
+
+	return M(state.Get(Q("fin")))
+}
+
+func TestFlow(t *testing.T) {
+	t.Run("if", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		then := hippo.MkPlan().MustWithNext("then", hippo.Static(giraffe.Of1(Q("branch"), "then")))
+		otherwise := hippo.MkPlan().MustWithNext("else", hippo.Static(giraffe.Of1(Q("branch"), "else")))
+
+		plan, err := hippo.MkPlan().WithNextIf("check", "user.age >= 18", then, otherwise)
+		require.NoError(t, err)
+
+		fin := ekranFlow(t, plan, `{"user": {"age": 20}}`)
+		assert.Equal(t, "then", M(fin.QStr("branch")))
+
+		fin = ekranFlow(t, plan, `{"user": {"age": 10}}`)
+		assert.Equal(t, "else", M(fin.QStr("branch")))
+
+		fin = ekranFlow(t, plan, `{}`)
+		assert.Equal(t, "else", M(fin.QStr("branch")))
+
+		plan, err = hippo.MkPlan().WithNextIf("check", "user.email", then, nil)
+		require.NoError(t, err)
+
+		fin = ekranFlow(t, plan, `{"user": {}}`)
+		assert.JSONEq(t, `{"user": {}}`, string(M(fin.MarshalJSON())))
+	})
+
+	t.Run("switch", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		branch := func(name string) *hippo.Plan {
+			return hippo.MkPlan().MustWithNext(name, hippo.Static(giraffe.Of1(Q("branch"), name)))
+		}
+
+		plan, err := hippo.MkPlan().WithNextSwitch("route", Q("kind"), map[string]*hippo.Plan{
+			"a":    branch("a"),
+			"1":    branch("one"),
+			"null": branch("none"),
+		}, branch("other"))
+		require.NoError(t, err)
+
+		for ini, expecting := range map[string]string{
+			`{"kind": "a"}`:  "a",
+			`{"kind": 1}`:    "one",
+			`{"kind": null}`: "none",
+			`{"kind": "z"}`:  "other",
+			`{}`:             "other",
+		} {
+			fin := ekranFlow(t, plan, ini)
+			assert.Equal(t, expecting, M(fin.QStr("branch")), ini)
+		}
+	})
+
+	t.Run("for each", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		each := hippo.MkPlan().MustWithNext("inc", inc("item.n", 0))
+
+		plan, err := hippo.MkPlan().WithNextForEach("loop", Q("items"), Q("item"), Q("out"), each)
+		require.NoError(t, err)
+
+		fin := ekranFlow(t, plan, `{"items": [{"n": 1}, {"n": 5}]}`)
+		assert.JSONEq(t, `{
+			"items": [{"n": 1}, {"n": 5}],
+			"out": [{"n": 2}, {"n": 6}]
+		}`, string(M(fin.MarshalJSON())))
+	})
+
+	t.Run("while", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		body := hippo.MkPlan().MustWithNext("inc", inc("n", 0))
+
+		plan, err := hippo.MkPlan().WithNextWhile("loop", "n < 3", 5, body)
+		require.NoError(t, err)
+
+		fin := ekranFlow(t, plan, `{"n": 0}`)
+		assert.Equal(t, 3, M(fin.QISz("n")))
+
+		fin = ekranFlow(t, plan, `{"n": 7}`)
+		assert.Equal(t, 7, M(fin.QISz("n")))
+
+		plan, err = hippo.MkPlan().WithNextWhile("loop", "n < 10", 5, body)
+		require.NoError(t, err)
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		_, err = pipeline.Ekran(gtx.Of(t.Context()), mkDatum(t, `{"n": 0}`))
+		require.ErrorContains(t, err, "loop did not end in 5 runs")
+	})
+
+	t.Run("until", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		body := hippo.MkPlan().MustWithNext("inc", inc("n", 2))
+
+		plan, err := hippo.MkPlan().WithNextUntil("retry", "n >= 1", 3, body)
+		require.NoError(t, err)
+
+		fin := ekranFlow(t, plan, `{"n": 0}`)
+		assert.Equal(t, 1, M(fin.QISz("n")))
+
+		body = hippo.MkPlan().MustWithNext("inc", inc("n", 5))
+
+		plan, err = hippo.MkPlan().WithNextUntil("retry", "n >= 1", 3, body)
+		require.NoError(t, err)
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		_, err = pipeline.Ekran(gtx.Of(t.Context()), mkDatum(t, `{"n": 0}`))
+		require.ErrorContains(t, err, "failing on purpose")
+		require.ErrorContains(t, err, "loop did not end in 3 runs")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		body := hippo.MkPlan().MustWithNext("inc", inc("n", 0))
+
+		_, err := hippo.If("a ==", body, nil)
+		require.Error(t, err)
+
+		_, err = hippo.If("a", nil, body)
+		require.Error(t, err)
+
+		_, err = hippo.While("a", 0, body)
+		require.Error(t, err)
+
+		_, err = hippo.Until("a", 1, hippo.MkPlan())
+		require.Error(t, err)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		reg := hippo.
+			MkFnRegistry().
+			MustWithNamed("inc", inc("n", 0)).
+			MustWithNamed("inc_item", inc("item.n", 0))
+
+		var steps []hippo.FnConfig
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"while": {"cond": "n < 2", "most": 5, "do": [{"fn": "inc"}]}},
+			{"if": {
+				"cond": "n == 2",
+				"then": [{"fn": "inc"}],
+				"else": [{"fn": "inc"}, {"fn": "inc"}]
+			}},
+			{"switch": {
+				"on": "n",
+				"cases": {"3": [{"fn": "inc"}]},
+				"default": []
+			}},
+			{"for_each": {
+				"over": "items",
+				"as": "item",
+				"into": "out",
+				"do": [{"fn": "inc_item"}, {"until": {"cond": "item.n > 5", "most": 9, "do": [{"fn": "inc_item"}]}}]
+			}}
+		]`), &steps))
+
+		plan, err := hippo.MkPlan().MustAndRegistry(reg).WithSteps(steps...)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"while#0000", "if#0001", "switch#0002", "for_each#0003"}, plan.Names())
+
+		fin := ekranFlow(t, plan, `{"n": 0, "items": [{"n": 0}, {"n": 7}]}`)
+		assert.JSONEq(t, `{
+			"n": 4,
+			"items": [{"n": 0}, {"n": 7}],
+			"out": [{"n": 6}, {"n": 9}]
+		}`, string(M(fin.MarshalJSON())))
+	})
+
+	t.Run("configured invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, step := range []string{
+			`{"fn": "inc", "if": {"cond": "a", "then": [{"fn": "inc"}]}}`,
+			`{}`,
+			`{"if": {"cond": "a", "then": []}}`,
+			`{"while": {"cond": "a", "most": 0, "do": [{"fn": "inc"}]}}`,
+			`{"if": {"cond": "a", "then": [{"fn": "nope"}]}}`,
+		} {
+			var cfg hippo.FnConfig
+			require.NoError(t, json.Unmarshal([]byte(step), &cfg))
+
+			_, err := cfg.Make(hippo.MkFnRegistry().MustWithNamed("inc", inc("n", 0)))
+			require.Error(t, err, step)
+		}
+	})
+}
@@ -73,6 +73,18 @@ type FnConfiguredData interface {
 	AndSelect(...giraffe.Query) FnOrig
 	WithSelect(...giraffe.Query) FnOrig
 	WithoutSelect() FnOrig
+
+	Merging() giraffe.Merging
+	WithMerging(giraffe.Merging) FnOrig
+	WithoutMerging() FnOrig
+
+	InputSchema() (giraffe.Schema, bool)
+	WithInputSchema(giraffe.Schema) FnOrig
+	WithoutInputSchema() FnOrig
+
+	OutputSchema() (giraffe.Schema, bool)
+	WithOutputSchema(giraffe.Schema) FnOrig
+	WithoutOutputSchema() FnOrig
 }
 
 type FnConfigured interface {
@@ -69,3 +69,27 @@ func TestRunner_Http(t *testing.T) {
 		gtesting.Write(t, "fin.json", state.Pretty())
 	})
 }
+
+func TestTunnels_Endpoint(t *testing.T) {
+	gtesting.Preamble(t)
+
+	tunnels := hippo.MkTunnels(conn.MakeCfg(gtesting.Zap(t)).Datum())
+
+	for _, ep := range []string{
+		"http://localhost",
+		"http://localhost:8080",
+		"https://127.0.0.1:8443",
+	} {
+		_, err := tunnels.WithGet("api", ep)
+		require.NoError(t, err, ep)
+	}
+
+	for _, ep := range []string{
+		"http://localhost:8080/",
+		"http://localhost:8080/path",
+		"http://localhost?q=1",
+	} {
+		_, err := tunnels.WithGet("api", ep)
+		require.ErrorContains(t, err, "endpoint must be only scheme, host and port", ep)
+	}
+}
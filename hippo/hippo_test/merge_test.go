@@ -0,0 +1,119 @@
+package hippo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+func TestPipeline_Merging(t *testing.T) {
+	emit := func(v int) *hippo.Fn {
+		return hippo.Static(giraffe.Of1(Q("out"), v))
+	}
+
+	t.Run("clashing step outputs fail by default", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("s_0", emit(1)).
+			MustWithNext("s_1", emit(2))
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		_, err = pipeline.Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.Error(t, err)
+	})
+
+	t.Run("later step overwrites", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("s_0", emit(1)).
+			MustWithNext("s_1", emit(2).WithMerging(
+				giraffe.MergingOf().WithRightWins(),
+			))
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		state, err := pipeline.Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.NoError(t, err)
+
+		fin, err := state.QISz(Q("fin.out"))
+		require.NoError(t, err)
+		assert.Equal(t, 2, fin)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		reg := hippo.
+			MkFnRegistry().
+			MustWithNamed("one", emit(1)).
+			MustWithNamed("two", emit(2))
+
+		var steps []hippo.FnConfig
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"fn": "one"},
+			{"fn": "two", "merge_policy": "left"}
+		]`), &steps))
+
+		plan, err := hippo.MkPlan().MustAndRegistry(reg).WithSteps(steps...)
+		require.NoError(t, err)
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		state, err := pipeline.Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.NoError(t, err)
+
+		fin, err := state.QISz(Q("fin.out"))
+		require.NoError(t, err)
+		assert.Equal(t, 1, fin)
+	})
+	t.Run("invalid configs", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		reg := hippo.MkFnRegistry().MustWithNamed("one", emit(1))
+
+		for cfg, expecting := range map[string]string{
+			`{"fn": "one", "merge_arr": "keyed"}`:                     "keyed array merge without merge key",
+			`{"fn": "one", "merge_key": "id"}`:                        "merge key given without keyed array merge",
+			`{"fn": "one", "merge_arr": "concat", "merge_key": "id"}`: "merge key given without keyed array merge",
+		} {
+			var step hippo.FnConfig
+			require.NoError(t, json.Unmarshal([]byte(cfg), &step))
+
+			errs := step.Validate()
+			require.Len(t, errs, 1, cfg)
+			require.ErrorContains(t, errs[0], expecting, cfg)
+
+			_, err := hippo.MkPlan().MustAndRegistry(reg).WithSteps(step)
+			require.ErrorContains(t, err, expecting, cfg)
+		}
+
+		var step hippo.FnConfig
+		require.Error(t, json.Unmarshal([]byte(`{"fn": "one", "merge_policy": "resolve"}`), &step))
+
+		resolve := giraffe.MergePolicyResolve
+		step = hippo.FnConfig{Fn: "one", MergePolicy: &resolve}
+		errs := step.Validate()
+		require.Len(t, errs, 1)
+		require.ErrorContains(t, errs[0], "resolve merge policy is not configurable")
+
+		var keyed hippo.FnConfig
+		require.NoError(t, json.Unmarshal([]byte(`{"fn": "one", "merge_arr": "keyed", "merge_key": "id"}`), &keyed))
+		assert.Empty(t, keyed.Validate())
+	})
+}
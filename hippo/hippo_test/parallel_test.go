@@ -0,0 +1,191 @@
+package hippo_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+// parallelFn reads in, and writes in+1 to out, calling hook first.
+func parallelFn(
+	in string,
+	out string,
+	hook func() error,
+) *hippo.Fn {
+	return hippo.FnOf(func(
+		_ gtx.Context,
+		c hippo.Call,
+	) (giraffe.Datum, error) {
+		if err := hook(); err != nil {
+			return giraffe.OfErr(), err
+		}
+
+		v, err := c.Data().QISz(Q(in))
+		if err != nil {
+			return giraffe.OfErr(), err
+		}
+
+		return giraffe.Of1(Q(out), v+1), nil
+	}).WithInputs(Q(in)).WithOutput(Q(out))
+}
+
+func TestPipeline_Parallel(t *testing.T) {
+	noop := func() error {
+		return nil
+	}
+
+	t.Run("independent steps run together", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		// Each of a, b and c waits for the others to start.
+		var barrier sync.WaitGroup
+		barrier.Add(3)
+		await := func() error {
+			barrier.Done()
+
+			waited := make(chan struct{})
+			go func() {
+				barrier.Wait()
+				close(waited)
+			}()
+
+			select {
+			case <-waited:
+				return nil
+			case <-time.After(5 * time.Second):
+				return EF("steps did not run concurrently")
+			}
+		}
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", parallelFn("x", "a", await)).
+			MustWithNext("b", parallelFn("x", "b", await)).
+			MustWithNext("c", parallelFn("x", "c", await)).
+			MustWithNext("d", parallelFn("b", "d", noop))
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		state, err := pipeline.
+			WithParallel(0).
+			Ekran(gtx.Of(t.Context()), giraffe.Of1(Q("x"), 0))
+		require.NoError(t, err)
+
+		fin, err := state.Get(Q("fin"))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"x": 0, "a": 1, "b": 1, "c": 1, "d": 2}`, string(M(fin.MarshalJSON())))
+
+		all, err := state.GetAll(Q("steps.*.name"))
+		require.NoError(t, err)
+
+		var names []string
+		for _, name := range all {
+			names = append(names, M(name.Str()))
+		}
+		assert.Equal(t, []string{"init", "a", "b", "c", "d"}, names)
+	})
+
+	t.Run("same as sequential", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", parallelFn("x", "a", noop)).
+			MustWithNext("b", parallelFn("a", "b", noop)).
+			MustWithNext("s", hippo.Static(giraffe.Of1(Q("s"), 9))).
+			MustWithNext("c", parallelFn("x", "c", noop)).
+			MustWithNext("d", parallelFn("c", "d", noop))
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		ini := giraffe.Of1(Q("x"), 0)
+
+		sequential, err := pipeline.Ekran(gtx.Of(t.Context()), ini)
+		require.NoError(t, err)
+
+		parallel, err := pipeline.WithParallel(2).Ekran(gtx.Of(t.Context()), ini)
+		require.NoError(t, err)
+
+		assert.True(t, sequential.Eq(parallel), parallel.Pretty())
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var (
+			mu      sync.Mutex
+			running int
+			most    int
+		)
+		track := func() error {
+			mu.Lock()
+			running++
+			most = max(most, running)
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		}
+
+		plan := hippo.MkPlan()
+		for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+			plan = plan.MustWithNext(name, parallelFn("x", name, track))
+		}
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		_, err = pipeline.
+			WithParallel(2).
+			Ekran(gtx.Of(t.Context()), giraffe.Of1(Q("x"), 0))
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, most)
+	})
+
+	t.Run("earliest failure", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var ran atomic.Bool
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", parallelFn("x", "a", noop)).
+			MustWithNext("b", parallelFn("x", "b", func() error {
+				time.Sleep(10 * time.Millisecond)
+				return EF("b failed")
+			})).
+			MustWithNext("c", parallelFn("x", "c", func() error {
+				return EF("c failed")
+			})).
+			MustWithNext("d", parallelFn("c", "d", func() error {
+				ran.Store(true)
+				return nil
+			}))
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		_, err = pipeline.
+			WithParallel(0).
+			Ekran(gtx.Of(t.Context()), giraffe.Of1(Q("x"), 0))
+		require.ErrorContains(t, err, "b failed")
+		assert.False(t, ran.Load())
+	})
+}
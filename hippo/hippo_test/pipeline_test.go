@@ -0,0 +1,107 @@
+package hippo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+func TestPipeline_DeltaHistory(t *testing.T) {
+	t.Run("steps replay to fin", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("s_0", hippo.Static(giraffe.Of1(Q("a.b"), 1))).
+			MustWithNext("s_1", hippo.Static(giraffe.Of1(Q("a.c"), 2)))
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		state, err := pipeline.
+			WithDeltaHistory().
+			Ekran(gtx.Of(t.Context()), giraffe.Of1(Q("x"), 0))
+		require.NoError(t, err)
+
+		steps, err := state.Get(Q("steps"))
+		require.NoError(t, err)
+
+		n, err := steps.Len()
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+
+		replay, err := state.Get(Q("steps.0.state"))
+		require.NoError(t, err)
+
+		for i := 1; i < n; i++ {
+			step, err := steps.At(i)
+			require.NoError(t, err)
+
+			has, err := step.Has(Q("state"))
+			require.NoError(t, err)
+			assert.False(t, has)
+
+			pDat, err := step.Get(Q("patch"))
+			require.NoError(t, err)
+
+			encoded, err := pDat.MarshalJSON()
+			require.NoError(t, err)
+
+			var patch giraffe.JsonPatch
+			require.NoError(t, json.Unmarshal(encoded, &patch))
+
+			replay, err = replay.Patched(patch)
+			require.NoError(t, err)
+		}
+
+		fin, err := state.Get(Q("fin"))
+		require.NoError(t, err)
+		assert.True(t, fin.Eq(replay), replay.Pretty())
+	})
+}
+
+func TestStaticTemplate(t *testing.T) {
+	t.Run("renders against data", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		doc, err := giraffe.DatumSerde().Read([]byte(`{
+			"greeting": "Hello {{ .user.name | upper }}",
+			"id": {"$q": ".user.id", "$default": 0}
+		}`))
+		require.NoError(t, err)
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("s_0", hippo.Static(giraffe.Of1(Q("user.name"), "ann"))).
+			MustWithNext("s_1", M(hippo.StaticTemplate(doc)))
+
+		pipeline, err := hippo.MkPipeline(plan)
+		require.NoError(t, err)
+
+		state, err := pipeline.Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.NoError(t, err)
+
+		greeting, err := state.QStr("fin.greeting")
+		require.NoError(t, err)
+		assert.Equal(t, "Hello ANN", greeting)
+
+		id, err := state.QISz("fin.id")
+		require.NoError(t, err)
+		assert.Equal(t, 0, id)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := hippo.StaticTemplate(giraffe.Of1(Q("x"), "{{ .a | shout }}"))
+		require.Error(t, err)
+	})
+}
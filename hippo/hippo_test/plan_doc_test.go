@@ -0,0 +1,279 @@
+package hippo_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+	"github.com/hkoosha/giraffe/hippo/remote"
+)
+
+const planDocYaml = `
+name: greet
+defaults:
+  merge_policy: right
+statics:
+  hello:
+    greeting: "hello {{ user.name }}"
+compensations:
+  - on_name_re: "^fail"
+    with:
+      recovered: true
+steps:
+  - fn: hello
+  - fn: inc
+  - if:
+      cond: n > 0
+      then:
+        - fn: inc
+`
+
+func TestLoadPlan(t *testing.T) {
+	reg := hippo.MkFnRegistry().MustWithNamed("inc", inc("n", 0))
+
+	t.Run("yaml", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		doc, err := hippo.LoadPlan(strings.NewReader(planDocYaml))
+		require.NoError(t, err)
+		assert.Equal(t, "greet", doc.Name)
+
+		plan, err := doc.Plan(reg, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hello#0000", "inc#0001", "if#0002"}, plan.Names())
+
+		fin := ekranFlow(t, plan, `{"n": 0, "user": {"name": "ann"}}`)
+		assert.JSONEq(t, `{
+			"n": 2,
+			"user": {"name": "ann"},
+			"greeting": "hello ann"
+		}`, string(M(fin.MarshalJSON())))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		doc, err := hippo.LoadPlan(strings.NewReader(`{
+			"name": "fallible",
+			"compensations": [{"on_err_re": "on purpose", "with": {"recovered": true}}],
+			"steps": [{"fn": "fail"}]
+		}`))
+		require.NoError(t, err)
+
+		plan, err := doc.Plan(hippo.MkFnRegistry().MustWithNamed("fail", inc("n", 1)), nil)
+		require.NoError(t, err)
+
+		fin := ekranFlow(t, plan, `{"n": 0}`)
+		assert.JSONEq(t, `{"n": 0, "recovered": true}`, string(M(fin.MarshalJSON())))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		_, err := hippo.LoadPlan(strings.NewReader(`
+name: "-"
+defaults:
+  fn: inc
+statics:
+  bad: "{{ a | nope }}"
+tunnels:
+  endpoints:
+    api:
+      endpoint: "http://localhost/path"
+      methods: [FETCH]
+compensations:
+  - on_err_re: "("
+steps:
+  - fn: inc
+    merge_arr: keyed
+`))
+		require.Error(t, err)
+
+		for _, expecting := range []string{
+			"invalid plan name: -",
+			"defaults must not have an fn or a control flow",
+			"invalid static: bad",
+			"invalid tunnel: api",
+			"unsupported http method of tunnel api: FETCH",
+			"invalid compensation: 0",
+			"keyed array merge without merge key",
+			"invalid step: 0",
+		} {
+			assert.ErrorContains(t, err, expecting)
+		}
+
+		_, err = hippo.LoadPlan(strings.NewReader(`{"name": "a", "steps": [{"fn": "inc"}], "nope": 1}`))
+		require.ErrorContains(t, err, "nope")
+
+		_, err = hippo.LoadPlan(strings.NewReader(`{"name": "a", "steps": []}`))
+		require.ErrorContains(t, err, "plan has no steps: a")
+	})
+
+	t.Run("tunnels without connection", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		doc, err := hippo.LoadPlan(strings.NewReader(`
+name: fetch
+tunnels:
+  endpoints:
+    api:
+      endpoint: "http://localhost:8080"
+      methods: [GET]
+steps:
+  - fn: GET
+    args: {channel: api, path: /}
+`))
+		require.NoError(t, err)
+
+		_, err = doc.Plan(reg, nil)
+		require.ErrorContains(t, err, "plan has tunnels but no connection: fetch")
+	})
+
+	t.Run("validate returns errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		arr := giraffe.ArrMergeKeyed
+		_, err := hippo.MkPlan().MustAndRegistry(reg).WithSteps(hippo.FnConfig{
+			Fn:       "inc",
+			MergeArr: &arr,
+		})
+		require.ErrorContains(t, err, "keyed array merge without merge key")
+	})
+}
+
+func TestPlanFiles(t *testing.T) {
+	reg := hippo.MkFnRegistry().MustWithNamed("inc", inc("n", 0))
+
+	serve := func(
+		t *testing.T,
+		srv remote.Server,
+		req string,
+	) (string, error) {
+		t.Helper()
+
+		out := bytes.Buffer{}
+		if err := srv(gtx.Of(t.Context()), strings.NewReader(req), &out); err != nil {
+			return "", err
+		}
+
+		fin, err := mkDatum(t, out.String()).Get(Q("fin"))
+		require.NoError(t, err)
+
+		return string(M(fin.MarshalJSON())), nil
+	}
+
+	t.Run("reload", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		fsys := fstest.MapFS{
+			"greet.yaml": {Data: []byte(planDocYaml)},
+			"README.md":  {Data: []byte("not a plan")},
+		}
+		files := remote.MkPlanFiles(fsys, reg)
+
+		loaded, err := files.Load()
+		require.NoError(t, err)
+
+		plans, err := remote.NewPlans(loaded)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"greet"}, plans.Names())
+
+		srv := remote.NewReloadingServer(reg, plans)
+
+		out, err := serve(t, srv, `{"plan": "greet", "init": {"n": 0, "user": {"name": "bob"}}}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"n": 2, "user": {"name": "bob"}, "greeting": "hello bob"}`, out)
+
+		fsys["count.json"] = &fstest.MapFile{Data: []byte(`{"name": "count", "steps": [{"fn": "inc"}]}`)}
+		require.NoError(t, files.Reload(plans))
+		assert.Equal(t, []string{"count", "greet"}, plans.Names())
+
+		out, err = serve(t, srv, `{"plan": "count", "init": {"n": 5}}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"n": 6}`, out)
+
+		// All or nothing.
+		fsys["broken.yaml"] = &fstest.MapFile{Data: []byte(`{"name": "broken", "steps": [{"fn": "nope"}]}`)}
+		delete(fsys, "count.json")
+		require.ErrorContains(t, files.Reload(plans), "invalid plan file: broken.yaml")
+		assert.Equal(t, []string{"count", "greet"}, plans.Names())
+
+		fsys["broken.yaml"] = &fstest.MapFile{Data: []byte(`{"name": "greet", "steps": [{"fn": "inc"}]}`)}
+		require.ErrorContains(t, files.Reload(plans), "duplicate plan: greet")
+	})
+
+	t.Run("zero plans", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		plans := &remote.Plans{}
+		_, ok := plans.Get("greet")
+		assert.False(t, ok)
+		assert.Empty(t, plans.Names())
+
+		require.NoError(t, remote.MkPlanFiles(fstest.MapFS{
+			"greet.yaml": {Data: []byte(planDocYaml)},
+		}, reg).Reload(plans))
+		assert.Equal(t, []string{"greet"}, plans.Names())
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		dir := t.TempDir()
+		write := func(name, content string) {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		}
+
+		write("count.json", `{"name": "count", "steps": [{"fn": "inc"}]}`)
+
+		files := remote.MkPlanFiles(os.DirFS(dir), reg)
+		plans, err := remote.NewPlans(nil)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		errs := make(chan error, 16)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			files.Watch(gtx.Of(ctx), plans, 5*time.Millisecond, func(err error) {
+				errs <- err
+			})
+		}()
+
+		assert.Eventually(t, func() bool {
+			_, ok := plans.Get("count")
+			return ok
+		}, 5*time.Second, 5*time.Millisecond)
+
+		write("more.yaml", "name: more\nsteps: [{fn: inc}, {fn: inc}]\n")
+		assert.Eventually(t, func() bool {
+			_, ok := plans.Get("more")
+			return ok
+		}, 5*time.Second, 5*time.Millisecond)
+
+		write("broken.yaml", "name: broken\nsteps: [{fn: nope}]\n")
+		select {
+		case err := <-errs:
+			require.ErrorContains(t, err, "invalid plan file: broken.yaml")
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "failed reload not reported")
+		}
+		assert.Equal(t, []string{"count", "more"}, plans.Names())
+
+		cancel()
+		<-done
+	})
+}
@@ -0,0 +1,245 @@
+package hippo_test
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+// undoLog records the undos run, in order, with the value at q they saw, each
+// undo failing its first fails calls.
+type undoLog struct {
+	undone []string
+	mu     sync.Mutex
+}
+
+func (l *undoLog) undo(
+	q string,
+	fails int,
+) *hippo.Fn {
+	var calls atomic.Int32
+
+	return hippo.FnOf(func(
+		_ gtx.Context,
+		c hippo.Call,
+	) (giraffe.Datum, error) {
+		v, err := M(c.Data().Get(Q(q))).SimpleString()
+		if err != nil {
+			return giraffe.OfErr(), err
+		}
+
+		l.mu.Lock()
+		l.undone = append(l.undone, v)
+		l.mu.Unlock()
+
+		if calls.Add(1) <= int32(fails) {
+			return giraffe.OfErr(), EF("undo failing on purpose: %s", v)
+		}
+
+		return giraffe.OfEmpty(), nil
+	})
+}
+
+func TestPipeline_Rollback(t *testing.T) {
+	var never atomic.Int32
+
+	t.Run("reverse", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		log := &undoLog{}
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", counted("a", "made a", 0, &never).WithUndo(log.undo("a", 0))).
+			MustWithNext("b", counted("b", "made b", 0, &never)).
+			MustWithNext("c", counted("c", "made c", 0, &never).WithUndo(log.undo("c", 0))).
+			MustWithNext("d", counted("d", "made d", 9, &atomic.Int32{}))
+
+		_, err := M(hippo.MkPipeline(plan)).Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.ErrorContains(t, err, "failing on purpose")
+		assert.Equal(t, []string{"made c", "made a"}, log.undone)
+		assert.ErrorContains(t, err, `"rollback": "done"`)
+		assert.NotContains(t, err.Error(), "rollback failed")
+	})
+
+	t.Run("errors aggregated", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		log := &undoLog{}
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", counted("a", "made a", 0, &never).WithUndo(log.undo("a", 9))).
+			MustWithNext("b", counted("b", "made b", 0, &never).WithUndo(log.undo("b", 0))).
+			MustWithNext("c", counted("c", "made c", 0, &never).WithUndo(log.undo("c", 9))).
+			MustWithNext("d", counted("d", "made d", 9, &atomic.Int32{}))
+
+		_, err := M(hippo.MkPipeline(plan)).Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.ErrorContains(t, err, "failing on purpose")
+		assert.Equal(t, []string{"made c", "made b", "made a"}, log.undone)
+
+		for _, expecting := range []string{
+			"rollback failed",
+			"cannot roll back step: a",
+			"cannot roll back step: c",
+			"undo failing on purpose: made a",
+			"undo failing on purpose: made c",
+			`"rollback": "failed"`,
+			`"rollback": "done"`,
+		} {
+			assert.ErrorContains(t, err, expecting)
+		}
+	})
+
+	t.Run("compensated", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		log := &undoLog{}
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", counted("a", "made a", 0, &never).WithUndo(log.undo("a", 0))).
+			MustWithNext("b", counted("b", "made b", 9, &atomic.Int32{})).
+			WithCompensator(hippo.Compensator{}.ForStepWith(1, giraffe.Of1(Q("b"), "fixed")))
+
+		state, err := M(hippo.MkPipeline(plan)).Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.NoError(t, err)
+		assert.Equal(t, "fixed", M(state.QStr("fin.b")))
+		assert.Empty(t, log.undone)
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		log := &undoLog{}
+		noop := func() error {
+			return nil
+		}
+
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", parallelFn("x", "a", noop).WithUndo(log.undo("x", 0))).
+			MustWithNext("b", parallelFn("a", "b", func() error {
+				return EF("b failed")
+			})).
+			MustWithNext("c", parallelFn("x", "c", noop).WithUndo(log.undo("c", 0)))
+
+		_, err := M(hippo.MkPipeline(plan)).
+			WithParallel(0).
+			Ekran(gtx.Of(t.Context()), giraffe.Of1(Q("x"), 0))
+		require.ErrorContains(t, err, "b failed")
+
+		// c is done past the failure of b, and undone first.
+		assert.Equal(t, []string{"1", "0"}, log.undone)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		log := &undoLog{}
+		reg := hippo.
+			MkFnRegistry().
+			MustWithNamed("make", counted("made", "yes", 0, &never)).
+			MustWithNamed("unmake", log.undo("made", 0)).
+			MustWithNamed("fail", counted("failed", "yes", 9, &atomic.Int32{}))
+
+		var steps []hippo.FnConfig
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"fn": "make", "undo": {"fn": "unmake"}},
+			{"fn": "fail"}
+		]`), &steps))
+
+		plan, err := hippo.MkPlan().MustAndRegistry(reg).WithSteps(steps...)
+		require.NoError(t, err)
+
+		_, err = M(hippo.MkPipeline(plan)).Ekran(gtx.Of(t.Context()), giraffe.OfEmpty())
+		require.ErrorContains(t, err, "failing on purpose")
+		assert.Equal(t, []string{"yes"}, log.undone)
+
+		var cfg hippo.FnConfig
+		require.NoError(t, json.Unmarshal([]byte(`{"fn": "make", "undo": {"fn": "unmake", "undo": {"fn": "make"}}}`), &cfg))
+		assert.NotEmpty(t, cfg.Validate())
+	})
+
+	t.Run("restarts checkpoint", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		log := &undoLog{}
+		var aCalls atomic.Int32
+		plan := hippo.
+			MkPlan().
+			MustWithNext("a", counted("a", "made a", 0, &aCalls).WithUndo(log.undo("a", 0))).
+			MustWithNext("b", counted("b", "made b", 1, &atomic.Int32{}))
+
+		store := hippo.MemCheckpoints()
+		pipeline := M(hippo.MkPipeline(plan)).WithCheckpoints(store)
+
+		_, err := pipeline.EkranRun(gtx.Of(t.Context()), "run", giraffe.Of1(Q("n"), 1))
+		require.Error(t, err)
+
+		c, err := store.Load(gtx.Of(t.Context()), "run")
+		require.NoError(t, err)
+		assert.Equal(t, 0, c.Done)
+		assert.JSONEq(t, `{"n": 1}`, string(M(c.Dat.MarshalJSON())))
+
+		state, err := pipeline.Resume(gtx.Of(t.Context()), "run")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"init", "a", "b"}, stepNames(t, state))
+		assert.Equal(t, int32(2), aCalls.Load())
+	})
+	pipelines := map[string]func(*hippo.PipelineFn) *hippo.PipelineFn{
+		"sequential": func(p *hippo.PipelineFn) *hippo.PipelineFn {
+			return p
+		},
+		"deltas": func(p *hippo.PipelineFn) *hippo.PipelineFn {
+			return p.WithDeltaHistory()
+		},
+		"parallel": func(p *hippo.PipelineFn) *hippo.PipelineFn {
+			return p.WithParallel(0)
+		},
+	}
+
+	for kind, mkPipeline := range pipelines {
+		t.Run("resumed "+kind, func(t *testing.T) {
+			gtesting.Preamble(t)
+
+			log := &undoLog{}
+			plan := hippo.
+				MkPlan().
+				MustWithNext("a", counted("a", "made a", 0, &never).WithUndo(log.undo("a", 1))).
+				MustWithNext("b", counted("b", "made b", 0, &never).WithUndo(log.undo("b", 0))).
+				MustWithNext("c", counted("c", "made c", 1, &atomic.Int32{}).WithUndo(log.undo("c", 0))).
+				MustWithNext("d", counted("d", "made d", 9, &atomic.Int32{}))
+
+			store := hippo.MemCheckpoints()
+			pipeline := mkPipeline(M(hippo.MkPipeline(plan))).WithCheckpoints(store)
+
+			// The rollback fails, so the checkpoint is kept to resume.
+			_, err := pipeline.EkranRun(gtx.Of(t.Context()), "run", giraffe.Of1(Q("n"), 1))
+			require.ErrorContains(t, err, "rollback failed")
+			assert.Equal(t, []string{"made b", "made a"}, log.undone)
+
+			c, err := store.Load(gtx.Of(t.Context()), "run")
+			require.NoError(t, err)
+			assert.Equal(t, 2, c.Done)
+
+			log.undone = nil
+			_, err = pipeline.Resume(gtx.Of(t.Context()), "run")
+			require.ErrorContains(t, err, "failing on purpose")
+			assert.NotContains(t, err.Error(), "rollback failed")
+			assert.Equal(t, []string{"made c", "made b", "made a"}, log.undone)
+
+			c, err = store.Load(gtx.Of(t.Context()), "run")
+			require.NoError(t, err)
+			assert.Equal(t, 0, c.Done)
+			assert.JSONEq(t, `{"n": 1}`, string(M(c.Dat.MarshalJSON())))
+		})
+	}
+}
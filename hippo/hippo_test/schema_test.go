@@ -0,0 +1,86 @@
+package hippo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	. "github.com/hkoosha/giraffe/dot"
+	"github.com/hkoosha/giraffe/hippo"
+)
+
+func TestFn_Schema(t *testing.T) {
+	ekran := func(t *testing.T, fn *hippo.Fn, dat giraffe.Datum) error {
+		t.Helper()
+
+		pipeline, err := hippo.MkPipeline(hippo.MkPlan().MustWithNext("s_0", fn))
+		require.NoError(t, err)
+
+		_, err = pipeline.Ekran(gtx.Of(t.Context()), dat)
+
+		return err
+	}
+
+	in := giraffe.SchemaOf(map[giraffe.Query]giraffe.Type{
+		Q("n"): giraffe.Int,
+	})
+
+	out := giraffe.SchemaOf(map[giraffe.Query]giraffe.Type{
+		Q("out"): giraffe.Str,
+	})
+
+	t.Run("typed inputs", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		fn := hippo.Static(giraffe.Of1(Q("out"), "x")).WithInputSchema(in)
+
+		require.NoError(t, ekran(t, fn, giraffe.Of1(Q("n"), 1)))
+
+		err := ekran(t, fn, giraffe.Of1(Q("n"), "1"))
+		var sErr *giraffe.SchemaError
+		require.ErrorAs(t, err, &sErr)
+		assert.Equal(t, Q("n"), sErr.Violations()[0].Query)
+	})
+
+	t.Run("typed outputs", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		fn := hippo.Static(giraffe.Of1(Q("out"), 1)).WithOutputSchema(out)
+
+		err := ekran(t, fn, giraffe.OfEmpty())
+		var sErr *giraffe.SchemaError
+		require.ErrorAs(t, err, &sErr)
+		assert.Equal(t, Q("out"), sErr.Violations()[0].Query)
+
+		require.NoError(t, ekran(t, fn.WithoutOutputSchema(), giraffe.OfEmpty()))
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var cfg hippo.FnConfig
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"fn": "emit",
+			"input_schema": {
+				"type": "object",
+				"required": ["n"],
+				"properties": {"n": {"type": "integer", "maximum": 10}}
+			}
+		}`), &cfg))
+
+		fn, err := cfg.Configure(hippo.Static(giraffe.Of1(Q("out"), "x")))
+		require.NoError(t, err)
+
+		schema, ok := fn.InputSchema()
+		require.True(t, ok)
+		assert.Equal(t, in.String(), schema.String())
+
+		require.NoError(t, ekran(t, fn, giraffe.Of1(Q("n"), 10)))
+		require.Error(t, ekran(t, fn, giraffe.Of1(Q("n"), 11)))
+	})
+}
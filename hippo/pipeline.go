@@ -50,6 +50,10 @@ func MkPipeline(
 		before: nil,
 		after:  nil,
 		plan:   plan,
+		deltas: false,
+		dag:    false,
+		limit:  0,
+		store:  nil,
 	}, nil
 }
 
@@ -57,6 +61,10 @@ type PipelineFn struct {
 	before ProbeBefore
 	after  ProbeBefore
 	plan   *Plan
+	deltas bool
+	dag    bool
+	limit  int
+	store  CheckpointStore
 }
 
 func (n *PipelineFn) String() string {
@@ -89,9 +97,122 @@ func (n *PipelineFn) WithAfter(
 	return clone
 }
 
+// WithDeltaHistory records, for each step in the steps history, the JSON
+// Patch from the previous state instead of the full state. The initial state
+// is still recorded in full.
+func (n *PipelineFn) WithDeltaHistory() *PipelineFn {
+	clone := n.shallow()
+	clone.deltas = true
+
+	return clone
+}
+
+// WithParallel runs the steps as a DAG instead of one after another, at most
+// limit of them at a time, or with no limit if limit is less than one.
+//
+// A step depends on the earlier steps whose declared outputs overlap its
+// declared inputs, optionals or combined queries, and starts once they are all
+// done, seeing the data as it was given merged with the results of the steps
+// it transitively depends on, in the order of the plan. A step declaring no
+// inputs, or a step declaring no outputs, is taken to read, or write,
+// everything; so is a step with an input schema or with SkipOnExists. Steps
+// must not read or write past what they declare, lest their results differ
+// from a sequential run.
+//
+// Results are merged in the order of the plan, and so are the steps recorded
+// in the history, so that fin is what a sequential run would make. Probes may
+// be called concurrently. On failure, the steps not yet started are not run,
+// the running ones see their context cancelled, and the failure of the
+// earliest failing step in the plan is returned.
+func (n *PipelineFn) WithParallel(
+	limit int,
+) *PipelineFn {
+	clone := n.shallow()
+	clone.dag = true
+	clone.limit = limit
+
+	return clone
+}
+
+// WithoutParallel runs the steps one after another, which is the default.
+func (n *PipelineFn) WithoutParallel() *PipelineFn {
+	clone := n.shallow()
+	clone.dag = false
+	clone.limit = 0
+
+	return clone
+}
+
+// WithCheckpoints saves a checkpoint of the runs given an id, see EkranRun,
+// to store as they start and after every step done, so that they may be
+// resumed, see Resume. Checkpoints are kept once runs end, failed or not, for
+// the store to expire or for the caller to delete.
+//
+// When run in parallel, see WithParallel, the checkpoint is saved once the
+// steps are done, and covers the longest run of steps done from the start of
+// the plan; the steps done past a failure are run again on resume.
+//
+// Runs rolled back, see Fn.WithUndo, are resumed from the start, unless the
+// rollback failed.
+func (n *PipelineFn) WithCheckpoints(
+	store CheckpointStore,
+) *PipelineFn {
+	clone := n.shallow()
+	clone.store = store
+
+	return clone
+}
+
 func (n *PipelineFn) Ekran(
 	ctx gtx.Context,
 	dat giraffe.Datum,
 ) (giraffe.Datum, error) {
-	return n.ekran(ctx, dat)
+	return n.ekran(ctx, "", dat)
+}
+
+// EkranRun runs as Ekran, saving checkpoints of the run under runID, see
+// WithCheckpoints and ValidateRunID.
+func (n *PipelineFn) EkranRun(
+	ctx gtx.Context,
+	runID string,
+	dat giraffe.Datum,
+) (giraffe.Datum, error) {
+	if n.store == nil {
+		return dErr, EF("pipeline has no checkpoint store")
+	}
+
+	if err := ValidateRunID(runID); err != nil {
+		return dErr, err
+	}
+
+	return n.ekran(ctx, runID, dat)
+}
+
+// Resume continues the run runID from its last checkpoint, running the steps
+// not yet done against the data and steps history as they were then. Steps
+// with SkipOnExists whose outputs the data already has are skipped, as they
+// are in any run, so steps made idempotent this way are not executed again.
+// A run already done gives its result again.
+func (n *PipelineFn) Resume(
+	ctx gtx.Context,
+	runID string,
+) (giraffe.Datum, error) {
+	if n.store == nil {
+		return dErr, EF("pipeline has no checkpoint store")
+	}
+
+	c, err := n.store.Load(ctx, runID)
+	switch {
+	case err != nil:
+		return dErr, E(err, EF("cannot load checkpoint of run: %s", runID))
+
+	case c == nil:
+		return dErr, EF("no checkpoint of run: %s", runID)
+	}
+
+	if err = n.checkResumable(c); err != nil {
+		return dErr, err
+	}
+
+	return n.run(ctx, runID, c.Done, c.Dat, c.Steps)
 }
@@ -0,0 +1,327 @@
+package hippo
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	"github.com/hkoosha/giraffe/dialects"
+)
+
+// access is the paths a step reads or writes, each split to its segments, or
+// everything if unknown.
+type access struct {
+	paths   [][]string
+	unknown bool
+}
+
+func accessOf(
+	queries ...[]giraffe.Query,
+) access {
+	acc := access{paths: nil, unknown: false}
+
+	for _, qs := range queries {
+		for _, q := range qs {
+			pointer, err := q.ExportAs(dialects.JsonPointer)
+			if err != nil {
+				// Patterns, modifiers and the like may touch anything.
+				return access{paths: nil, unknown: true}
+			}
+
+			acc.paths = append(acc.paths, strings.Split(pointer, "/"))
+		}
+	}
+
+	return acc
+}
+
+func (a access) overlaps(
+	other access,
+) bool {
+	switch {
+	case !a.unknown && len(a.paths) == 0,
+		!other.unknown && len(other.paths) == 0:
+		return false
+
+	case a.unknown || other.unknown:
+		return true
+	}
+
+	for _, p := range a.paths {
+		for _, o := range other.paths {
+			n := min(len(p), len(o))
+			if slices.Equal(p[:n], o[:n]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (f *Fn) reads() access {
+	if f.skipWith != nil || f.skipped {
+		return access{paths: nil, unknown: false}
+	}
+
+	if len(f.inputs) == 0 || f.inSchema != nil || f.skipOnExists {
+		return access{paths: nil, unknown: true}
+	}
+
+	combined := make([]giraffe.Query, 0, len(f.combine))
+	for _, froms := range f.combine {
+		combined = append(combined, froms...)
+	}
+
+	return accessOf(f.inputs, f.optionals, combined)
+}
+
+func (f *Fn) writes() access {
+	switch {
+	case f.skipped:
+		return access{paths: nil, unknown: false}
+
+	case f.skipWith != nil, f.skipOnExists:
+		return access{paths: nil, unknown: true}
+
+	case f.scoped != nil:
+		return accessOf([]giraffe.Query{*f.scoped})
+
+	case len(f.selected) > 0:
+		return accessOf(f.selected)
+
+	case len(f.outputs) == 0:
+		return access{paths: nil, unknown: true}
+	}
+
+	copied := make([]giraffe.Query, 0, len(f.copy))
+	for _, into := range f.copy {
+		copied = append(copied, into)
+	}
+
+	return accessOf(f.outputs, copied)
+}
+
+// =====================================
+
+// dag is the steps of a plan, each with the earlier steps it directly depends
+// on, the ones it transitively depends on, and the ones depending on it.
+type dag struct {
+	deps       [][]int
+	ancestors  [][]int
+	dependents [][]int
+}
+
+func mkDag(
+	steps []namedStep,
+) dag {
+	g := dag{
+		deps:       make([][]int, len(steps)),
+		ancestors:  make([][]int, len(steps)),
+		dependents: make([][]int, len(steps)),
+	}
+
+	writes := make([]access, len(steps))
+	for i, s := range steps {
+		writes[i] = s.fn.writes()
+	}
+
+	for j, s := range steps {
+		reads := s.fn.reads()
+		anc := make([]bool, j)
+
+		for i := range j {
+			if !writes[i].overlaps(reads) {
+				continue
+			}
+
+			g.deps[j] = append(g.deps[j], i)
+			g.dependents[i] = append(g.dependents[i], j)
+
+			anc[i] = true
+			for _, a := range g.ancestors[i] {
+				anc[a] = true
+			}
+		}
+
+		for i, ok := range anc {
+			if ok {
+				g.ancestors[j] = append(g.ancestors[j], i)
+			}
+		}
+	}
+
+	return g
+}
+
+// =====================================
+
+func (n *PipelineFn) ekranDag(
+	ctx gtx.Context,
+	runID string,
+	from int,
+	dat giraffe.Datum,
+	hist giraffe.Datum,
+	undos []undoStep,
+) (giraffe.Datum, error) {
+	steps := n.plan.steps[from:]
+	g := mkDag(steps)
+
+	sCtxs := make([]StepContext, len(steps))
+	results := make([]giraffe.Datum, len(steps))
+	errs := make([]error, len(steps))
+	finished := make([]bool, len(steps))
+	pending := make([]int, len(steps))
+
+	for i, fn := range steps {
+		sCtxs[i] = StepContext{
+			stepNo:   from + i,
+			stepName: fn.name,
+			fn:       fn.fn,
+			dat:      dat,
+			arg:      fn.arg,
+		}
+		pending[i] = len(g.deps[i])
+	}
+
+	gCtx, group := ctx.Group()
+	if n.limit > 0 {
+		group.SetLimit(n.limit)
+	}
+
+	// Buffered, so that steps never block on it while Go blocks on the limit.
+	done := make(chan int, len(steps))
+	running := 0
+	failed := false
+
+	launch := func(i int) {
+		in, err := g.input(sCtxs, results, dat, i)
+		if err != nil {
+			errs[i] = err
+			failed = true
+
+			return
+		}
+
+		sCtxs[i].dat = in
+		running++
+
+		group.Go(func() error {
+			results[i], errs[i] = n.exe(gCtx, &sCtxs[i])
+			done <- i
+
+			return errs[i]
+		})
+	}
+
+	for i := range steps {
+		if pending[i] == 0 {
+			launch(i)
+		}
+	}
+
+	for running > 0 {
+		i := <-done
+		running--
+
+		if errs[i] != nil {
+			failed = true
+		} else {
+			finished[i] = true
+		}
+
+		if failed {
+			continue
+		}
+
+		for _, j := range g.dependents[i] {
+			if pending[j]--; pending[j] == 0 {
+				launch(j)
+			}
+		}
+	}
+
+	// Errors are taken from errs, in the order of the plan instead.
+	_ = group.Wait()
+
+	undoable := func(i int, dat giraffe.Datum) {
+		if undo := steps[i].fn.undo; undo != nil {
+			undos = append(undos, undoStep{
+				fn:   undo,
+				dat:  dat,
+				arg:  steps[i].arg,
+				name: steps[i].name,
+			})
+		}
+	}
+
+	// The steps done from the start on are kept, as a sequential run would.
+	kept := 0
+	for ; kept < len(steps) && finished[kept]; kept++ {
+		sCtx := sCtxs[kept].clone()
+		sCtx.dat = dat
+
+		next, nextHist, fErr := n.fold(sCtx, hist, results[kept])
+		if fErr != nil {
+			errs[kept] = fErr
+			finished[kept] = false
+
+			break
+		}
+
+		dat, hist = next, nextHist
+		undoable(kept, dat)
+
+		if kept == len(steps)-1 || !finished[kept+1] {
+			if err := n.checkpoint(ctx, runID, from+kept+1, dat, hist); err != nil {
+				return dErr, err
+			}
+		}
+	}
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		// The steps done past the ones kept are undone as well.
+		for j := kept; j < len(steps); j++ {
+			if !finished[j] {
+				continue
+			}
+
+			merged, mErr := sCtxs[j].dat.MergeWith(sCtxs[j].fn.merging, results[j])
+			if mErr != nil {
+				merged = sCtxs[j].dat
+			}
+
+			undoable(j, merged)
+		}
+
+		return dErr, n.fail(ctx, runID, &sCtxs[i], hist, undos, err)
+	}
+
+	return giraffe.Of(giraffe.Implode{
+		qFin:   dat,
+		qSteps: giraffe.Of(hist),
+	}), nil
+}
+
+// input is the data step i is given: dat merged with the results of the steps
+// it transitively depends on, in the order of the plan.
+func (g *dag) input(
+	sCtxs []StepContext,
+	results []giraffe.Datum,
+	dat giraffe.Datum,
+	i int,
+) (giraffe.Datum, error) {
+	for _, a := range g.ancestors[i] {
+		var err error
+		if dat, err = dat.MergeWith(sCtxs[a].fn.merging, results[a]); err != nil {
+			return dErr, err
+		}
+	}
+
+	return dat, nil
+}
@@ -1,6 +1,8 @@
 package hippo
 
 import (
+	"encoding/json"
+
 	"github.com/hkoosha/giraffe"
 	. "github.com/hkoosha/giraffe/core/t11y/dot"
 	"github.com/hkoosha/giraffe/core/t11y/gtx"
@@ -12,14 +14,22 @@ var (
 	qSteps = giraffe.Q("steps")
 	qName  = giraffe.Q("name")
 	qState = giraffe.Q("state")
+	qPatch = giraffe.Q("patch")
+
+	qRollback = giraffe.Q("rollback")
+	qError    = giraffe.Q("error")
 
 	stepInit = "init"
 
+	rollbackDone   = "done"
+	rollbackFailed = "failed"
+
 	dErr = giraffe.OfErr()
 )
 
 func (n *PipelineFn) ekran(
 	ctx gtx.Context,
+	runID string,
 	dat giraffe.Datum,
 ) (giraffe.Datum, error) {
 	hist, hErr := history(dat)
@@ -27,7 +37,33 @@ func (n *PipelineFn) ekran(
 		return dErr, hErr
 	}
 
-	for i, fn := range n.plan.steps {
+	if err := n.checkpoint(ctx, runID, 0, dat, hist); err != nil {
+		return dErr, err
+	}
+
+	return n.run(ctx, runID, 0, dat, hist)
+}
+
+// run runs the steps of the plan from the step from on, given the data and
+// the steps history as they are once the steps before it are done.
+func (n *PipelineFn) run(
+	ctx gtx.Context,
+	runID string,
+	from int,
+	dat giraffe.Datum,
+	hist giraffe.Datum,
+) (giraffe.Datum, error) {
+	undos, uErr := n.undone(from, dat, hist)
+	if uErr != nil {
+		return dErr, uErr
+	}
+
+	if n.dag {
+		return n.ekranDag(ctx, runID, from, dat, hist, undos)
+	}
+
+	for i := from; i < len(n.plan.steps); i++ {
+		fn := n.plan.steps[i]
 		sCtx := StepContext{
 			stepNo:   i,
 			stepName: fn.name,
@@ -38,21 +74,26 @@ func (n *PipelineFn) ekran(
 
 		next, eErr := n.exe(ctx, &sCtx)
 		if eErr != nil {
-			return dErr, onFnErr(&sCtx, hist, eErr)
+			return dErr, n.fail(ctx, runID, &sCtx, hist, undos, eErr)
+		}
+
+		var fErr error
+		if dat, hist, fErr = n.fold(&sCtx, hist, next); fErr != nil {
+			return dErr, n.fail(ctx, runID, &sCtx, hist, undos, fErr)
 		}
 
-		merged, mErr := dat.Merge(next)
-		if mErr != nil {
-			return dErr, onFnErr(&sCtx, hist, mErr)
+		if fn.fn.undo != nil {
+			undos = append(undos, undoStep{
+				name: fn.name,
+				fn:   fn.fn.undo,
+				dat:  dat,
+				arg:  fn.arg,
+			})
 		}
 
-		dat = merged
-		hist = M(hist.Append(
-			M(giraffe.OfN(
-				giraffe.TupleOf(qName, sCtx.stepName),
-				giraffe.TupleOf(qState, dat),
-			)),
-		))
+		if err := n.checkpoint(ctx, runID, i+1, dat, hist); err != nil {
+			return dErr, err
+		}
 	}
 
 	return giraffe.Of(giraffe.Implode{
@@ -61,6 +102,59 @@ func (n *PipelineFn) ekran(
 	}), nil
 }
 
+// fold merges next, the result of the step, into the data the step was given,
+// recording the step in hist.
+func (n *PipelineFn) fold(
+	sCtx *StepContext,
+	hist giraffe.Datum,
+	next giraffe.Datum,
+) (giraffe.Datum, giraffe.Datum, error) {
+	merged, mErr := sCtx.dat.MergeWith(sCtx.fn.merging, next)
+	if mErr != nil {
+		return dErr, dErr, mErr
+	}
+
+	step, sErr := n.step(sCtx.stepName, sCtx.dat, merged)
+	if sErr != nil {
+		return dErr, dErr, sErr
+	}
+
+	return merged, M(hist.Append(step)), nil
+}
+
+func (n *PipelineFn) step(
+	name string,
+	prev giraffe.Datum,
+	next giraffe.Datum,
+) (giraffe.Datum, error) {
+	if !n.deltas {
+		return giraffe.OfN(
+			giraffe.TupleOf(qName, name),
+			giraffe.TupleOf(qState, next),
+		)
+	}
+
+	patch, err := prev.PatchTo(next)
+	if err != nil {
+		return dErr, err
+	}
+
+	encoded, err := json.Marshal(patch)
+	if err != nil {
+		return dErr, E(err)
+	}
+
+	pDat, err := giraffe.DatumSerde().Read(encoded)
+	if err != nil {
+		return dErr, err
+	}
+
+	return giraffe.OfN(
+		giraffe.TupleOf(qName, name),
+		giraffe.TupleOf(qPatch, pDat),
+	)
+}
+
 func (n *PipelineFn) exe(
 	ctx gtx.Context,
 	sCtx *StepContext,
@@ -93,7 +187,157 @@ func (n *PipelineFn) shallow() *PipelineFn {
 		plan:   n.plan,
 		before: n.before,
 		after:  n.after,
+		deltas: n.deltas,
+		dag:    n.dag,
+		limit:  n.limit,
+		store:  n.store,
+	}
+}
+
+// undoStep is a step done, to undo on failure with fn, given the data of the
+// step merged with its result.
+type undoStep struct {
+	fn   *Fn
+	dat  giraffe.Datum
+	arg  *giraffe.Datum
+	name string
+}
+
+// undone is the steps before the step from to undo on failure, given the data
+// and the steps history as they are once these steps are done, as when a run
+// is resumed off its checkpoint. The data each step is undone with is taken
+// off its history.
+func (n *PipelineFn) undone(
+	from int,
+	dat giraffe.Datum,
+	hist giraffe.Datum,
+) ([]undoStep, error) {
+	var undos []undoStep
+
+	state := dErr
+	for i := range from + 1 {
+		step, err := hist.At(i)
+		if err != nil {
+			return nil, E(err, EF("invalid steps history at: %d", i))
+		}
+
+		if state, err = n.stateOf(state, step); err != nil {
+			return nil, E(err, EF("invalid steps history at: %d", i))
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		fn := n.plan.steps[i-1]
+		if fn.fn.undo == nil {
+			continue
+		}
+
+		if i == from {
+			state = dat
+		}
+
+		undos = append(undos, undoStep{
+			name: fn.name,
+			fn:   fn.fn.undo,
+			dat:  state,
+			arg:  fn.arg,
+		})
+	}
+
+	return undos, nil
+}
+
+// stateOf is the data once step, an entry of the steps history, is done,
+// given prev, the data before it.
+func (n *PipelineFn) stateOf(
+	prev giraffe.Datum,
+	step giraffe.Datum,
+) (giraffe.Datum, error) {
+	ok, err := step.Has(qState)
+	switch {
+	case err != nil:
+		return dErr, err
+
+	case ok:
+		return step.Get(qState)
+	}
+
+	pDat, err := step.Get(qPatch)
+	if err != nil {
+		return dErr, err
+	}
+
+	encoded, err := pDat.MarshalJSON()
+	if err != nil {
+		return dErr, err
+	}
+
+	var patch giraffe.JsonPatch
+	if err = json.Unmarshal(encoded, &patch); err != nil {
+		return dErr, E(err)
+	}
+
+	return prev.Patched(patch)
+}
+
+// fail rolls back the steps done, see Compensator.rollback, and makes the
+// error of the step failed with err, the rollback recorded in its history.
+// Once rolled back, the checkpoint of the run, if any, is reset to its start;
+// it is kept as it is if the rollback fails, so that the run may be resumed
+// instead.
+func (n *PipelineFn) fail(
+	ctx gtx.Context,
+	runID string,
+	sCtx *StepContext,
+	hist giraffe.Datum,
+	done []undoStep,
+	err error,
+) error {
+	if len(done) == 0 {
+		return onFnErr(sCtx, hist, err)
+	}
+
+	rolled, rErr := n.plan.compensator.rollback(ctx, hist, done)
+	if rErr != nil {
+		return E(onFnErr(sCtx, rolled, err), E(rErr, EF("rollback failed")))
+	}
+
+	if cErr := n.restart(ctx, runID, hist); cErr != nil {
+		return E(onFnErr(sCtx, rolled, err), cErr)
+	}
+
+	return onFnErr(sCtx, rolled, err)
+}
+
+// restart resets the checkpoint of the run, if any, to its start, the data
+// given being the first in hist.
+func (n *PipelineFn) restart(
+	ctx gtx.Context,
+	runID string,
+	hist giraffe.Datum,
+) error {
+	if runID == "" {
+		return nil
 	}
+
+	ini, err := hist.At(0)
+	if err != nil {
+		return err
+	}
+
+	iniDat, err := ini.Get(qState)
+	if err != nil {
+		return err
+	}
+
+	iniHist, err := history(iniDat)
+	if err != nil {
+		return err
+	}
+
+	return n.checkpoint(ctx, runID, 0, iniDat, iniHist)
 }
 
 func onFnErr(
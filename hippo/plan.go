@@ -101,17 +101,12 @@ func (p *Plan) WithSteps(
 			return nil, E(err...)
 		}
 
-		fn, err := p.registry.Named(f.Fn)
+		fn, err := f.Make(p.registry)
 		if err != nil {
 			return nil, err
 		}
 
-		fn, err = f.Configure(fn)
-		if err != nil {
-			return nil, err
-		}
-
-		stepName := fmt.Sprintf("%s#%04d", f.Fn, i)
+		stepName := fmt.Sprintf("%s#%04d", f.Name(), i)
 		cp.steps = z.Appended(cp.steps, newNamedStep(stepName, fn, Clone(f.Args)))
 	}
 
@@ -46,6 +46,50 @@ func (c Compensator) compensate(
 	return dErr, false
 }
 
+// rollback runs, in reverse, the undo of the steps done, see Fn.WithUndo, all
+// of them even if some fail, recording each in hist. It fails with the errors
+// of all the undos failed.
+func (c Compensator) rollback(
+	ctx gtx.Context,
+	hist giraffe.Datum,
+	done []undoStep,
+) (giraffe.Datum, error) {
+	var errs []error
+
+	for i := len(done) - 1; i >= 0; i-- {
+		u := done[i]
+
+		outcome := []giraffe.Tuple{
+			giraffe.TupleOf(qName, u.name),
+			giraffe.TupleOf(qRollback, rollbackDone),
+		}
+
+		if _, err := u.fn.call(ctx, mkCall(u.name, u.dat, u.arg)); err != nil {
+			errs = append(errs, E(err, EF("cannot roll back step: %s", u.name)))
+			outcome = []giraffe.Tuple{
+				giraffe.TupleOf(qName, u.name),
+				giraffe.TupleOf(qRollback, rollbackFailed),
+				giraffe.TupleOf(qError, err.Error()),
+			}
+		}
+
+		step, err := giraffe.OfN(outcome...)
+		if err != nil {
+			return hist, E(append(errs, err)...)
+		}
+
+		if hist, err = hist.Append(step); err != nil {
+			return hist, E(append(errs, err)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return hist, E(errs...)
+	}
+
+	return hist, nil
+}
+
 func (c Compensator) For(
 	msg *regexp.Regexp,
 	name *regexp.Regexp,
@@ -53,8 +97,8 @@ func (c Compensator) For(
 	with *Fn,
 ) Compensator {
 	c.comp = z.Appended(c.comp, compCondition{
-		onErr:  &*msg,
-		onName: &*name,
+		onErr:  msg,
+		onName: name,
 		onStep: step,
 		fn:     with,
 	})
@@ -76,7 +120,7 @@ func (c Compensator) ForError(
 	with *Fn,
 ) Compensator {
 	c.comp = z.Appended(c.comp, compCondition{
-		onErr:  &*msg,
+		onErr:  msg,
 		onName: nil,
 		onStep: -1,
 		fn:     with,
@@ -128,7 +172,7 @@ func (c Compensator) ForNamed(
 
 			cp = append(cp, compCondition{
 				onErr:  nil,
-				onName: &*name,
+				onName: name,
 				onStep: step,
 				fn:     with,
 			})
@@ -142,7 +186,7 @@ func (c Compensator) ForNamed(
 	return Compensator{
 		comp: z.Appended(c.comp, compCondition{
 			onErr:  nil,
-			onName: &*name,
+			onName: name,
 			onStep: -1,
 			fn:     with,
 		}),
@@ -156,3 +200,88 @@ func (c Compensator) ForNamedWith(
 ) Compensator {
 	return c.ForNamed(name, Static(with), steps...)
 }
+
+// =====================================
+
+// CompensationConfig configures a compensation of a Compensator, see
+// Compensator.For. The regexps and step are optional, and it compensates with
+// the fn named WithFn, if set, or the static value of With otherwise.
+type CompensationConfig struct {
+	With     any     `json:"with"                 yaml:"with"`
+	OnErrRe  *string `json:"on_err_re,omitempty"  yaml:"on_err_re,omitempty"`
+	OnNameRe *string `json:"on_name_re,omitempty" yaml:"on_name_re,omitempty"`
+	OnStep   *int    `json:"on_step,omitempty"    yaml:"on_step,omitempty"`
+	WithFn   string  `json:"with_fn"              yaml:"with_fn"`
+}
+
+func (c *CompensationConfig) Validate() []error {
+	var errs []error
+
+	for _, re := range []*string{c.OnErrRe, c.OnNameRe} {
+		if re == nil {
+			continue
+		}
+
+		if _, err := regexp.Compile(*re); err != nil {
+			errs = append(errs, E(err))
+		}
+	}
+
+	if c.OnStep != nil && *c.OnStep < 0 {
+		errs = append(errs, EF("invalid step: %d", *c.OnStep))
+	}
+
+	if c.WithFn == "" {
+		if _, err := giraffe.From(c.With); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// MkCompensator makes a compensator of cfgs, in order, their fns named in r.
+func (r *FnRegistry) MkCompensator(
+	cfgs ...CompensationConfig,
+) (Compensator, error) {
+	c := Compensator{
+		comp: make([]compCondition, 0, len(cfgs)),
+	}
+
+	for _, cfg := range cfgs {
+		if errs := cfg.Validate(); len(errs) > 0 {
+			return Compensator{}, E(errs...)
+		}
+
+		var msg *regexp.Regexp
+		if cfg.OnErrRe != nil {
+			msg = regexp.MustCompile(*cfg.OnErrRe)
+		}
+
+		var name *regexp.Regexp
+		if cfg.OnNameRe != nil {
+			name = regexp.MustCompile(*cfg.OnNameRe)
+		}
+
+		step := -1
+		if cfg.OnStep != nil {
+			step = *cfg.OnStep
+		}
+
+		var with *Fn
+		if cfg.WithFn != "" {
+			fn, err := r.Named(cfg.WithFn)
+			if err != nil {
+				return Compensator{}, err
+			}
+
+			with = fn
+		} else {
+			with = Static(M(giraffe.From(cfg.With)))
+		}
+
+		c = c.For(msg, name, step, with)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,127 @@
+package hippo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/conn"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// PlanDoc is a plan declared as a document, see LoadPlan.
+//
+// The steps are configured as in Plan.WithSteps, every option they leave unset
+// taken from Defaults, if any, those of the steps of control flows included.
+// Statics are registered, by name, as StaticTemplate of their value, and
+// Tunnels as DatumTunnels, one fn per http method, as in "GET". Compensations
+// make the compensator of the plan, see FnRegistry.MkCompensator.
+//
+//nolint:lll
+type PlanDoc struct {
+	Defaults      *FnConfig                `json:"defaults,omitempty"      yaml:"defaults,omitempty"`
+	Statics       map[string]giraffe.Datum `json:"statics,omitempty"       yaml:"statics,omitempty"`
+	Tunnels       *TunnelsConfig           `json:"tunnels,omitempty"       yaml:"tunnels,omitempty"`
+	Name          string                   `json:"name"                    yaml:"name"`
+	Compensations []CompensationConfig     `json:"compensations,omitempty" yaml:"compensations,omitempty"`
+	Steps         []FnConfig               `json:"steps"                   yaml:"steps"`
+}
+
+// TunnelsConfig configures DatumTunnels, each endpoint by the name steps give
+// as their channel.
+type TunnelsConfig struct {
+	Headers   map[string]string         `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Endpoints map[string]EndpointConfig `json:"endpoints"         yaml:"endpoints"`
+}
+
+// EndpointConfig is an endpoint of DatumTunnels, and the http methods it is
+// called with, as in "POST".
+type EndpointConfig struct {
+	Endpoint string   `json:"endpoint" yaml:"endpoint"`
+	Methods  []string `json:"methods"  yaml:"methods"`
+}
+
+// LoadPlan reads a PlanDoc, either YAML or JSON, and validates it, failing
+// with every error found, unknown fields included.
+func LoadPlan(
+	r io.Reader,
+) (*PlanDoc, error) {
+	dat, err := giraffe.DatumYamlSerde().StreamFrom(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := dat.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	var doc PlanDoc
+	if err = dec.Decode(&doc); err != nil {
+		return nil, E(err)
+	}
+
+	if errs := doc.Validate(); len(errs) > 0 {
+		return nil, E(errs...)
+	}
+
+	return &doc, nil
+}
+
+func (d *PlanDoc) Validate() []error {
+	var errs []error
+
+	errs = append(errs, d.validateShape()...)
+	errs = append(errs, d.validateRegistry()...)
+
+	for i, c := range d.Compensations {
+		for _, err := range c.Validate() {
+			errs = append(errs, E(err, EF("invalid compensation: %d", i)))
+		}
+	}
+
+	for i, s := range d.steps() {
+		for _, err := range s.Validate() {
+			errs = append(errs, E(err, EF("invalid step: %d", i)))
+		}
+	}
+
+	return errs
+}
+
+// Plan makes the plan of the document off reg, with the statics and tunnels
+// of the document registered as well. The tunnels call cnx, which may be nil
+// if the document has none.
+func (d *PlanDoc) Plan(
+	reg *FnRegistry,
+	cnx conn.Datum,
+) (*Plan, error) {
+	if errs := d.Validate(); len(errs) > 0 {
+		return nil, E(errs...)
+	}
+
+	reg, err := d.registry(reg, cnx)
+	if err != nil {
+		return nil, err
+	}
+
+	comp, err := reg.MkCompensator(d.Compensations...)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := MkPlan().AndRegistry(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan, err = plan.WithSteps(d.steps()...); err != nil {
+		return nil, err
+	}
+
+	return plan.WithCompensator(comp), nil
+}
@@ -0,0 +1,203 @@
+package hippo
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/hkoosha/giraffe"
+	"github.com/hkoosha/giraffe/conn"
+	"github.com/hkoosha/giraffe/conn/httpmethod"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/hippo/internal"
+)
+
+func (d *PlanDoc) validateShape() []error {
+	var errs []error
+
+	if !internal.SimpleName.MatchString(d.Name) {
+		errs = append(errs, EF("invalid plan name: %s", d.Name))
+	}
+
+	if len(d.Steps) == 0 {
+		errs = append(errs, EF("plan has no steps: %s", d.Name))
+	}
+
+	if d.Defaults != nil && (d.Defaults.Fn != "" || d.Defaults.flows() > 0) {
+		errs = append(errs, EF("defaults must not have an fn or a control flow"))
+	}
+
+	return errs
+}
+
+func (d *PlanDoc) validateRegistry() []error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(d.Statics)) {
+		if !internal.SimpleName.MatchString(name) {
+			errs = append(errs, EF("invalid static name: %s", name))
+		}
+
+		if _, err := giraffe.ParseDocTemplate(d.Statics[name]); err != nil {
+			errs = append(errs, E(err, EF("invalid static: %s", name)))
+		}
+	}
+
+	if d.Tunnels == nil {
+		return errs
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.Tunnels.Endpoints)) {
+		ep := d.Tunnels.Endpoints[name]
+
+		if err := validateEndpoint(ep.Endpoint); err != nil {
+			errs = append(errs, E(err, EF("invalid tunnel: %s", name)))
+		}
+
+		if len(ep.Methods) == 0 {
+			errs = append(errs, EF("tunnel has no methods: %s", name))
+		}
+
+		for _, m := range ep.Methods {
+			if _, ok := httpmethod.Of(m); !ok {
+				errs = append(errs, EF("unsupported http method of tunnel %s: %s", name, m))
+			}
+		}
+	}
+
+	return errs
+}
+
+// registry is reg with the statics and tunnels of the document registered.
+func (d *PlanDoc) registry(
+	reg *FnRegistry,
+	cnx conn.Datum,
+) (*FnRegistry, error) {
+	for _, name := range slices.Sorted(maps.Keys(d.Statics)) {
+		fn, err := StaticTemplate(d.Statics[name])
+		if err != nil {
+			return nil, err
+		}
+
+		if reg, err = reg.WithNamed(name, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.Tunnels == nil {
+		return reg, nil
+	}
+
+	if cnx == nil {
+		return nil, EF("plan has tunnels but no connection: %s", d.Name)
+	}
+
+	tunnels := MkTunnels(cnx).WithEnforcedHeaders(d.Tunnels.Headers)
+	for name, ep := range d.Tunnels.Endpoints {
+		for _, m := range ep.Methods {
+			method, _ := httpmethod.Of(m)
+
+			var err error
+			if tunnels, err = tunnels.with(name, ep.Endpoint, method); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return tunnels.RegisterTo(reg)
+}
+
+// steps is the steps of the document, with the defaults applied.
+func (d *PlanDoc) steps() []FnConfig {
+	if d.Defaults == nil {
+		return d.Steps
+	}
+
+	return withDefaults(d.Steps, d.Defaults)
+}
+
+// =====================================
+
+func withDefaults(
+	steps []FnConfig,
+	def *FnConfig,
+) []FnConfig {
+	if steps == nil {
+		return nil
+	}
+
+	applied := make([]FnConfig, len(steps))
+	for i, s := range steps {
+		applied[i] = s.withDefaults(def)
+	}
+
+	return applied
+}
+
+func orDefault[T any](
+	v *T,
+	def *T,
+) *T {
+	if v != nil {
+		return v
+	}
+
+	return def
+}
+
+// withDefaults is f with the options it leaves unset taken from def, or for a
+// control flow, with the defaults applied to the steps of its plans instead.
+func (f FnConfig) withDefaults(
+	def *FnConfig,
+) FnConfig {
+	switch {
+	case f.If != nil:
+		cp := *f.If
+		cp.Then = withDefaults(cp.Then, def)
+		cp.Else = withDefaults(cp.Else, def)
+		f.If = &cp
+
+	case f.Switch != nil:
+		cp := *f.Switch
+		cp.Cases = make(map[string][]FnConfig, len(f.Switch.Cases))
+		for k, steps := range f.Switch.Cases {
+			cp.Cases[k] = withDefaults(steps, def)
+		}
+		cp.Default = withDefaults(cp.Default, def)
+		f.Switch = &cp
+
+	case f.ForEach != nil:
+		cp := *f.ForEach
+		cp.Do = withDefaults(cp.Do, def)
+		f.ForEach = &cp
+
+	case f.While != nil:
+		cp := *f.While
+		cp.Do = withDefaults(cp.Do, def)
+		f.While = &cp
+
+	case f.Until != nil:
+		cp := *f.Until
+		cp.Do = withDefaults(cp.Do, def)
+		f.Until = &cp
+
+	default:
+		f.Combine = orDefault(f.Combine, def.Combine)
+		f.Gather = orDefault(f.Gather, def.Gather)
+		f.Copy = orDefault(f.Copy, def.Copy)
+		f.Require = orDefault(f.Require, def.Require)
+		f.Select = orDefault(f.Select, def.Select)
+		f.Scoped = orDefault(f.Scoped, def.Scoped)
+		f.Args = orDefault(f.Args, def.Args)
+		f.SkipOnExists = orDefault(f.SkipOnExists, def.SkipOnExists)
+		f.Skipped = orDefault(f.Skipped, def.Skipped)
+		f.SkippedWith = orDefault(f.SkippedWith, def.SkippedWith)
+		f.NoSkipWith = orDefault(f.NoSkipWith, def.NoSkipWith)
+		f.MergePolicy = orDefault(f.MergePolicy, def.MergePolicy)
+		f.MergeArr = orDefault(f.MergeArr, def.MergeArr)
+		f.MergeKey = orDefault(f.MergeKey, def.MergeKey)
+		f.InputSchema = orDefault(f.InputSchema, def.InputSchema)
+		f.OutputSchema = orDefault(f.OutputSchema, def.OutputSchema)
+	}
+
+	return f
+}
@@ -336,12 +336,7 @@ func (r *FnRegistry) MkFns(
 			return nil, E(err...)
 		}
 
-		fn, err := r.Named(s.Fn)
-		if err != nil {
-			return nil, err
-		}
-
-		fn, err = s.Configure(fn)
+		fn, err := s.Make(r)
 		if err != nil {
 			return nil, err
 		}
@@ -40,7 +40,7 @@ func (m *remoteFn) Ekran(
 	ctx gtx.Context,
 	call hippo.Call,
 ) (giraffe.Datum, error) {
-	_, rx, err := m.cnx.HCall(ctx, &Request{
+	_, _, rx, err := m.cnx.HCall(ctx, &Request{
 		Init:          call.Data(),
 		Plan:          m.plan,
 		Compensations: nil,
@@ -0,0 +1,269 @@
+package remote
+
+import (
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hkoosha/giraffe/conn"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/core/t11y/gtx"
+	"github.com/hkoosha/giraffe/hippo"
+	"github.com/hkoosha/giraffe/hippo/internal"
+)
+
+// Plans is the plans a server runs, by name, swapped all at once and safe to
+// use concurrently. The zero value has no plans.
+type Plans struct {
+	plans atomic.Pointer[map[string]*hippo.Plan]
+}
+
+func NewPlans(
+	templates map[string]*hippo.Plan,
+) (*Plans, error) {
+	p := &Plans{}
+	if err := p.Swap(templates); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Plans) Get(
+	name string,
+) (*hippo.Plan, bool) {
+	plan, ok := p.load()[name]
+	return plan, ok
+}
+
+func (p *Plans) Names() []string {
+	return slices.Sorted(maps.Keys(p.load()))
+}
+
+func (p *Plans) load() map[string]*hippo.Plan {
+	if plans := p.plans.Load(); plans != nil {
+		return *plans
+	}
+
+	return nil
+}
+
+// Swap replaces every plan with templates, or none if any is invalid.
+func (p *Plans) Swap(
+	templates map[string]*hippo.Plan,
+) error {
+	for name, plan := range templates {
+		if !internal.SimpleName.MatchString(name) {
+			return EF("invalid plan name: %s", name)
+		}
+
+		if plan == nil {
+			return EF("nil plan: %s", name)
+		}
+	}
+
+	cp := maps.Clone(templates)
+	if cp == nil {
+		cp = make(map[string]*hippo.Plan)
+	}
+
+	p.plans.Store(&cp)
+
+	return nil
+}
+
+// =============================================================================.
+
+// PlanFiles is the plan documents, see hippo.LoadPlan, of the .yaml, .yml and
+// .json files at the root of a file system, made off a registry.
+type PlanFiles struct {
+	fsys fs.FS
+	reg  *hippo.FnRegistry
+	cnx  conn.Datum
+}
+
+func MkPlanFiles(
+	fsys fs.FS,
+	reg *hippo.FnRegistry,
+) *PlanFiles {
+	return &PlanFiles{
+		fsys: fsys,
+		reg:  reg,
+		cnx:  nil,
+	}
+}
+
+// WithConn sets the connection the tunnels of the plans call.
+func (f *PlanFiles) WithConn(
+	cnx conn.Datum,
+) *PlanFiles {
+	cp := *f
+	cp.cnx = cnx
+	return &cp
+}
+
+// Load makes the plans of every file, by the name they declare, failing with
+// the errors of all the files, if any.
+func (f *PlanFiles) Load() (map[string]*hippo.Plan, error) {
+	files, err := f.files()
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make(map[string]*hippo.Plan, len(files))
+	var errs []error
+
+	for _, file := range files {
+		name, plan, lErr := f.load(file)
+		switch {
+		case lErr != nil:
+			errs = append(errs, E(lErr, EF("invalid plan file: %s", file)))
+
+		case plans[name] != nil:
+			errs = append(errs, EF("duplicate plan: %s, in: %s", name, file))
+
+		default:
+			plans[name] = plan
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, E(errs...)
+	}
+
+	return plans, nil
+}
+
+// Reload loads the plans, swapping them into plans only if all load.
+func (f *PlanFiles) Reload(
+	plans *Plans,
+) error {
+	loaded, err := f.Load()
+	if err != nil {
+		return err
+	}
+
+	return plans.Swap(loaded)
+}
+
+// Watch reloads the plans right away, and again whenever the files change,
+// checking every so often until ctx is done. Failed reloads keep the plans as
+// they were, and are given to onErr, if not nil, once per change.
+func (f *PlanFiles) Watch(
+	ctx gtx.Context,
+	plans *Plans,
+	every time.Duration,
+	onErr func(error),
+) {
+	report := func(err error) {
+		if err != nil && onErr != nil {
+			onErr(err)
+		}
+	}
+
+	last, err := f.stamp()
+	if err != nil {
+		report(err)
+	} else {
+		report(f.Reload(plans))
+	}
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+
+		stamp, sErr := f.stamp()
+		if sErr != nil {
+			report(sErr)
+			continue
+		}
+
+		if stamp == last {
+			continue
+		}
+
+		last = stamp
+		report(f.Reload(plans))
+	}
+}
+
+func (f *PlanFiles) files() ([]string, error) {
+	entries, err := fs.ReadDir(f.fsys, ".")
+	if err != nil {
+		return nil, E(err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		switch path.Ext(e.Name()) {
+		case ".yaml", ".yml", ".json":
+			if !e.IsDir() {
+				files = append(files, e.Name())
+			}
+		}
+	}
+
+	return files, nil
+}
+
+func (f *PlanFiles) load(
+	file string,
+) (string, *hippo.Plan, error) {
+	r, err := f.fsys.Open(file)
+	if err != nil {
+		return "", nil, E(err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	doc, err := hippo.LoadPlan(r)
+	if err != nil {
+		return "", nil, err
+	}
+
+	plan, err := doc.Plan(f.reg, f.cnx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return doc.Name, plan, nil
+}
+
+// stamp is the name, size and modification time of every file, changing when
+// any of them does.
+func (f *PlanFiles) stamp() (string, error) {
+	files, err := f.files()
+	if err != nil {
+		return "", err
+	}
+
+	sb := strings.Builder{}
+	for _, file := range files {
+		info, sErr := fs.Stat(f.fsys, file)
+		if sErr != nil {
+			return "", E(sErr)
+		}
+
+		sb.WriteString(file)
+		sb.WriteString(":")
+		sb.WriteString(strconv.FormatInt(info.Size(), 10))
+		sb.WriteString(":")
+		sb.WriteString(strconv.FormatInt(info.ModTime().UnixNano(), 10))
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
@@ -3,15 +3,10 @@ package remote
 import (
 	"github.com/hkoosha/giraffe"
 	"github.com/hkoosha/giraffe/core/serdes"
+	"github.com/hkoosha/giraffe/hippo"
 )
 
-type requestCompensations struct {
-	With     any     `json:"with"                 yaml:"with"`
-	OnErrRe  *string `json:"on_err_re,omitempty"  yaml:"on_err_re,omitempty"`
-	OnNameRe *string `json:"on_name_re,omitempty" yaml:"on_name_re,omitempty"`
-	OnStep   *int    `json:"on_step,omitempty"    yaml:"on_step,omitempty"`
-	WithFn   string  `json:"with_fn"              yaml:"with_fn"`
-}
+type requestCompensations = hippo.CompensationConfig
 
 type Request struct {
 	Compensations *[]requestCompensations `json:"compensations,omitempty"`
@@ -2,17 +2,13 @@ package remote
 
 import (
 	"io"
-	"maps"
 	"net/http"
-	"regexp"
 
 	"github.com/hkoosha/giraffe"
 	"github.com/hkoosha/giraffe/core/serdes"
 	"github.com/hkoosha/giraffe/core/t11y"
-	. "github.com/hkoosha/giraffe/core/t11y/dot"
 	"github.com/hkoosha/giraffe/core/t11y/gtx"
 	"github.com/hkoosha/giraffe/hippo"
-	"github.com/hkoosha/giraffe/hippo/internal"
 )
 
 type Server func(
@@ -39,9 +35,9 @@ func (s Server) ServeHTTP(
 }
 
 type server struct {
-	reg       *hippo.FnRegistry
-	templates map[string]*hippo.Plan
-	serde     serdes.Serde[Request]
+	reg   *hippo.FnRegistry
+	plans *Plans
+	serde serdes.Serde[Request]
 }
 
 func (s server) ekran(
@@ -59,51 +55,25 @@ func (s server) ekran(
 		return newErrorParsingPayload(err)
 	}
 
-	var compensator hippo.Compensator
 	if req.Compensations == nil {
 		req.Compensations = &[]requestCompensations{}
 	}
 	for _, comp := range *req.Compensations {
-		var msgRe *regexp.Regexp
-		if comp.OnErrRe != nil {
-			msgRe, err = regexp.Compile(*comp.OnErrRe)
-			if err != nil {
-				return newErrorParsingPayload(err)
-			}
+		if comp.WithFn == "" {
+			continue
 		}
 
-		var nameRe *regexp.Regexp
-		if comp.OnNameRe != nil {
-			nameRe, err = regexp.Compile(*comp.OnNameRe)
-			if err != nil {
-				return newErrorParsingPayload(err)
-			}
-		}
-
-		step := -1
-		if comp.OnStep != nil {
-			step = *comp.OnStep
-		}
-
-		var with *hippo.Fn
-		//nolint:nestif
-		if comp.WithFn != "" {
-			if with, err = s.reg.Named(comp.WithFn); err != nil {
-				return newErrorMissingFn(comp.WithFn)
-			}
-		} else {
-			withDatum, mkErr := giraffe.From(comp.With)
-			if mkErr != nil {
-				return newErrorParsingPayload(mkErr)
-			}
-			with = hippo.Static(withDatum)
+		if _, err = s.reg.Named(comp.WithFn); err != nil {
+			return newErrorMissingFn(comp.WithFn)
 		}
+	}
 
-		// TODO msgRe & nameRe can be nil?
-		compensator = compensator.For(msgRe, nameRe, step, with)
+	compensator, err := s.reg.MkCompensator(*req.Compensations...)
+	if err != nil {
+		return newErrorParsingPayload(err)
 	}
 
-	plan, ok := s.templates[req.Plan]
+	plan, ok := s.plans.Get(req.Plan)
 	if !ok {
 		return newErrorMissingPlan(req.Plan)
 	}
@@ -135,19 +105,24 @@ func NewServer(
 	reg *hippo.FnRegistry,
 	templates map[string]*hippo.Plan,
 ) (Server, error) {
-	for name, plan := range templates {
-		if !internal.SimpleName.MatchString(name) {
-			return nil, EF("invalid plan name: %s", name)
-		}
-
-		if plan == nil {
-			return nil, EF("nil plan: %s", name)
-		}
+	plans, err := NewPlans(templates)
+	if err != nil {
+		return nil, err
 	}
 
+	return NewReloadingServer(reg, plans), nil
+}
+
+// NewReloadingServer serves the plans as they are at each request, so that
+// swapping them, see Plans.Swap and PlanFiles.Watch, takes effect with no
+// restart.
+func NewReloadingServer(
+	reg *hippo.FnRegistry,
+	plans *Plans,
+) Server {
 	return server{
-		reg:       reg,
-		templates: maps.Clone(templates),
-		serde:     RequestSerde(),
-	}.ekran, nil
+		reg:   reg,
+		plans: plans,
+		serde: RequestSerde(),
+	}.ekran
 }
@@ -5,7 +5,10 @@ import (
 
 	"github.com/hkoosha/giraffe/core/inmem"
 	"github.com/hkoosha/giraffe/dialects"
+	"github.com/hkoosha/giraffe/internal/queryimpl"
 	"github.com/hkoosha/giraffe/internal/queryimpl/gquery"
+	"github.com/hkoosha/giraffe/internal/queryimpl/jsonpath"
+	"github.com/hkoosha/giraffe/internal/queryimpl/jsonpointer"
 )
 
 var cache = inmem.Make[gquery.GiraffeQuery](
@@ -25,6 +28,12 @@ func parse(
 	case dialects.Giraffe1v1:
 		return gquery.Parse(spec)
 
+	case dialects.JsonPointer:
+		return jsonpointer.Parse(spec)
+
+	case dialects.JsonPath:
+		return jsonpath.Parse(spec)
+
 	case dialects.Unknown:
 		return gquery.GiraffeQuery{}, dialects.ErrUnknown()
 
@@ -47,9 +56,30 @@ func Parse(
 	return cached.Unpack()
 }
 
+func Exported(
+	q queryimpl.QueryImpl,
+	dialect dialects.Dialect,
+) (string, error) {
+	switch dialect {
+	case dialects.Giraffe1v1:
+		return q.Escaped(), nil
+
+	case dialects.JsonPointer:
+		return jsonpointer.Exported(q)
+
+	case dialects.JsonPath:
+		return jsonpath.Exported(q)
+
+	case dialects.Unknown:
+		return "", dialects.ErrUnknown()
+
+	default:
+		return "", dialects.ErrUnknown()
+	}
+}
+
 func Escaped(
 	spec string,
 ) string {
-	// TODO :D
-	return spec
+	return gquery.Escaped(spec)
 }
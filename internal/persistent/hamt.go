@@ -0,0 +1,294 @@
+// Package persistent holds immutable collections, each write making a new
+// version which shares with the previous one all it did not change.
+package persistent
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+const (
+	hamtBits  = 5
+	hamtMask  = 1<<hamtBits - 1
+	hamtDepth = 64 / hamtBits * hamtBits
+)
+
+var hamtSeed = maphash.MakeSeed()
+
+// hamtEntry is either a key and its value, or a child node.
+type hamtEntry[V any] struct {
+	child *hamtNode[V]
+	key   string
+	val   V
+	hash  uint64
+}
+
+// hamtNode holds the entries of the bits set in its bitmap, in order. Past
+// the last level, a node holds the keys sharing their hash, with no bitmap.
+type hamtNode[V any] struct {
+	entries []hamtEntry[V]
+	bitmap  uint32
+}
+
+// Map is a hash array mapped trie of string keys. The zero value is an empty
+// map ready to use. Keys are listed in no particular order.
+type Map[V any] struct {
+	root *hamtNode[V]
+	size int
+}
+
+func (m Map[V]) Len() int {
+	return m.size
+}
+
+func (m Map[V]) Get(
+	k string,
+) (V, bool) {
+	if m.root == nil {
+		var zero V
+		return zero, false
+	}
+
+	return m.root.get(maphash.String(hamtSeed, k), 0, k)
+}
+
+// Set is a version of m with k set to v.
+func (m Map[V]) Set(
+	k string,
+	v V,
+) Map[V] {
+	root := m.root
+	if root == nil {
+		root = &hamtNode[V]{}
+	}
+
+	e := hamtEntry[V]{
+		child: nil,
+		key:   k,
+		val:   v,
+		hash:  maphash.String(hamtSeed, k),
+	}
+
+	root, added := root.set(e, 0)
+	if added {
+		return Map[V]{root: root, size: m.size + 1}
+	}
+
+	return Map[V]{root: root, size: m.size}
+}
+
+// Delete is a version of m without k.
+func (m Map[V]) Delete(
+	k string,
+) Map[V] {
+	if m.root == nil {
+		return m
+	}
+
+	root, removed := m.root.del(maphash.String(hamtSeed, k), 0, k)
+	if !removed {
+		return m
+	}
+
+	return Map[V]{root: root, size: m.size - 1}
+}
+
+func (m Map[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		if m.root != nil {
+			m.root.all(yield)
+		}
+	}
+}
+
+// =====================================.
+
+func (n *hamtNode[V]) at(
+	h uint64,
+	shift uint,
+) (uint32, int) {
+	bit := uint32(1) << ((h >> shift) & hamtMask)
+
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode[V]) get(
+	h uint64,
+	shift uint,
+	k string,
+) (V, bool) {
+	var zero V
+
+	for ; ; shift += hamtBits {
+		if shift >= hamtDepth {
+			for _, e := range n.entries {
+				if e.key == k {
+					return e.val, true
+				}
+			}
+
+			return zero, false
+		}
+
+		bit, i := n.at(h, shift)
+		if n.bitmap&bit == 0 {
+			return zero, false
+		}
+
+		e := &n.entries[i]
+		if e.child == nil {
+			if e.key == k {
+				return e.val, true
+			}
+
+			return zero, false
+		}
+
+		n = e.child
+	}
+}
+
+// set is a copy of n with e set, and whether e is a new key.
+func (n *hamtNode[V]) set(
+	e hamtEntry[V],
+	shift uint,
+) (*hamtNode[V], bool) {
+	if shift >= hamtDepth {
+		i := slices.IndexFunc(n.entries, func(it hamtEntry[V]) bool {
+			return it.key == e.key
+		})
+		if i < 0 {
+			return &hamtNode[V]{entries: append(slices.Clip(n.entries), e), bitmap: 0}, true
+		}
+
+		return n.replaced(i, e), false
+	}
+
+	bit, i := n.at(e.hash, shift)
+	if n.bitmap&bit == 0 {
+		return &hamtNode[V]{
+			entries: slices.Insert(slices.Clip(n.entries), i, e),
+			bitmap:  n.bitmap | bit,
+		}, true
+	}
+
+	switch existing := n.entries[i]; {
+	case existing.child != nil:
+		child, added := existing.child.set(e, shift+hamtBits)
+
+		return n.replaced(i, hamtEntry[V]{child: child}), added
+
+	case existing.key == e.key:
+		return n.replaced(i, e), false
+
+	default:
+		child := hamtPair(existing, e, shift+hamtBits)
+
+		return n.replaced(i, hamtEntry[V]{child: child}), true
+	}
+}
+
+// del is a copy of n without k, and whether k was there at all. A node left
+// with a single key, and no children, is folded into its parent.
+func (n *hamtNode[V]) del(
+	h uint64,
+	shift uint,
+	k string,
+) (*hamtNode[V], bool) {
+	if shift >= hamtDepth {
+		i := slices.IndexFunc(n.entries, func(it hamtEntry[V]) bool {
+			return it.key == k
+		})
+		if i < 0 {
+			return n, false
+		}
+
+		return &hamtNode[V]{entries: slices.Delete(slices.Clone(n.entries), i, i+1), bitmap: 0}, true
+	}
+
+	bit, i := n.at(h, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+
+	existing := n.entries[i]
+	if existing.child == nil {
+		if existing.key != k {
+			return n, false
+		}
+
+		return &hamtNode[V]{
+			entries: slices.Delete(slices.Clone(n.entries), i, i+1),
+			bitmap:  n.bitmap &^ bit,
+		}, true
+	}
+
+	child, removed := existing.child.del(h, shift+hamtBits, k)
+	if !removed {
+		return n, false
+	}
+
+	if len(child.entries) == 1 && child.entries[0].child == nil {
+		return n.replaced(i, child.entries[0]), true
+	}
+
+	return n.replaced(i, hamtEntry[V]{child: child}), true
+}
+
+func (n *hamtNode[V]) replaced(
+	i int,
+	e hamtEntry[V],
+) *hamtNode[V] {
+	entries := slices.Clone(n.entries)
+	entries[i] = e
+
+	return &hamtNode[V]{entries: entries, bitmap: n.bitmap}
+}
+
+func (n *hamtNode[V]) all(
+	yield func(string, V) bool,
+) bool {
+	for _, e := range n.entries {
+		if e.child != nil {
+			if !e.child.all(yield) {
+				return false
+			}
+		} else if !yield(e.key, e.val) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hamtPair is a node holding two keys which collided up to shift.
+func hamtPair[V any](
+	a hamtEntry[V],
+	b hamtEntry[V],
+	shift uint,
+) *hamtNode[V] {
+	if shift >= hamtDepth {
+		return &hamtNode[V]{entries: []hamtEntry[V]{a, b}, bitmap: 0}
+	}
+
+	n := &hamtNode[V]{}
+	bitA, _ := n.at(a.hash, shift)
+	bitB, _ := n.at(b.hash, shift)
+
+	switch {
+	case bitA == bitB:
+		n.entries = []hamtEntry[V]{{child: hamtPair(a, b, shift+hamtBits)}}
+
+	case bitA < bitB:
+		n.entries = []hamtEntry[V]{a, b}
+
+	default:
+		n.entries = []hamtEntry[V]{b, a}
+	}
+
+	n.bitmap = bitA | bitB
+
+	return n
+}
@@ -0,0 +1,131 @@
+package persistent_test
+
+import (
+	"maps"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/internal/persistent"
+)
+
+func TestMap(t *testing.T) {
+	t.Run("set get delete", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		const n = 5000
+
+		var m persistent.Map[int]
+		versions := make([]persistent.Map[int], 0, n)
+		for i := range n {
+			m = m.Set("k"+strconv.Itoa(i), i)
+			versions = append(versions, m)
+		}
+
+		assert.Equal(t, n, m.Len())
+		for i := range n {
+			v, ok := m.Get("k" + strconv.Itoa(i))
+			assert.True(t, ok)
+			assert.Equal(t, i, v)
+		}
+
+		// Earlier versions are untouched.
+		assert.Equal(t, 10, versions[9].Len())
+		_, ok := versions[9].Get("k10")
+		assert.False(t, ok)
+
+		replaced := m.Set("k1", -1)
+		assert.Equal(t, n, replaced.Len())
+		assert.Equal(t, -1, get(replaced, "k1"))
+		assert.Equal(t, 1, get(m, "k1"))
+
+		deleted := m
+		for i := 0; i < n; i += 2 {
+			deleted = deleted.Delete("k" + strconv.Itoa(i))
+		}
+
+		assert.Equal(t, n/2, deleted.Len())
+		assert.Equal(t, n, m.Len())
+		assert.Equal(t, n/2, len(maps.Collect(deleted.All())))
+
+		_, ok = deleted.Get("k0")
+		assert.False(t, ok)
+		assert.Equal(t, 1, get(deleted, "k1"))
+
+		assert.Equal(t, deleted.Len(), deleted.Delete("missing").Len())
+	})
+
+	t.Run("zero", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		var m persistent.Map[string]
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, 0, m.Delete("a").Len())
+		assert.Empty(t, maps.Collect(m.All()))
+
+		_, ok := m.Get("a")
+		assert.False(t, ok)
+	})
+}
+
+func get(
+	m persistent.Map[int],
+	k string,
+) int {
+	v, _ := m.Get(k)
+	return v
+}
+
+func TestVector(t *testing.T) {
+	t.Run("append get set", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, n := range []int{0, 1, 31, 32, 33, 1024, 1056, 1057, 40000} {
+			var v persistent.Vector[int]
+			for i := range n {
+				v = v.Append(i)
+			}
+
+			assert.Equal(t, n, v.Len())
+			for i := range n {
+				if v.Get(i) != i {
+					assert.Equal(t, i, v.Get(i), n)
+				}
+			}
+
+			all := maps.Collect(v.All())
+			assert.Len(t, all, n)
+			if n > 0 {
+				assert.Equal(t, n-1, all[n-1])
+			}
+
+			if n == 0 {
+				continue
+			}
+
+			for _, i := range []int{0, n / 2, n - 1} {
+				set := v.Set(i, -1)
+				assert.Equal(t, -1, set.Get(i))
+				assert.Equal(t, i, v.Get(i))
+			}
+
+			appended := v.Append(-1)
+			assert.Equal(t, n+1, appended.Len())
+			assert.Equal(t, n, v.Len())
+
+			other := v.Append(-2)
+			assert.Equal(t, -1, appended.Get(n))
+			assert.Equal(t, -2, other.Get(n))
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		v := persistent.VectorOf(1, 2)
+		assert.Panics(t, func() { v.Get(2) })
+		assert.Panics(t, func() { v.Set(-1, 0) })
+	})
+}
@@ -0,0 +1,225 @@
+package persistent
+
+import (
+	"iter"
+	"slices"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+const (
+	vecBits  = 5
+	vecWidth = 1 << vecBits
+	vecMask  = vecWidth - 1
+)
+
+// vecNode holds either children, or the values of a leaf.
+type vecNode[V any] struct {
+	children []*vecNode[V]
+	values   []V
+}
+
+// Vector is a bit-partitioned vector trie, the RRB vector short of relaxed
+// nodes, which only concatenation needs. The last values are held out of the
+// trie, in a tail, to make appending cheap. The zero value is an empty vector
+// ready to use.
+type Vector[V any] struct {
+	root  *vecNode[V]
+	tail  []V
+	size  int
+	shift uint
+}
+
+func VectorOf[V any](
+	values ...V,
+) Vector[V] {
+	v := Vector[V]{}
+	for _, it := range values {
+		v = v.Append(it)
+	}
+
+	return v
+}
+
+func (v Vector[V]) Len() int {
+	return v.size
+}
+
+// tailAt is the index of the first value in the tail.
+func (v Vector[V]) tailAt() int {
+	if v.size < vecWidth {
+		return 0
+	}
+
+	return ((v.size - 1) >> vecBits) << vecBits
+}
+
+// leaf is the values of the leaf holding i.
+func (v Vector[V]) leaf(
+	i int,
+) []V {
+	if i >= v.tailAt() {
+		return v.tail
+	}
+
+	n := v.root
+	for level := v.shift; level > 0; level -= vecBits {
+		n = n.children[(i>>level)&vecMask]
+	}
+
+	return n.values
+}
+
+// Get panics if i is out of bounds, as indexing a slice does.
+func (v Vector[V]) Get(
+	i int,
+) V {
+	if i < 0 || i >= v.size {
+		panic(EF("index out of range: %d, len=%d", i, v.size))
+	}
+
+	return v.leaf(i)[i&vecMask]
+}
+
+// Set is a version of v with the value at i set, panicking if i is out of
+// bounds.
+func (v Vector[V]) Set(
+	i int,
+	value V,
+) Vector[V] {
+	if i < 0 || i >= v.size {
+		panic(EF("index out of range: %d, len=%d", i, v.size))
+	}
+
+	if i >= v.tailAt() {
+		tail := slices.Clone(v.tail)
+		tail[i&vecMask] = value
+
+		return Vector[V]{root: v.root, tail: tail, size: v.size, shift: v.shift}
+	}
+
+	return Vector[V]{
+		root:  vecSet(v.root, v.shift, i, value),
+		tail:  v.tail,
+		size:  v.size,
+		shift: v.shift,
+	}
+}
+
+// Append is a version of v with value added last.
+func (v Vector[V]) Append(
+	value V,
+) Vector[V] {
+	if v.size-v.tailAt() < vecWidth {
+		tail := make([]V, len(v.tail)+1, vecWidth)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = value
+
+		return Vector[V]{root: v.root, tail: tail, size: v.size + 1, shift: v.shift}
+	}
+
+	shift := v.shift
+	if shift == 0 {
+		shift = vecBits
+	}
+
+	root := v.root
+	if root == nil {
+		root = &vecNode[V]{}
+	}
+
+	full := &vecNode[V]{children: nil, values: v.tail}
+
+	if (v.size >> vecBits) > (1 << shift) {
+		root = &vecNode[V]{
+			children: []*vecNode[V]{root, vecPath(shift, full)},
+			values:   nil,
+		}
+		shift += vecBits
+	} else {
+		root = vecPush(root, shift, v.size, full)
+	}
+
+	tail := make([]V, 1, vecWidth)
+	tail[0] = value
+
+	return Vector[V]{root: root, tail: tail, size: v.size + 1, shift: shift}
+}
+
+func (v Vector[V]) All() iter.Seq2[int, V] {
+	return func(yield func(int, V) bool) {
+		for i := 0; i < v.size; i += vecWidth {
+			for j, it := range v.leaf(i) {
+				if !yield(i+j, it) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// =====================================.
+
+func vecSet[V any](
+	n *vecNode[V],
+	level uint,
+	i int,
+	value V,
+) *vecNode[V] {
+	if level == 0 {
+		values := slices.Clone(n.values)
+		values[i&vecMask] = value
+
+		return &vecNode[V]{children: nil, values: values}
+	}
+
+	children := slices.Clone(n.children)
+	at := (i >> level) & vecMask
+	children[at] = vecSet(children[at], level-vecBits, i, value)
+
+	return &vecNode[V]{children: children, values: nil}
+}
+
+// vecPush is a copy of n with the full leaf added after the size values
+// already in the trie and its tail.
+func vecPush[V any](
+	n *vecNode[V],
+	level uint,
+	size int,
+	full *vecNode[V],
+) *vecNode[V] {
+	at := ((size - 1) >> level) & vecMask
+	children := slices.Clip(slices.Clone(n.children))
+
+	var child *vecNode[V]
+	switch {
+	case level == vecBits:
+		child = full
+
+	case at < len(children):
+		child = vecPush(children[at], level-vecBits, size, full)
+
+	default:
+		child = vecPath(level-vecBits, full)
+	}
+
+	if at < len(children) {
+		children[at] = child
+	} else {
+		children = append(children, child)
+	}
+
+	return &vecNode[V]{children: children, values: nil}
+}
+
+// vecPath is the full leaf, nested down from level.
+func vecPath[V any](
+	level uint,
+	full *vecNode[V],
+) *vecNode[V] {
+	if level == 0 {
+		return full
+	}
+
+	return &vecNode[V]{children: []*vecNode[V]{vecPath(level-vecBits, full)}, values: nil}
+}
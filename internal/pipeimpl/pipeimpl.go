@@ -19,6 +19,7 @@ var cache = inmem.Make[PipeImpl](
 type PipeImpl struct {
 	steps  []queryimpl.QueryImpl
 	sSteps []string
+	specs  []string
 }
 
 func (p PipeImpl) String() string {
@@ -33,24 +34,60 @@ func (p PipeImpl) SSteps() []string {
 	return slices.Clone(p.sSteps)
 }
 
+// Specs are the stages as written, trimmed of the surrounding spaces, which
+// tells apart the escaped stages from the bare ones.
+func (p PipeImpl) Specs() []string {
+	return slices.Clone(p.specs)
+}
+
+// split splits on the unescaped pipes.
+func split(
+	spec string,
+) []string {
+	var parts []string
+
+	start := 0
+	escaped := false
+	for i := range len(spec) {
+		switch {
+		case escaped:
+			escaped = false
+
+		case spec[i] == cmd.Escape.Byte():
+			escaped = true
+
+		case spec[i] == cmd.Pipe.Byte():
+			parts = append(parts, spec[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, spec[start:])
+}
+
 func parse(
 	spec string,
 ) (PipeImpl, error) {
-	queries := strings.Split(spec, cmd.Pipe.String())
+	queries := split(spec)
 	steps := make([]queryimpl.QueryImpl, len(queries))
 	sSteps := make([]string, len(queries))
+	specs := make([]string, len(queries))
 	for i, spec := range queries {
+		spec = strings.TrimSpace(spec)
+
 		parsed, err := internal.Parse(spec)
 		if err != nil {
 			return PipeImpl{}, err
 		}
 		steps[i] = parsed
 		sSteps[i] = parsed.String()
+		specs[i] = spec
 	}
 
 	return PipeImpl{
 		steps:  steps,
 		sSteps: sSteps,
+		specs:  specs,
 	}, nil
 }
 
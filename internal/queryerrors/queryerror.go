@@ -19,6 +19,9 @@ const (
 	ErrCodeUnexpectedToken
 	ErrCodeNestingTooDeep
 	ErrCodeNotWritable
+	ErrCodeInvalidPredicate
+	ErrCodeUnsupported
+	ErrCodeNotExportable
 )
 
 //goland:noinspection GoNameStartsWithPackageName
@@ -50,6 +53,17 @@ func NewNotWritableError(
 	)
 }
 
+func NewNotExportableError(
+	q string,
+	dialect string,
+	what string,
+) error {
+	return newError(
+		ErrCodeNotExportable,
+		"query not exportable to the dialect: dialect="+dialect+", query="+q+", "+what,
+	)
+}
+
 func NewParseError(
 	code uint64,
 	at int,
@@ -140,3 +154,31 @@ func NestingTooDeepError(
 		"query nesting is too deep",
 	)
 }
+
+func UnsupportedError(
+	at int,
+	spec string,
+	what string,
+) error {
+	return NewParseError(
+		ErrCodeUnsupported,
+		at,
+		spec,
+		"unsupported by the dialect",
+		what,
+	)
+}
+
+func InvalidPredicateError(
+	at int,
+	spec string,
+	predicate string,
+) error {
+	return NewParseError(
+		ErrCodeInvalidPredicate,
+		at,
+		spec,
+		"invalid predicate",
+		"predicate="+predicate,
+	)
+}
@@ -1,11 +1,36 @@
 package gquery
 
 import (
-	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
 )
 
+// Escaped escapes a single segment (an object key) so that it is taken
+// literally by the parser. A key reading as an index, as "0" or "-1" do, is
+// escaped as well, so that it is not taken for one, and the empty key is
+// written as `[""]`.
 func Escaped(
-	spec string,
+	ref string,
 ) string {
-	panic(EF("todo escape: %s", spec))
+	if ref == "" {
+		return cmd.BraceL.String() + `""` + cmd.BraceR.String()
+	}
+
+	sb := strings.Builder{}
+	sb.Grow(len(ref) + 2)
+
+	if uintRegex.MatchString(ref) || negRegex.MatchString(ref) {
+		sb.WriteByte(cmd.Escape.Byte())
+	}
+
+	for i := range len(ref) {
+		if cmd.IsCmd(ref[i]) {
+			sb.WriteByte(cmd.Escape.Byte())
+		}
+
+		sb.WriteByte(ref[i])
+	}
+
+	return sb.String()
 }
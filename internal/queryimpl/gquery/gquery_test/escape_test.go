@@ -0,0 +1,160 @@
+package gquery_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe/cmd"
+	"github.com/hkoosha/giraffe/core/gtesting"
+	"github.com/hkoosha/giraffe/internal/queryimpl/gquery"
+)
+
+const cmds = `+=!$?.\@#[]*~|`
+
+func attrs(
+	t *testing.T,
+	q gquery.GiraffeQuery,
+) []string {
+	t.Helper()
+
+	var keys []string
+	for _, p := range q.VisibleForTestingPath() {
+		require.True(t, p.Flags().IsObj(), p.String())
+		keys = append(keys, p.Attr())
+	}
+
+	return keys
+}
+
+func TestEscaped(t *testing.T) {
+	t.Run("every command", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for i := range len(cmds) {
+			require.True(t, cmd.IsCmd(cmds[i]), cmds[i:i+1])
+
+			for _, key := range []string{
+				cmds[i : i+1],
+				"a" + cmds[i:i+1] + "b",
+				cmds[i:i+1] + "k" + cmds[i:i+1],
+			} {
+				spec := gquery.Escaped(key)
+
+				first, err := gquery.Parse(spec)
+				gtesting.NoError(t, err)
+				assert.Equal(t, []string{key}, attrs(t, first), spec)
+				assert.Equal(t, spec, first.String())
+
+				again, err := gquery.Parse(first.String())
+				gtesting.NoError(t, err)
+				assert.Equal(t, []string{key}, attrs(t, again), spec)
+				assert.Equal(t, first.String(), again.String())
+			}
+		}
+	})
+
+	t.Run("path", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		keys := []string{"a.b", "x[0]", "*", "#", "?k", "=", "+", "!", "$", "@1", `back\slash`, "~j", "p|q", "plain", "end."}
+
+		escaped := make([]string, len(keys))
+		for i, key := range keys {
+			escaped[i] = gquery.Escaped(key)
+		}
+
+		spec := strings.Join(escaped, cmd.Sep.String())
+
+		first, err := gquery.Parse(spec)
+		gtesting.NoError(t, err)
+		assert.Equal(t, keys, attrs(t, first))
+		assert.Equal(t, spec, first.String())
+		assert.Equal(t, spec, first.Escaped())
+
+		again, err := gquery.Parse(first.String())
+		gtesting.NoError(t, err)
+		assert.Equal(t, keys, attrs(t, again))
+		assert.Equal(t, first.String(), again.String())
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		assert.Equal(t, "k0_k-1", gquery.Escaped("k0_k-1"))
+		assert.Equal(t, `a\.b\[0\]`, gquery.Escaped("a.b[0]"))
+		assert.Equal(t, `\\\.`, gquery.Escaped(`\.`))
+	})
+
+	t.Run("digit keys", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for key, spec := range map[string]string{
+			"0":   `\0`,
+			"12":  `\12`,
+			"-1":  `\-1`,
+			"01":  `\01`,
+			"-0":  "-0",
+			"1a":  "1a",
+			"-1a": "-1a",
+		} {
+			assert.Equal(t, spec, gquery.Escaped(key), key)
+
+			q, err := gquery.Parse(spec)
+			gtesting.NoError(t, err)
+			assert.Equal(t, []string{key}, attrs(t, q), spec)
+		}
+
+		q, err := gquery.Parse("0.-1")
+		gtesting.NoError(t, err)
+		for _, p := range q.VisibleForTestingPath() {
+			assert.True(t, p.Flags().IsArr(), p.String())
+		}
+	})
+
+	t.Run("string keys", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		assert.Equal(t, `[""]`, gquery.Escaped(""))
+
+		for spec, keys := range map[string][]string{
+			`[""]`:       {""},
+			`a.[""].b`:   {"a", "", "b"},
+			`a[""][""]`:  {"a", "", ""},
+			`["a.b"]`:    {"a.b"},
+			`["0"]`:      {"0"},
+			`["q\"]\\"]`: {`q"]\`},
+		} {
+			q, err := gquery.Parse(spec)
+			gtesting.NoError(t, err)
+			assert.Equal(t, keys, attrs(t, q), spec)
+
+			again, err := gquery.Parse(q.String())
+			gtesting.NoError(t, err)
+			assert.Equal(t, keys, attrs(t, again), spec)
+		}
+
+		_, err := gquery.Parse(`["a]`)
+		require.Error(t, err)
+	})
+
+	t.Run("trailing separator", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, keys := range map[string][]string{
+			`a.`:     {"a"},
+			`a\.`:    {"a."},
+			`a\\.`:   {`a\`},
+			`a\\\.`:  {`a\.`},
+			`a.\.`:   {"a", "."},
+			`\.\.`:   {".."},
+			`a\..b.`: {"a.", "b"},
+		} {
+			q, err := gquery.Parse(spec)
+			gtesting.NoError(t, err)
+			assert.Equal(t, keys, attrs(t, q), spec)
+		}
+	})
+}
@@ -12,12 +12,28 @@ import (
 
 func TestQFlag_Mods(t *testing.T) {
 	qFlags := []cmd.QFlag{
+		cmd.QModSelf,
+		cmd.QModOverwrit,
 		cmd.QModIndeter,
 		cmd.QModeMaybe,
 		cmd.QModeMake,
 		cmd.QModAppend,
+		cmd.QModDelete,
 		cmd.QModArr,
 		cmd.QModObj,
+		cmd.QModRoot,
+		cmd.QModLeaf,
+		cmd.QModSingle,
+		cmd.QModWrite,
+		cmd.QModDyn,
+		cmd.QModBraces,
+		cmd.QModSubQuery,
+		cmd.QModWildcard,
+		cmd.QModDescent,
+		cmd.QModSlice,
+		cmd.QModNegative,
+		cmd.QModPattern,
+		cmd.QModPredicate,
 	}
 
 	t.Run("bit count", func(t *testing.T) {
@@ -40,4 +56,17 @@ func TestQFlag_Mods(t *testing.T) {
 			)
 		}
 	})
+
+	t.Run("no overflow", func(t *testing.T) {
+		maxSeq := cmd.SequenceMask >> cmd.SeqShift
+
+		for _, qFlag := range qFlags {
+			f := qFlag | cmd.ValueMask | cmd.SequenceMask
+
+			assert.Equal(t, int(cmd.ValueMask), f.Val())
+			assert.Equal(t, int(maxSeq), f.Seq())
+			assert.Equal(t, qFlag, f&cmd.ModMask)
+			assert.Zero(t, qFlag&(cmd.ValueMask|cmd.SequenceMask))
+		}
+	})
 }
@@ -77,6 +77,193 @@ func TestParse(t *testing.T) {
 		p := k1.Prev()
 		assert.Equal(t, k0, p)
 	})
+
+	t.Run("self", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		first, err := gquery.Parse("#")
+		gtesting.NoError(t, err)
+
+		assert.Equal(t, "#", first.String())
+		assert.True(t, first.Flags().IsSelf())
+		assert.True(t, first.Flags().IsLeaf())
+
+		first, err = gquery.Parse("k0.#.k1")
+		gtesting.NoError(t, err)
+
+		assert.True(t, first.Next().Flags().IsSelf())
+		assert.Equal(t, "k1", first.Next().Next().Attr())
+	})
+
+	t.Run("braces", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string]string{
+			"users[.selected_id].name": "users.[selected_id].name",
+			"users.[selected_id].name": "users.[selected_id].name",
+			"[a]":                      "[a]",
+			"a[b][c]":                  "a.[b].[c]",
+			"a[b[.c].d].e":             "a.[b.[c].d].e",
+			`a[b\]]`:                   `a.[b\]]`,
+		} {
+			first, err := gquery.Parse(spec)
+			gtesting.NoError(t, err)
+
+			assert.Equal(t, expected, first.String(), spec)
+			assert.True(t, first.Flags().IsDyn(), spec)
+		}
+
+		for _, spec := range []string{
+			"a[b",
+			"a]b",
+			"a[]",
+			"a[b]c",
+			"a[b[c]",
+		} {
+			_, err := gquery.Parse(spec)
+			require.Error(t, err, spec)
+		}
+	})
+}
+
+func TestParse_Patterns(t *testing.T) {
+	t.Run("patterns", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string]string{
+			"items.*.id":  "items.*.id",
+			"**.id":       "**.id",
+			"a.-1":        "a.-1",
+			"a[2:5].b":    "a.[2:5].b",
+			"a[:-1]":      "a.[:-1]",
+			"a[1:]":       "a.[1:]",
+			"a[:]":        "a.[:]",
+			`a.\*`:        `a.\*`,
+			"a[sel].*.-2": "a.[sel].*.-2",
+			"*[1:2]":      "*.[1:2]",
+		} {
+			first, err := gquery.Parse(spec)
+			gtesting.NoError(t, err)
+
+			assert.Equal(t, expected, first.String(), spec)
+		}
+
+		for _, spec := range []string{
+			"***",
+			"*a",
+			"a*",
+			"*\\a",
+		} {
+			_, err := gquery.Parse(spec)
+			require.Error(t, err, spec)
+		}
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		q, err := gquery.Parse("a.*.**.-3")
+		gtesting.NoError(t, err)
+
+		assert.True(t, q.Flags().IsPattern())
+		assert.True(t, q.Flags().IsIndeterministic())
+		assert.True(t, q.Next().Flags().IsWildcard())
+		assert.True(t, q.Next().Next().Flags().IsDescent())
+		assert.True(t, q.Leaf().Flags().IsNegative())
+		assert.Equal(t, -3, q.Leaf().Index())
+
+		q, err = gquery.Parse("a.-1")
+		gtesting.NoError(t, err)
+
+		assert.True(t, q.Flags().IsPattern())
+		assert.False(t, q.Flags().IsIndeterministic())
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string][2]int{
+			"[2:5]":   {2, 5},
+			"[:]":     {0, 10},
+			"[-3:]":   {7, 10},
+			"[:-1]":   {0, 9},
+			"[5:100]": {5, 10},
+			"[-20:2]": {0, 2},
+			"[6:2]":   {6, 2},
+		} {
+			q, err := gquery.Parse(spec)
+			gtesting.NoError(t, err)
+
+			from, to := q.Slice(10)
+			assert.Equal(t, expected, [2]int{from, to}, spec)
+		}
+	})
+}
+
+func TestParse_Predicates(t *testing.T) {
+	t.Run("predicates", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string][4]string{
+			`items[?status=="active"].id`: {`items.[?status=="active"].id`, "status", "==", `"active"`},
+			`items[? a.b >= 10 ]`:         {`items.[?a.b>=10]`, "a.b", ">=", "10"},
+			`items[?#!=null]`:             {`items.[?#!=null]`, "#", "!=", "null"},
+			`items[?.id]`:                 {`items.[?id]`, "id", "", ""},
+			`items[?name<"a]b"]`:          {`items.[?name<"a]b"]`, "name", "<", `"a]b"`},
+			`items[?a\=b==true]`:          {`items.[?a\=b==true]`, `a\=b`, "==", "true"},
+			`items[?a\<b>1]`:              {`items.[?a\<b>1]`, `a\<b`, ">", "1"},
+			`items[?n=="x\"y"][?z<=-1.5]`: {`items.[?n=="x\"y"].[?z<=-1.5]`, "n", "==", `"x\"y"`},
+		} {
+			first, err := gquery.Parse(spec)
+			gtesting.NoError(t, err)
+
+			assert.Equal(t, expected[0], first.String(), spec)
+
+			pred := first.Next()
+			assert.True(t, pred.Flags().IsPredicate(), spec)
+			assert.True(t, pred.Flags().IsIndeterministic(), spec)
+
+			left, op, literal := pred.Predicate()
+			assert.Equal(t, expected[1:], []string{left, op, literal}, spec)
+		}
+
+		for _, spec := range []string{
+			`items[?]`,
+			`items[?=="a"]`,
+			`items[?a==]`,
+			`items[?a==active]`,
+			`items[?a.*==1]`,
+			`items[?a=="b]`,
+		} {
+			_, err := gquery.Parse(spec)
+			require.Error(t, err, spec)
+		}
+	})
+}
+
+func TestResolved(t *testing.T) {
+	t.Run("resolved", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		q, err := gquery.Parse("a[b[.c]].$[d]")
+		gtesting.NoError(t, err)
+
+		values := map[string]string{
+			"c":     "x",
+			"b.[c]": "1",
+			"d":     `e\.f`,
+		}
+
+		r, err := q.Resolved(func(query string) (string, error) {
+			return values[query], nil
+		})
+		gtesting.NoError(t, err)
+
+		assert.Equal(t, `a.1.$e\.f`, r.String())
+		assert.False(t, r.Flags().IsDyn())
+		assert.Equal(t, 1, r.Next().Index())
+		assert.Equal(t, "e.f", r.Leaf().Attr())
+	})
 }
 
 func TestNext(t *testing.T) {
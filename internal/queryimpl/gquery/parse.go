@@ -1,6 +1,7 @@
 package gquery
 
 import (
+	"encoding/json"
 	"regexp"
 	"slices"
 	"strconv"
@@ -13,15 +14,26 @@ import (
 	"github.com/hkoosha/giraffe/internal/queryimpl"
 )
 
-var uintRegex = regexp.MustCompile(`^\d+$`)
+var (
+	uintRegex  = regexp.MustCompile(`^\d+$`)
+	negRegex   = regexp.MustCompile(`^-[1-9]\d*$`)
+	sliceRegex = regexp.MustCompile(`^(-?\d+)?:(-?\d+)?$`)
+)
 
 type state struct {
 	ref     strings.Builder
 	flags   cmd.QFlag
+	depth   int
 	isFin   bool
 	noCmd   bool
 	escaped bool
 	inside  bool
+	quoted  bool
+	closed  bool
+
+	// literal is set once any character of the segment is escaped, which
+	// makes it a key even if it reads as an index, as `\0` does.
+	literal bool
 }
 
 type parser struct {
@@ -44,6 +56,7 @@ func (p *parser) onEscape() error {
 
 	p.state.noCmd = true
 	p.state.escaped = true
+	p.state.literal = true
 
 	return nil
 }
@@ -162,12 +175,39 @@ func (p *parser) onOverwrite() error {
 		return queryerrors.ConflictingCmdError(p.i, p.spec, p.c)
 	}
 
-	p.global = cmd.QModOverwrit
+	p.global |= cmd.QModOverwrit
+
+	return nil
+}
+
+func (p *parser) onWildcard() error {
+	switch {
+	case p.state.closed,
+		p.state.flags.IsDescent(),
+		p.state.ref.Len() > 0 && !p.state.flags.IsWildcard():
+		return queryerrors.UnexpectedTokenError(p.i, p.spec, p.c)
+
+	case p.state.flags.IsWildcard():
+		p.state.flags &= ^cmd.QModWildcard
+		p.state.flags |= cmd.QModDescent
+
+	default:
+		p.state.flags |= cmd.QModWildcard
+	}
+
+	p.global |= cmd.QModIndeter | cmd.QModPattern
+	p.state.ref.WriteByte(p.c)
+	p.state.isFin = true
+	p.state.noCmd = true
 
 	return nil
 }
 
 func (p *parser) onRune() error {
+	if p.state.closed || p.state.flags.IsWildcard() || p.state.flags.IsDescent() {
+		return queryerrors.UnexpectedTokenError(p.i, p.spec, p.c)
+	}
+
 	p.state.ref.WriteByte(p.c)
 
 	return nil
@@ -176,10 +216,21 @@ func (p *parser) onRune() error {
 func (p *parser) onSep() error {
 	str := p.state.ref.String()
 
+	if p.state.closed {
+		// The segment was already made by the closing bracket.
+		//nolint:exhaustruct // is made specifically for zero state.
+		p.state = state{}
+
+		return nil
+	}
+
 	switch {
 	case p.state.flags.IsAppend() && str == "":
 		str = "0"
 
+	case p.state.flags.IsSelf() && str == "":
+		// A bare self, referring to the datum at hand.
+
 	case str == "":
 		return queryerrors.EmptyError(p.i, p.spec)
 	}
@@ -195,7 +246,20 @@ func (p *parser) onSep() error {
 	)
 
 	switch {
-	case uintRegex.MatchString(str):
+	case curr.flags.IsWildcard(), curr.flags.IsDescent():
+		// Neither an attr nor an index, matches all children.
+
+	case !p.state.literal && negRegex.MatchString(str):
+		curr.flags |= cmd.QModArr | cmd.QModNegative
+
+		value := cmd.QFlag(M(strconv.ParseUint(str[1:], 10, 64)))
+		if value&cmd.ValueMask != value {
+			return EF("value too big: %s", str)
+		}
+		curr.flags |= value
+		p.global |= cmd.QModPattern
+
+	case !p.state.literal && uintRegex.MatchString(str):
 		// Not entirely sound, or rather too restrictive if the isMake switch
 		// was turned on by previous key parts.
 		if !curr.flags.IsMake() && curr.flags.IsMaybe() && curr.ref != "0" {
@@ -233,35 +297,93 @@ func (p *parser) onSep() error {
 	return nil
 }
 
-func (p *parser) onBraceR() error {
+// onInside collects the sub-query verbatim, up to the matching bracket.
+// Brackets in a quoted literal, as in a predicate, are taken as is.
+func (p *parser) onInside() error {
 	switch {
-	case !p.state.inside:
-		return queryerrors.UnexpectedTokenError(p.i, p.spec, p.c)
+	case p.state.escaped:
+		p.state.escaped = false
+
+	case p.c == cmd.Escape.Byte():
+		p.state.escaped = true
+
+	case p.c == '"':
+		p.state.quoted = !p.state.quoted
+
+	case p.state.quoted:
+		// Taken as is.
+
+	case p.c == cmd.BraceL.Byte():
+		p.state.depth++
+
+	case p.c == cmd.BraceR.Byte() && p.state.depth == 0:
+		return p.onBraceR()
+
+	case p.c == cmd.BraceR.Byte():
+		p.state.depth--
+	}
 
-	case p.state.ref.Len() == 0:
+	p.state.ref.WriteByte(p.c)
+
+	return nil
+}
+
+func (p *parser) onBraceR() error {
+	inner := p.state.ref.String()
+
+	// A leading separator marks the sub-query as absolute, which it always is.
+	sub := strings.TrimPrefix(inner, cmd.Sep.String())
+
+	switch {
+	case sub == "":
 		return queryerrors.EmptyError(p.i, p.spec)
 
 	case int64(p.global.Seq()) >= int64(p.maxDepth):
 		return queryerrors.NestingTooDeepError(p.i, p.spec)
 	}
 
-	p.state.inside = false
+	var curr GiraffeQuery
+	switch {
+	case sliceRegex.MatchString(inner):
+		p.global |= cmd.QModBraces | cmd.QModIndeter | cmd.QModPattern
+		curr = newQuery(nil, inner, p.global|p.state.flags|cmd.QModSlice)
 
-	subQ, err := mkParser(p.level+1, p.state.ref.String()).parse()
-	if err != nil {
-		return err
-	}
+	case strings.HasPrefix(inner, cmd.Maybe.String()):
+		predicate, err := p.onPredicate(inner)
+		if err != nil {
+			return err
+		}
 
-	curr := newQuery(
-		nil,
-		"["+subQ.String()+"]",
-		p.global|p.state.flags|cmd.QModSubQuery,
-	)
+		p.global |= cmd.QModBraces | cmd.QModIndeter | cmd.QModPattern
+		curr = newQuery(nil, predicate, p.global|p.state.flags|cmd.QModPredicate)
+
+	case strings.HasPrefix(inner, `"`):
+		// A key as a JSON string, as in `[""]`, the one way to write the empty
+		// key.
+		var key string
+		if err := json.Unmarshal([]byte(inner), &key); err != nil {
+			return queryerrors.UnexpectedTokenError(p.i, p.spec, p.c)
+		}
+
+		curr = newQuery(nil, key, p.global|p.state.flags|cmd.QModObj)
+
+	default:
+		subQ, err := mkParser(p.level+1, sub).parse()
+		if err != nil {
+			return err
+		}
+
+		p.global |= cmd.QModBraces | cmd.QModDyn
+		curr = newQuery(nil, subQ.String(), p.global|p.state.flags|cmd.QModSubQuery)
+	}
 
 	p.path = append(p.path, curr)
 
 	//nolint:exhaustruct // is made specifically for zero state.
 	p.state = state{}
+	p.state.closed = true
+	p.state.isFin = true
+	p.state.noCmd = true
 
 	seq := p.global.Seq()
 	p.global &= ^cmd.SequenceMask
@@ -272,15 +394,24 @@ func (p *parser) onBraceR() error {
 
 func (p *parser) onBraceL() error {
 	switch {
-	case p.state.inside:
-		// Nested bracket not supported
-		return queryerrors.UnexpectedTokenError(p.i, p.spec, p.c)
-
-	case p.level > 0:
+	case p.level >= int(p.maxDepth):
 		return queryerrors.NestingTooDeepError(p.i, p.spec)
+
+	case p.state.escaped:
+		panic(EF("unreachable: escaped bracket"))
+
+	case p.state.ref.Len() > 0:
+		// As in "users[...]", the key before the bracket is a segment of its own.
+		if err := p.onSep(); err != nil {
+			return err
+		}
+
+	case p.state.closed:
+		// As in "[...][...]".
+		//nolint:exhaustruct // is made specifically for zero state.
+		p.state = state{}
 	}
 
-	p.global |= cmd.QModBraces | cmd.QModDyn
 	p.state.inside = true
 
 	return nil
@@ -307,16 +438,21 @@ func (p *parser) doParse() error {
 
 	for p.i = range p.spec {
 		p.c = p.spec[p.i]
+
+		if p.state.inside {
+			if err := p.onInside(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
 		switch consumed, err := p.preParse(); {
 		case err != nil:
 			return err
 
 		case consumed:
 			continue
-
-		case p.state.inside && p.c != cmd.BraceR.Byte():
-			p.state.ref.WriteByte(p.c)
-			continue
 		}
 
 		switch p.c {
@@ -366,13 +502,16 @@ func (p *parser) doParse() error {
 			}
 
 		case cmd.BraceR.Byte():
-			if err := p.onBraceR(); err != nil {
-				return err
-			}
+			return queryerrors.UnexpectedTokenError(p.i, p.spec, p.c)
 
 		case cmd.At.Byte():
 			return queryerrors.UnexpectedTokenError(p.i, p.spec, p.c)
 
+		case cmd.Wildcard.Byte():
+			if err := p.onWildcard(); err != nil {
+				return err
+			}
+
 		default:
 			if err := p.onRune(); err != nil {
 				return err
@@ -385,6 +524,9 @@ func (p *parser) doParse() error {
 
 func (p *parser) parsePostValidate() error {
 	switch {
+	case p.state.inside:
+		return queryerrors.UnexpectedTokenError(p.i, p.spec, cmd.BraceL.Byte())
+
 	case len(p.path) == 0:
 		return queryerrors.EmptyError(p.i, p.spec)
 
@@ -418,6 +560,14 @@ func (p *parser) postProcess() {
 			p.path[i].flags |= cmd.QModIndeter
 		}
 
+		if p.global.IsDyn() {
+			p.path[i].flags |= cmd.QModDyn | cmd.QModBraces
+		}
+
+		if p.global.IsPattern() {
+			p.path[i].flags |= cmd.QModPattern
+		}
+
 		if i == 0 {
 			p.path[i].flags |= cmd.QModRoot
 		}
@@ -446,11 +596,28 @@ func (p *parser) parse() (GiraffeQuery, error) {
 	return p.path[0], nil
 }
 
+// endsWithSep reports whether spec ends in a separator, and not in an escaped
+// one as in `a\.`, which is part of the last key.
+func endsWithSep(
+	spec string,
+) bool {
+	if !strings.HasSuffix(spec, cmd.Sep.String()) {
+		return false
+	}
+
+	escapes := 0
+	for i := len(spec) - 2; i >= 0 && spec[i] == cmd.Escape.Byte(); i-- {
+		escapes++
+	}
+
+	return escapes%2 == 0
+}
+
 func mkParser(
 	level int,
 	spec string,
 ) *parser {
-	if !strings.HasSuffix(spec, cmd.Sep.String()) {
+	if !endsWithSep(spec) {
 		spec += cmd.Sep.String()
 	}
 
@@ -470,6 +637,10 @@ func mkParser(
 			noCmd:   false,
 			escaped: false,
 			inside:  false,
+			quoted:  false,
+			closed:  false,
+			literal: false,
+			depth:   0,
 		},
 	}
 }
@@ -0,0 +1,86 @@
+package gquery
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal/queryerrors"
+)
+
+// predicateOps are the comparisons of a predicate, longest first so that "<="
+// is not taken for "<".
+var predicateOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// splitPredicate splits the body of a predicate, as in `status=="active"`,
+// into the query on the left, the comparison and the JSON literal on the
+// right. A bare query, as in `status`, tests for existence and has neither.
+func splitPredicate(
+	body string,
+) (string, string, string) {
+	escaped := false
+	for i := range len(body) {
+		switch {
+		case escaped:
+			escaped = false
+			continue
+
+		case body[i] == cmd.Escape.Byte():
+			escaped = true
+			continue
+		}
+
+		for _, op := range predicateOps {
+			if strings.HasPrefix(body[i:], op) {
+				return strings.TrimSpace(body[:i]), op, strings.TrimSpace(body[i+len(op):])
+			}
+		}
+	}
+
+	return strings.TrimSpace(body), "", ""
+}
+
+// onPredicate validates the predicate captured in brackets, as in
+// `[?status=="active"]`, and makes its canonical form.
+func (p *parser) onPredicate(
+	inner string,
+) (string, error) {
+	left, op, right := splitPredicate(strings.TrimPrefix(inner, cmd.Maybe.String()))
+
+	switch {
+	case left == "",
+		op != "" && !json.Valid([]byte(right)):
+		return "", queryerrors.InvalidPredicateError(p.i, p.spec, inner)
+	}
+
+	subQ, err := mkParser(p.level+1, strings.TrimPrefix(left, cmd.Sep.String())).parse()
+	if err != nil {
+		return "", err
+	}
+
+	if subQ.flags.IsPattern() || subQ.flags.IsDyn() || !subQ.flags.IsReadonly() {
+		return "", queryerrors.InvalidPredicateError(p.i, p.spec, inner)
+	}
+
+	// Not being commands, "<" and ">" are left unescaped by the query, which
+	// would then be taken for a comparison.
+	canonical := strings.NewReplacer(
+		"<", cmd.Escape.String()+"<",
+		">", cmd.Escape.String()+">",
+	).Replace(subQ.String())
+
+	return cmd.Maybe.String() + canonical + op + right, nil
+}
+
+// Predicate is the query, the comparison and the JSON literal of a predicate
+// segment, as in `[?status=="active"]`. The query is relative to each child
+// tested, and the comparison is empty when the predicate only tests for
+// existence.
+func (q GiraffeQuery) Predicate() (string, string, string) {
+	if !q.flags.IsPredicate() {
+		panic(EF("unreachable: not a predicate: %s", q.String()))
+	}
+
+	return splitPredicate(strings.TrimPrefix(q.ref, cmd.Maybe.String()))
+}
@@ -2,6 +2,7 @@ package gquery
 
 import (
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/hkoosha/giraffe/cmd"
@@ -39,28 +40,65 @@ func (q GiraffeQuery) Dialect() dialects.Dialect {
 	return dialects.Giraffe1v1
 }
 
+// Escaped is the whole query, from root to leaf, in a form that parses back
+// to the same query.
 func (q GiraffeQuery) Escaped() string {
-	return Escaped(q.String())
+	return (*q.path)[0].string0()
 }
 
 func (q GiraffeQuery) Flags() cmd.QFlag {
 	return q.flags
 }
 
+// Attr is the key of an object segment, or an index as it is written, being
+// the key it addresses in an object. It is empty for any other segment.
 func (q GiraffeQuery) Attr() string {
-	if !q.flags.IsObj() {
+	if !q.flags.IsObj() && (!q.flags.IsArr() || q.flags.IsAppend()) {
 		return ""
 	}
 
 	return q.ref
 }
 
+// Index is the index of an array segment, negative if counted from the end.
 func (q GiraffeQuery) Index() int {
-	if !q.flags.IsArr() || q.flags.IsAppend() {
+	switch {
+	case !q.flags.IsArr() || q.flags.IsAppend():
 		return -1
+
+	case q.flags.IsNegative():
+		return -q.flags.Val()
+
+	default:
+		return q.flags.Val()
+	}
+}
+
+// Slice is the half-open range a slice segment selects in an array of length
+// n. Negative bounds count from the end, and both are clamped into [0, n].
+func (q GiraffeQuery) Slice(
+	n int,
+) (int, int) {
+	if !q.flags.IsSlice() {
+		panic(EF("unreachable: not a slice: %s", q.String()))
+	}
+
+	bound := func(s string, or int) int {
+		if s == "" {
+			return or
+		}
+
+		i := M(strconv.Atoi(s))
+		if i < 0 {
+			i += n
+		}
+
+		return min(max(i, 0), n)
 	}
 
-	return q.flags.Val()
+	from, to, _ := strings.Cut(q.ref, ":")
+
+	return bound(from, 0), bound(to, n)
 }
 
 func (q GiraffeQuery) Root() queryimpl.QueryImpl {
@@ -105,16 +143,34 @@ func (q GiraffeQuery) Resolved(
 			if err != nil {
 				return nil, err
 			}
+
+			sb.WriteString(p.flags.ReconstructPreMod())
 			sb.WriteString(v)
 		} else {
 			sb.WriteString(p.flags.ReconstructPreMod())
-			sb.WriteString(p.ref)
+			sb.WriteString(p.escapedRef())
 		}
 	}
 
 	return Parse(sb.String())
 }
 
+func (q GiraffeQuery) escapedRef() string {
+	switch {
+	case q.flags.IsSubQuery(), q.flags.IsSlice(), q.flags.IsPredicate():
+		return cmd.BraceL.String() + q.ref + cmd.BraceR.String()
+
+	case q.flags.IsWildcard(), q.flags.IsDescent(), q.flags.IsArr():
+		return q.ref
+
+	case q.flags.IsSelf() && q.ref == "":
+		// A bare self, rather than the empty key.
+		return q.ref
+	}
+
+	return Escaped(q.ref)
+}
+
 func (q GiraffeQuery) String() string {
 	return q.string0()
 }
@@ -132,7 +188,7 @@ func (q GiraffeQuery) string0() string {
 		}
 
 		sb.WriteString(p.flags.ReconstructPreMod())
-		sb.WriteString(p.ref)
+		sb.WriteString(p.escapedRef())
 	}
 
 	return sb.String()
@@ -149,7 +205,7 @@ func (q GiraffeQuery) bef(
 	for i := range q.flags.Seq() {
 		qI := path[i]
 		sb.WriteString(qI.flags.ReconstructPreMod())
-		sb.WriteString(qI.ref)
+		sb.WriteString(qI.escapedRef())
 		sb.WriteByte(cmd.Sep.Byte())
 	}
 }
@@ -167,7 +223,7 @@ func (q GiraffeQuery) aft(
 
 		qI := path[i]
 		sb.WriteString(qI.flags.ReconstructPreMod())
-		sb.WriteString(qI.ref)
+		sb.WriteString(qI.escapedRef())
 	}
 }
 
@@ -202,7 +258,7 @@ func (q GiraffeQuery) reconstructInAs(
 	flags cmd.QFlag,
 ) {
 	flags.ReconstructPreModIn(sb)
-	sb.WriteString(q.ref)
+	sb.WriteString(q.escapedRef())
 }
 
 func (q GiraffeQuery) at(
@@ -271,7 +327,7 @@ func (q GiraffeQuery) PlusS(other string) queryimpl.QueryImpl {
 	q.bef(&sb)
 
 	sb.WriteString(q.flags.ReconstructPreMod())
-	sb.WriteString(q.ref)
+	sb.WriteString(q.escapedRef())
 	sb.WriteByte(cmd.Sep.Byte())
 	sb.WriteString(other)
 
@@ -0,0 +1,552 @@
+// Package jsonpath translates RFC 9535 JSONPath queries into the giraffe1v1
+// dialect.
+//
+// Names, indices, wildcards, descendants, slices without a step and filters
+// comparing a single relative query against a literal are supported, while
+// unions, functions and logical operators in filters are not.
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/dialects"
+	"github.com/hkoosha/giraffe/internal/queryerrors"
+	"github.com/hkoosha/giraffe/internal/queryimpl"
+	"github.com/hkoosha/giraffe/internal/queryimpl/gquery"
+)
+
+var (
+	digitRegex = regexp.MustCompile(`^-?\d+$`)
+	nameRegex  = regexp.MustCompile(`^[A-Za-z_\x{80}-\x{10FFFF}][A-Za-z0-9_\x{80}-\x{10FFFF}]*$`)
+	sliceRegex = regexp.MustCompile(`^(-?\d+)?:(-?\d+)?(:1?)?$`)
+)
+
+// filterOps are the comparisons of a filter, longest first so that "<=" is
+// not taken for "<".
+var filterOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+type scanner struct {
+	spec string
+	i    int
+}
+
+func (s *scanner) done() bool {
+	return s.i >= len(s.spec)
+}
+
+func (s *scanner) peek(
+	c byte,
+) bool {
+	return !s.done() && s.spec[s.i] == c
+}
+
+func (s *scanner) eat(
+	c byte,
+) bool {
+	if s.peek(c) {
+		s.i++
+		return true
+	}
+
+	return false
+}
+
+func (s *scanner) ws() {
+	for !s.done() && strings.IndexByte(" \t\n\r", s.spec[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+func (s *scanner) unexpected() error {
+	if s.done() {
+		return queryerrors.EmptyError(s.i, s.spec)
+	}
+
+	return queryerrors.UnexpectedTokenError(s.i, s.spec, s.spec[s.i])
+}
+
+func (s *scanner) unsupported(
+	what string,
+) error {
+	return queryerrors.UnsupportedError(s.i, s.spec, what)
+}
+
+// segments reads the segments following "$" or "@", each in the giraffe1v1
+// dialect. Within a filter, patterns are left to the giraffe1v1 parser to
+// reject.
+func (s *scanner) segments() ([]string, error) {
+	var segs []string
+
+	for {
+		switch {
+		case strings.HasPrefix(s.spec[s.i:], ".."):
+			s.i += 2
+			segs = append(segs, cmd.Wildcard.String()+cmd.Wildcard.String())
+
+			if s.peek('[') {
+				continue
+			}
+
+			seg, err := s.dotted()
+			if err != nil {
+				return nil, err
+			}
+
+			segs = append(segs, seg)
+
+		case s.eat('.'):
+			seg, err := s.dotted()
+			if err != nil {
+				return nil, err
+			}
+
+			segs = append(segs, seg)
+
+		case s.eat('['):
+			seg, err := s.bracketed()
+			if err != nil {
+				return nil, err
+			}
+
+			segs = append(segs, seg)
+
+		default:
+			return segs, nil
+		}
+	}
+}
+
+// dotted reads a wildcard or a member name shorthand, as in ".name".
+func (s *scanner) dotted() (string, error) {
+	if s.eat('*') {
+		return cmd.Wildcard.String(), nil
+	}
+
+	from := s.i
+	for !s.done() {
+		c := s.spec[s.i]
+		isAlpha := c >= 0x80 || c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+		if !isAlpha && (s.i == from || c < '0' || '9' < c) {
+			break
+		}
+
+		s.i++
+	}
+
+	if s.i == from {
+		return "", s.unexpected()
+	}
+
+	return gquery.Escaped(s.spec[from:s.i]), nil
+}
+
+// bracketed reads a single selector up to the closing bracket.
+func (s *scanner) bracketed() (string, error) {
+	s.ws()
+
+	var seg string
+
+	switch {
+	case s.peek('\'') || s.peek('"'):
+		name, err := s.str()
+		if err != nil {
+			return "", err
+		}
+
+		seg = gquery.Escaped(name)
+
+	case s.eat('*'):
+		seg = cmd.Wildcard.String()
+
+	case s.eat('?'):
+		filter, err := s.filter()
+		if err != nil {
+			return "", err
+		}
+
+		seg = filter
+
+	default:
+		from := s.i
+		for !s.done() && strings.IndexByte("-0123456789: \t\n\r", s.spec[s.i]) >= 0 {
+			s.i++
+		}
+
+		sel := strings.Join(strings.Fields(s.spec[from:s.i]), "")
+
+		switch {
+		case digitRegex.MatchString(sel):
+			seg = sel
+
+		case sliceRegex.MatchString(sel):
+			// A step of one, the default, is all there is to a giraffe1v1 slice.
+			if strings.Count(sel, ":") == 2 {
+				sel = sel[:strings.LastIndexByte(sel, ':')]
+			}
+
+			seg = cmd.BraceL.String() + sel + cmd.BraceR.String()
+
+		case strings.Count(sel, ":") > 1:
+			return "", s.unsupported("slice step")
+
+		default:
+			return "", s.unexpected()
+		}
+	}
+
+	s.ws()
+
+	switch {
+	case s.peek(','):
+		return "", s.unsupported("union")
+
+	case !s.eat(']'):
+		return "", s.unexpected()
+	}
+
+	return seg, nil
+}
+
+// str reads a quoted string, in either quotes.
+func (s *scanner) str() (string, error) {
+	quote := s.spec[s.i]
+	s.i++
+
+	from := s.i
+	for !s.done() && s.spec[s.i] != quote {
+		if s.spec[s.i] == '\\' {
+			s.i++
+		}
+
+		s.i++
+	}
+
+	if s.done() {
+		return "", s.unexpected()
+	}
+
+	raw := s.spec[from:s.i]
+	s.i++
+
+	if quote == '\'' {
+		raw = singleQuoted(raw)
+	}
+
+	var fin string
+	if err := json.Unmarshal([]byte(`"`+raw+`"`), &fin); err != nil {
+		return "", queryerrors.UnexpectedTokenError(from, s.spec, quote)
+	}
+
+	return fin, nil
+}
+
+// singleQuoted is the body of a single-quoted string as that of a double-quoted
+// one, with \' unescaped and " escaped.
+func singleQuoted(
+	raw string,
+) string {
+	sb := strings.Builder{}
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == '\'':
+			sb.WriteByte('\'')
+			i++
+
+		case raw[i] == '\\' && i+1 < len(raw):
+			sb.WriteString(raw[i : i+2])
+			i++
+
+		case raw[i] == '"':
+			sb.WriteString(`\"`)
+
+		default:
+			sb.WriteByte(raw[i])
+		}
+	}
+
+	return sb.String()
+}
+
+// filter reads a filter after "?", as in `@.status == 'active'` or `@.id`,
+// optionally in parentheses.
+func (s *scanner) filter() (string, error) {
+	s.ws()
+	parens := s.eat('(')
+	s.ws()
+
+	if !s.eat('@') {
+		return "", s.unsupported("filter not starting with @")
+	}
+
+	segs, err := s.segments()
+	if err != nil {
+		return "", err
+	}
+
+	left := cmd.Self.String()
+	if len(segs) > 0 {
+		for i, seg := range segs {
+			segs[i] = predicateEscaped(seg)
+		}
+
+		left = strings.Join(segs, cmd.Sep.String())
+	}
+
+	s.ws()
+
+	op := ""
+	for _, it := range filterOps {
+		if strings.HasPrefix(s.spec[s.i:], it) {
+			op = it
+			s.i += len(it)
+
+			break
+		}
+	}
+
+	literal := ""
+	if op != "" {
+		s.ws()
+
+		if literal, err = s.literal(); err != nil {
+			return "", err
+		}
+	}
+
+	s.ws()
+
+	switch {
+	case strings.HasPrefix(s.spec[s.i:], "&&"),
+		strings.HasPrefix(s.spec[s.i:], "||"):
+		return "", s.unsupported("logical operator")
+
+	case parens && !s.eat(')'):
+		return "", s.unexpected()
+	}
+
+	return cmd.BraceL.String() +
+		cmd.Maybe.String() + left + op + literal +
+		cmd.BraceR.String(), nil
+}
+
+// literal reads the literal a filter compares against, as JSON.
+func (s *scanner) literal() (string, error) {
+	if s.peek('\'') || s.peek('"') {
+		str, err := s.str()
+		if err != nil {
+			return "", err
+		}
+
+		return quoted(str), nil
+	}
+
+	from := s.i
+	for !s.done() && strings.IndexByte(" \t\n\r)]&|", s.spec[s.i]) < 0 {
+		s.i++
+	}
+
+	literal := s.spec[from:s.i]
+	if literal == "" || strings.ContainsAny(literal[:1], "[{") || !json.Valid([]byte(literal)) {
+		return "", queryerrors.UnexpectedTokenError(from, s.spec, s.spec[min(from, len(s.spec)-1)])
+	}
+
+	return literal, nil
+}
+
+// predicateEscaped escapes what would otherwise end the query of a giraffe1v1
+// predicate.
+func predicateEscaped(
+	seg string,
+) string {
+	sb := strings.Builder{}
+	for i := range len(seg) {
+		if strings.IndexByte(`"<>`, seg[i]) >= 0 {
+			sb.WriteByte(cmd.Escape.Byte())
+		}
+
+		sb.WriteByte(seg[i])
+	}
+
+	return sb.String()
+}
+
+// Translated is spec in the giraffe1v1 dialect.
+func Translated(
+	spec string,
+) (string, error) {
+	s := &scanner{spec: spec, i: 0}
+
+	s.ws()
+	if !s.eat('$') {
+		return "", s.unexpected()
+	}
+
+	segs, err := s.segments()
+	if err != nil {
+		return "", err
+	}
+
+	s.ws()
+	if !s.done() {
+		return "", s.unexpected()
+	}
+
+	if len(segs) == 0 {
+		return cmd.Self.String(), nil
+	}
+
+	return strings.Join(segs, cmd.Sep.String()), nil
+}
+
+func Parse(
+	spec string,
+) (gquery.GiraffeQuery, error) {
+	translated, err := Translated(spec)
+	if err != nil {
+		return gquery.GiraffeQuery{}, err
+	}
+
+	return gquery.Parse(translated)
+}
+
+// Exported is q as a JSONPath query, the reverse of Translated. Modifiers,
+// sub-queries and a trailing recursive descent have no JSONPath counterpart.
+func Exported(
+	q queryimpl.QueryImpl,
+) (string, error) {
+	sb := strings.Builder{}
+	sb.WriteByte('$')
+
+	if err := exportedIn(&sb, q, q.Root()); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// exportedIn writes the segments from "from" to the leaf in sb.
+func exportedIn(
+	sb *strings.Builder,
+	q queryimpl.QueryImpl,
+	from queryimpl.QueryImpl,
+) error {
+	descent := false
+	for at := from; ; at = at.Next() {
+		f := at.Flags()
+
+		// After "..", a shorthand takes no dot of its own.
+		dot := "."
+		if descent {
+			dot = ""
+		}
+
+		switch {
+		case f.ReconstructPreMod() == cmd.Self.String():
+
+		case f.IsDyn(), f.ReconstructPreMod() != "":
+			return notExportable(q, at)
+
+		case f.IsDescent() && (f.IsLeaf() || descent):
+			return notExportable(q, at)
+
+		case f.IsDescent():
+			sb.WriteString("..")
+
+		case f.IsWildcard():
+			sb.WriteString(dot)
+			sb.WriteByte('*')
+
+		case f.IsSlice():
+			sb.WriteString(at.Reconstructed())
+
+		case f.IsPredicate():
+			if err := predicateIn(sb, q, at); err != nil {
+				return err
+			}
+
+		case f.IsArr():
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(at.Index()))
+			sb.WriteByte(']')
+
+		case nameRegex.MatchString(at.Attr()):
+			sb.WriteString(dot)
+			sb.WriteString(at.Attr())
+
+		default:
+			sb.WriteByte('[')
+			sb.WriteString(quoted(at.Attr()))
+			sb.WriteByte(']')
+		}
+
+		descent = f.IsDescent()
+
+		if f.IsLeaf() {
+			return nil
+		}
+	}
+}
+
+// predicateIn writes the predicate segment at as a filter in sb.
+func predicateIn(
+	sb *strings.Builder,
+	q queryimpl.QueryImpl,
+	at queryimpl.QueryImpl,
+) error {
+	left, op, literal := at.Predicate()
+
+	lq, err := gquery.Parse(left)
+	if err != nil {
+		return err
+	}
+
+	sb.WriteString("[?@")
+
+	if err := exportedIn(sb, q, lq.Root()); err != nil {
+		return err
+	}
+
+	if op != "" {
+		if strings.ContainsAny(literal[:1], "[{") {
+			return notExportable(q, at)
+		}
+
+		sb.WriteByte(' ')
+		sb.WriteString(op)
+		sb.WriteByte(' ')
+		sb.WriteString(literal)
+	}
+
+	sb.WriteByte(']')
+
+	return nil
+}
+
+// quoted is str as a JSON string, which is a JSONPath string as well.
+func quoted(
+	str string,
+) string {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	OK(enc.Encode(str))
+
+	return strings.TrimSpace(buf.String())
+}
+
+func notExportable(
+	q queryimpl.QueryImpl,
+	at queryimpl.QueryImpl,
+) error {
+	return queryerrors.NewNotExportableError(
+		q.Escaped(),
+		dialects.JsonPath.String(),
+		"segment="+at.Reconstructed(),
+	)
+}
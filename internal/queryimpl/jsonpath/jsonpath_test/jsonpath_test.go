@@ -0,0 +1,159 @@
+package jsonpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal/queryimpl/gquery"
+	"github.com/hkoosha/giraffe/internal/queryimpl/jsonpath"
+)
+
+func TestTranslated(t *testing.T) {
+	t.Run("translated", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string]string{
+			"$":                               "#",
+			"$.a.b":                           "a.b",
+			"$['a']['b c']":                   "a.b c",
+			`$["a.b"]`:                        `a\.b`,
+			`$['it\'s']`:                      "it's",
+			"$.items[0].id":                   "items.0.id",
+			"$.items[-1]":                     "items.-1",
+			"$.items[*].id":                   "items.*.id",
+			"$.items.*":                       "items.*",
+			"$..id":                           "**.id",
+			"$..*":                            "**.*",
+			"$..[0]":                          "**.0",
+			"$.items[1:3]":                    "items.[1:3]",
+			"$.items[:-1]":                    "items.[:-1]",
+			"$.items[2:]":                     "items.[2:]",
+			"$.items[::1]":                    "items.[:]",
+			"$.items[1:1]":                    "items.[1:1]",
+			"$.items[ 0 ]":                    "items.0",
+			`$.items[?@.status == 'active']`:  `items.[?status=="active"]`,
+			`$.items[?(@.price < 10)].id`:     `items.[?price<10].id`,
+			`$.items[?@.a.b>=1.5]`:            `items.[?a.b>=1.5]`,
+			`$.items[?@['x<y'] != null]`:      `items.[?x\<y!=null]`,
+			`$.items[?@.id]`:                  `items.[?id]`,
+			`$.items[?@ == "a]b"]`:            `items.[?#=="a]b"]`,
+			`$.items[?@.q == 'say "hi"']`:     `items.[?q=="say \"hi\""]`,
+			`$.items[?@.on == true].name`:     `items.[?on==true].name`,
+			`$.ünï`:                           "ünï",
+			` $.a `:                           "a",
+			`$.items[?(@.status=="x")][0]`:    `items.[?status=="x"].0`,
+			`$.items[?@.name=='a\\b']`:        `items.[?name=="a\\b"]`,
+			`$.items[?@.n==-2]`:               `items.[?n==-2]`,
+			`$.items[?@.n=='']`:               `items.[?n==""]`,
+			`$['$make']`:                      `\$make`,
+			`$.items[?@.name=='<&>']`:         `items.[?name=="<&>"]`,
+			`$.items[?@.x=='a\'b']`:           `items.[?x=="a'b"]`,
+			`$.items[?@.s == null].id`:        `items.[?s==null].id`,
+			`$.items[?( @.s )].id`:            `items.[?s].id`,
+			`$.items[?@[0] == 1]`:             `items.[?0==1]`,
+			`$.items[?@.s == 'a'].tags[-2:]`:  `items.[?s=="a"].tags.[-2:]`,
+			`$..items[?@.s == 'a']..id`:       `**.items.[?s=="a"].**.id`,
+			`$.items[?@.s == 'a'].tags[*][0]`: `items.[?s=="a"].tags.*.0`,
+			`$['0']`:                          `\0`,
+			`$.a['-1'][0]`:                    `a.\-1.0`,
+			`$['']`:                           `[""]`,
+			`$.a[''].b`:                       `a.[""].b`,
+		} {
+			actual, err := jsonpath.Translated(spec)
+			require.NoError(t, err, spec)
+			assert.Equal(t, expected, actual, spec)
+
+			_, err = jsonpath.Parse(spec)
+			require.NoError(t, err, spec)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, spec := range []string{
+			"",
+			"a.b",
+			"$.",
+			"$..",
+			"$[",
+			"$[0",
+			"$['a'",
+			"$['a','b']",
+			"$[0,1]",
+			"$[1:5:2]",
+			"$[?@.a == 1 && @.b == 2]",
+			"$[?@.a == 1 || @.b == 2]",
+			"$[?1 == @.a]",
+			"$[?@.a == ]",
+			"$[?@.a == bare]",
+			"$[?@.a == [1]]",
+			"$[?(@.a == 1]",
+			"$[?@.* == 1]",
+			"$.a b",
+			"$.1a",
+		} {
+			_, err := jsonpath.Parse(spec)
+			require.Error(t, err, spec)
+		}
+	})
+}
+
+func TestExported(t *testing.T) {
+	t.Run("exported", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string]string{
+			"#":                    "$",
+			"a.b":                  "$.a.b",
+			"a b.c":                `$["a b"].c`,
+			`a\.b`:                 `$["a.b"]`,
+			"items.0.id":           "$.items[0].id",
+			"items.-1":             "$.items[-1]",
+			"items.*.id":           "$.items.*.id",
+			"**.id":                "$..id",
+			"**.*":                 "$..*",
+			"**.0":                 "$..[0]",
+			"items[1:3]":           "$.items[1:3]",
+			"items[:-1]":           "$.items[:-1]",
+			`items[?status=="on"]`: `$.items[?@.status == "on"]`,
+			`items[?a.b>=1.5].id`:  `$.items[?@.a.b >= 1.5].id`,
+			`items[?id]`:           `$.items[?@.id]`,
+			`items[?#==1]`:         `$.items[?@ == 1]`,
+			`items[?x\<y!=null]`:   `$.items[?@["x<y"] != null]`,
+			`\0`:                   `$["0"]`,
+			`a.\-1.0`:              `$.a["-1"][0]`,
+			`a.[""].b`:             `$.a[""].b`,
+		} {
+			actual, err := jsonpath.Exported(M(gquery.Parse(spec)))
+			require.NoError(t, err, spec)
+			assert.Equal(t, expected, actual, spec)
+
+			back, err := jsonpath.Parse(actual)
+			require.NoError(t, err, spec)
+			assert.Equal(t, M(gquery.Parse(spec)).Escaped(), back.Escaped(), spec)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, spec := range []string{
+			"a.**",
+			"a.+",
+			"a[b]",
+			"$a.b",
+			"=a",
+			"!a",
+			"?a",
+			`a[?b=={"c":1}]`,
+		} {
+			_, err := jsonpath.Exported(M(gquery.Parse(spec)))
+			require.Error(t, err, spec)
+		}
+	})
+}
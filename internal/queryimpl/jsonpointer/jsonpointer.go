@@ -0,0 +1,141 @@
+// Package jsonpointer translates RFC 6901 JSON Pointers into the giraffe1v1
+// dialect.
+package jsonpointer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	"github.com/hkoosha/giraffe/dialects"
+	"github.com/hkoosha/giraffe/internal/queryerrors"
+	"github.com/hkoosha/giraffe/internal/queryimpl"
+	"github.com/hkoosha/giraffe/internal/queryimpl/gquery"
+)
+
+var indexRegex = regexp.MustCompile(`^(0|[1-9]\d*)$`)
+
+// Tokens is the unescaped reference tokens of ptr, none for the whole
+// document.
+func Tokens(
+	ptr string,
+) ([]string, error) {
+	if ptr == "" {
+		return []string{}, nil
+	}
+
+	if ptr[0] != '/' {
+		return nil, queryerrors.UnexpectedTokenError(0, ptr, ptr[0])
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	for i, t := range tokens {
+		t = strings.ReplaceAll(t, "~1", "/")
+		t = strings.ReplaceAll(t, "~0", "~")
+		tokens[i] = t
+	}
+
+	return tokens, nil
+}
+
+// Translated is ptr in the giraffe1v1 dialect. A token being an index, as "0"
+// is, stays an index, which addresses the key of the same name in an object,
+// and "-" appends past the end of an array. Any other token is a key, the
+// empty one and those such as "01" or "-1" included.
+func Translated(
+	ptr string,
+) (string, error) {
+	tokens, err := Tokens(ptr)
+	if err != nil {
+		return "", err
+	}
+
+	if len(tokens) == 0 {
+		return cmd.Self.String(), nil
+	}
+
+	segs := make([]string, len(tokens))
+	for i, t := range tokens {
+		switch {
+		case t == "-":
+			segs[i] = cmd.Append.String()
+
+		case indexRegex.MatchString(t):
+			segs[i] = t
+
+		default:
+			segs[i] = gquery.Escaped(t)
+		}
+	}
+
+	return strings.Join(segs, cmd.Sep.String()), nil
+}
+
+func Parse(
+	ptr string,
+) (gquery.GiraffeQuery, error) {
+	spec, err := Translated(ptr)
+	if err != nil {
+		return gquery.GiraffeQuery{}, err
+	}
+
+	return gquery.Parse(spec)
+}
+
+// Exported is q as a JSON Pointer, the reverse of Translated. Only keys and
+// indices have a JSON Pointer counterpart, appending does not, as "-" names
+// no element to read.
+func Exported(
+	q queryimpl.QueryImpl,
+) (string, error) {
+	sb := strings.Builder{}
+
+	for at := q.Root(); ; at = at.Next() {
+		f := at.Flags()
+
+		switch {
+		case f.IsDyn(), f.IsWildcard(), f.IsDescent(), f.IsSlice(), f.IsPredicate(), f.IsNegative():
+			return "", notExportable(q, at)
+
+		case f.ReconstructPreMod() == cmd.Self.String():
+
+		case f.ReconstructPreMod() != "":
+			return "", notExportable(q, at)
+
+		case f.IsArr() && !indexRegex.MatchString(at.Attr()):
+			// As "01" is, an index against an array but a key in a pointer.
+			return "", notExportable(q, at)
+
+		case f.IsArr():
+			sb.WriteByte('/')
+			sb.WriteString(strconv.Itoa(at.Index()))
+
+		case at.Attr() == "-":
+			// A token of its own, appending.
+			return "", notExportable(q, at)
+
+		default:
+			t := strings.ReplaceAll(at.Attr(), "~", "~0")
+			t = strings.ReplaceAll(t, "/", "~1")
+
+			sb.WriteByte('/')
+			sb.WriteString(t)
+		}
+
+		if f.IsLeaf() {
+			return sb.String(), nil
+		}
+	}
+}
+
+func notExportable(
+	q queryimpl.QueryImpl,
+	at queryimpl.QueryImpl,
+) error {
+	return queryerrors.NewNotExportableError(
+		q.Escaped(),
+		dialects.JsonPointer.String(),
+		"segment="+at.Reconstructed(),
+	)
+}
@@ -0,0 +1,118 @@
+package jsonpointer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hkoosha/giraffe/core/gtesting"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal/queryimpl/gquery"
+	"github.com/hkoosha/giraffe/internal/queryimpl/jsonpointer"
+)
+
+func TestTranslated(t *testing.T) {
+	t.Run("translated", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for ptr, expected := range map[string]string{
+			"":             "#",
+			"/a":           "a",
+			"/a/0/b":       "a.0.b",
+			"/a~1b/c~0d":   `a/b.c\~d`,
+			"/a.b/c[d]":    `a\.b.c\[d\]`,
+			"/list/-":      "list.+",
+			"/x/10":        "x.10",
+			"/star*/$make": `star\*.\$make`,
+			"/01":          `\01`,
+			"/a/-1":        `a.\-1`,
+			"/":            `[""]`,
+			"/a//b":        `a.[""].b`,
+			"/a/":          `a.[""]`,
+		} {
+			actual, err := jsonpointer.Translated(ptr)
+			require.NoError(t, err, ptr)
+			assert.Equal(t, expected, actual, ptr)
+
+			_, err = jsonpointer.Parse(ptr)
+			require.NoError(t, err, ptr)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, ptr := range []string{
+			"a",
+			"#",
+		} {
+			_, err := jsonpointer.Translated(ptr)
+			require.Error(t, err, ptr)
+		}
+	})
+
+	t.Run("tokens", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		tokens, err := jsonpointer.Tokens("/a~1b/~01")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a/b", "~1"}, tokens)
+
+		tokens, err = jsonpointer.Tokens("")
+		require.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+}
+
+func TestExported(t *testing.T) {
+	t.Run("exported", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for spec, expected := range map[string]string{
+			"#":           "",
+			"a":           "/a",
+			"a.0.b":       "/a/0/b",
+			`a/b.c\~d`:    "/a~1b/c~0d",
+			`a\.b.c\[d\]`: "/a.b/c[d]",
+			`a.0.\-1`:     "/a/0/-1",
+			`[""].b`:      "//b",
+		} {
+			actual, err := jsonpointer.Exported(M(gquery.Parse(spec)))
+			require.NoError(t, err, spec)
+			assert.Equal(t, expected, actual, spec)
+
+			back, err := jsonpointer.Parse(actual)
+			require.NoError(t, err, spec)
+			assert.Equal(t, M(gquery.Parse(spec)).Escaped(), back.Escaped(), spec)
+		}
+
+		actual, err := jsonpointer.Exported(M(gquery.Parse("#.a")))
+		require.NoError(t, err)
+		assert.Equal(t, "/a", actual)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		gtesting.Preamble(t)
+
+		for _, spec := range []string{
+			"a.*",
+			"**.a",
+			"a.-1",
+			"a.[1:]",
+			`a[?b==1]`,
+			"a[b]",
+			"$a.b",
+			"=a",
+			"!a",
+			"?a",
+			"a.+.b",
+			"list.+",
+			"a.01",
+			`a.\-`,
+		} {
+			_, err := jsonpointer.Exported(M(gquery.Parse(spec)))
+			require.Error(t, err, spec)
+		}
+	})
+}
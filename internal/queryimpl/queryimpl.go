@@ -13,14 +13,17 @@ const MaxDepth = 255
 type QueryImpl interface {
 	fmt.Stringer
 
-	// Resolved(func(query string) (data string, _ error)) (QueryImpl, error)
+	Resolved(func(query string) (data string, _ error)) (QueryImpl, error)
 
 	Flags() cmd.QFlag
 	Dialect() dialects.Dialect
 	Escaped() string
+	Reconstructed() string
 
 	Attr() string
 	Index() int
+	Slice(n int) (int, int)
+	Predicate() (query string, op string, literal string)
 
 	Root() QueryImpl
 	Leaf() QueryImpl
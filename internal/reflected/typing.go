@@ -17,6 +17,9 @@ var (
 	TU32   = reflect.TypeOf((*uint32)(nil)).Elem()
 	TU64   = reflect.TypeOf((*uint64)(nil)).Elem()
 
+	TF32 = reflect.TypeOf((*float32)(nil)).Elem()
+	TF64 = reflect.TypeOf((*float64)(nil)).Elem()
+
 	TStr = reflect.TypeOf((*string)(nil)).Elem()
 
 	TErr = reflect.TypeOf((*error)(nil)).Elem()
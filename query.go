@@ -3,6 +3,7 @@ package giraffe
 import (
 	"github.com/hkoosha/giraffe/cmd"
 	"github.com/hkoosha/giraffe/dialects"
+	"github.com/hkoosha/giraffe/internal"
 )
 
 // Query NEVER INSTANTIATE DIRECTLY. NEVER CAST TO. NEVER CAST FROM.
@@ -44,6 +45,15 @@ func (q Query) Dialect() dialects.Dialect {
 	return q.impl().Dialect()
 }
 
+// ExportAs is q, whichever dialect it was written in, as a query of format
+// without the dialect prefix, as in "/users/0/name" for dialects.JsonPointer.
+// Modifiers, and other features format has no counterpart for, are an error.
+func (q Query) ExportAs(
+	format dialects.Dialect,
+) (string, error) {
+	return internal.Exported(q.impl(), format)
+}
+
 func (q Query) Parser() func(string) (Query, error) {
 	return GQParser(q.impl().String())
 }
@@ -64,12 +74,16 @@ func (q Query) WithoutOverwrite() Query {
 	return Query(q.impl().WithoutOverwrite().String())
 }
 
-// func (q Query) Resolved(
-// 	resolver func(query string) (data string, _ error),
-// ) (Query, error) {
-// 	r, err := q.impl().Resolved(resolver)
-// 	if err != nil {
-// 		return "", err
-// 	}
-// 	return Query(r.String()), nil
-// }
+// Resolved substitutes each bracketed sub-query, as in "users[.selected].name",
+// with what resolver returns for it, which is taken as an escaped segment.
+// Nested brackets are passed to resolver as is.
+func (q Query) Resolved(
+	resolver func(query string) (data string, _ error),
+) (Query, error) {
+	r, err := q.impl().Resolved(resolver)
+	if err != nil {
+		return "", err
+	}
+
+	return Query(r.String()), nil
+}
@@ -0,0 +1,34 @@
+package giraffe
+
+// Compiled reads a fixed set of queries off a Datum in a single traversal,
+// walking each prefix the queries share once. It is safe for concurrent use.
+type Compiled struct {
+	queries []Query
+	root    *compiledNode
+	dynamic []int
+}
+
+// Compile makes the queries into a Compiled, to read them all off many datums.
+// Queries are read-only and deterministic, as with Datum.Get. Dynamic queries,
+// depending on the datum read, are not compiled and are read with Datum.Get.
+//
+//	c, err := giraffe.Compile(Q("user.name"), Q("user.email"), Q("items.0.id"))
+//	values, err := c.Get(d)
+func Compile(
+	queries ...Query,
+) (*Compiled, error) {
+	return compile(queries)
+}
+
+// Queries are the queries c was compiled from, in order.
+func (c *Compiled) Queries() []Query {
+	return append([]Query(nil), c.queries...)
+}
+
+// Get is what Datum.Get returns for each query c was compiled from, in order.
+// If any of the queries fails, the error is that of the first failing one.
+func (c *Compiled) Get(
+	d Datum,
+) ([]Datum, error) {
+	return c.get(d)
+}
@@ -0,0 +1,233 @@
+package giraffe
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/hkoosha/giraffe/internal"
+	"github.com/hkoosha/giraffe/internal/vendored/radix"
+)
+
+// compiledNode is a segment shared by the queries going through it.
+type compiledNode struct {
+	// seg is the segment of the first query going through the node, the one
+	// errors are made of.
+	seg   queryT
+	attr  string
+	index int
+	arr   bool
+
+	// first is the first query at or under the node, in the order compiled.
+	first   int
+	outputs []int
+	next    []*compiledNode
+}
+
+// compiledPath is a query as a list of segments, self segments left out.
+type compiledPath struct {
+	tokens  []string
+	segs    []queryT
+	outputs []int
+}
+
+// compiledToken identifies a segment, its kind and its length prefixed to
+// the ref, so that no key is a prefix of another unless the segments are.
+func compiledToken(
+	q queryT,
+) string {
+	ref := q.Attr()
+	kind := "o"
+	if q.Flags().IsArr() {
+		ref = strconv.Itoa(q.Index())
+		kind = "a"
+	}
+
+	return kind + strconv.Itoa(len(ref)) + ":" + ref
+}
+
+func compile(
+	queries []Query,
+) (*Compiled, error) {
+	c := &Compiled{
+		queries: slices.Clone(queries),
+		root:    &compiledNode{first: len(queries)},
+		dynamic: nil,
+	}
+
+	// The radix tree dedupes the queries, and walks them in order so that the
+	// ones sharing a prefix come one after another.
+	paths := radix.New()
+
+	for i, query := range queries {
+		q, err := internal.Parse(string(query))
+		if err != nil {
+			return nil, err
+		}
+
+		if err = readonly(q); err != nil {
+			return nil, err
+		}
+
+		qf := q.Flags()
+		switch {
+		case qf.IsIndeterministic():
+			return nil, newDataReadIndeterministicQueryError(q)
+
+		case qf.IsDyn():
+			c.dynamic = append(c.dynamic, i)
+			continue
+		}
+
+		p := &compiledPath{}
+		key := ""
+		for at := q.Root(); ; at = at.Next() {
+			if !at.Flags().IsSelf() {
+				token := compiledToken(at)
+				p.tokens = append(p.tokens, token)
+				p.segs = append(p.segs, at)
+				key += token
+			}
+
+			if at.Flags().IsLeaf() {
+				break
+			}
+		}
+
+		if prev, ok := paths.Get(key); ok {
+			p = prev.(*compiledPath) //nolint:forcetypeassert
+		}
+
+		p.outputs = append(p.outputs, i)
+		paths.Insert(key, p)
+	}
+
+	paths.Walk(func(_ string, v any) bool {
+		p := v.(*compiledPath) //nolint:forcetypeassert
+
+		n := c.root
+		n.first = min(n.first, p.outputs[0])
+		for j, token := range p.tokens {
+			// Walked in order, a shared segment is always the last one added.
+			if last := len(n.next) - 1; last >= 0 && compiledToken(n.next[last].seg) == token {
+				n = n.next[last]
+				if p.outputs[0] < n.first {
+					n.seg = p.segs[j]
+				}
+			} else {
+				seg := p.segs[j]
+				child := &compiledNode{
+					seg:     seg,
+					attr:    seg.Attr(),
+					index:   seg.Index(),
+					arr:     seg.Flags().IsArr(),
+					first:   p.outputs[0],
+					outputs: nil,
+					next:    nil,
+				}
+				n.next = append(n.next, child)
+				n = child
+			}
+
+			n.first = min(n.first, p.outputs[0])
+		}
+
+		n.outputs = append(n.outputs, p.outputs...)
+
+		return false
+	})
+
+	return c, nil
+}
+
+func (c *Compiled) get(
+	d Datum,
+) ([]Datum, error) {
+	out := make([]Datum, len(c.queries))
+
+	failed := len(c.queries)
+	var err error
+	fail := func(at int, e error) {
+		if at < failed {
+			failed = at
+			err = e
+		}
+	}
+
+	c.root.get(d, out, fail)
+
+	for _, i := range c.dynamic {
+		if i > failed {
+			break
+		}
+
+		q, e := internal.Parse(string(c.queries[i]))
+		if e == nil {
+			out[i], e = d.get(q)
+		}
+
+		if e != nil {
+			fail(i, e)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (n *compiledNode) get(
+	d Datum,
+	out []Datum,
+	fail func(int, error),
+) {
+	for _, i := range n.outputs {
+		out[i] = d
+	}
+
+	for _, next := range n.next {
+		v, err := next.step(d)
+		if err != nil {
+			fail(next.first, err)
+			continue
+		}
+
+		next.get(v, out, fail)
+	}
+}
+
+// step reads the segment of n off d, as Datum.get does.
+func (n *compiledNode) step(
+	d Datum,
+) (Datum, error) {
+	dt := d.typ
+
+	switch {
+	case n.arr && dt.IsArr():
+		i := n.index
+		if i < 0 {
+			i += d.len()
+		}
+
+		if i < 0 || i >= d.len() {
+			return OfErr(), newDataReadOutOfBoundsError(n.seg)
+		}
+
+		return d.arrAt(i), nil
+
+	case n.arr && !isKeyIn(n.seg, dt):
+		return OfErr(), newDataReadUnexpectedTypeError(n.seg, Arr, dt)
+
+	case dt.IsObj():
+		v, ok := d.objAt(n.attr)
+		if !ok {
+			return OfErr(), newDataReadMissingKeyError(n.seg)
+		}
+
+		return v, nil
+
+	default:
+		return OfErr(), newDataReadUnexpectedTypeError(n.seg, Obj, dt)
+	}
+}
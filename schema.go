@@ -0,0 +1,344 @@
+package giraffe
+
+import (
+	"fmt"
+	"maps"
+	"math/big"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hkoosha/giraffe/core/serdes/gson"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+)
+
+// FieldOf describes the value expected at a path of a Schema. The type may be
+// a union of Obj, Int, Flt, Bln and Str, or none of them to accept any type.
+// The Arr modifier expects an array, and the rest of the field then applies
+// to each of its elements. The Nil modifier makes the path optional: it may
+// be missing or null.
+func FieldOf(typ Type) Field {
+	return Field{
+		pattern: nil,
+		nested:  nil,
+		min:     nil,
+		max:     nil,
+		enum:    nil,
+		typ:     typ,
+	}
+}
+
+type Field struct {
+	pattern *regexp.Regexp
+	nested  *Schema
+	min     *big.Float
+	max     *big.Float
+	enum    []Datum
+	typ     Type
+}
+
+func (f Field) String() string {
+	return "Field[" + schemaTypeRepr(f.typ) + "]"
+}
+
+func (f Field) Type() Type {
+	return f.typ
+}
+
+func (f Field) IsRequired() bool {
+	return !f.typ.IsNil()
+}
+
+func (f Field) Enum() []Datum {
+	return slices.Clone(f.enum)
+}
+
+// WithEnum restricts the value to one of the given values, by Datum.Eq.
+func (f Field) WithEnum(
+	values ...Datum,
+) Field {
+	if len(values) == 0 {
+		panic(EF("no enum values provided, use WithoutEnum for this case"))
+	}
+
+	f.enum = slices.Clone(values)
+
+	return f
+}
+
+func (f Field) WithoutEnum() Field {
+	f.enum = nil
+
+	return f
+}
+
+// WithRange bounds numeric values, inclusive. A nil bound is unbounded.
+func (f Field) WithRange(
+	minimum *big.Float,
+	maximum *big.Float,
+) Field {
+	if minimum != nil && maximum != nil && minimum.Cmp(maximum) > 0 {
+		panic(EF("invalid range: %s > %s", minimum.String(), maximum.String()))
+	}
+
+	f.min = minimum
+	f.max = maximum
+
+	return f
+}
+
+func (f Field) WithoutRange() Field {
+	return f.WithRange(nil, nil)
+}
+
+// WithPattern requires string values to match the pattern.
+func (f Field) WithPattern(
+	pattern *regexp.Regexp,
+) Field {
+	f.pattern = pattern
+
+	return f
+}
+
+func (f Field) WithoutPattern() Field {
+	f.pattern = nil
+
+	return f
+}
+
+// WithSchema validates object values against the nested schema, relative to
+// the object itself.
+func (f Field) WithSchema(
+	nested Schema,
+) Field {
+	if f.typ&types != Obj {
+		panic(EF("nested schema on non-obj field: %s", schemaTypeRepr(f.typ)))
+	}
+
+	f.nested = &nested
+
+	return f
+}
+
+func (f Field) WithoutSchema() Field {
+	f.nested = nil
+
+	return f
+}
+
+// =====================================.
+
+// SchemaOf declares the expected type of each path. Paths are relative to
+// the validated datum, and may go several levels deep.
+func SchemaOf(
+	fields map[Query]Type,
+) Schema {
+	s := Schema{
+		fields: make(map[Query]Field, len(fields)),
+	}
+
+	for q, t := range fields {
+		s.fields[q] = FieldOf(t)
+	}
+
+	return s
+}
+
+// SchemaOfJson imports a JSON Schema. Only the subset that maps onto Field is
+// supported: type, properties, required, items, enum, minimum, maximum and
+// pattern. Annotations, such as title or description, are ignored. The
+// number type is imported as Int|Flt, and a property which is either not
+// required or nullable is imported as optional.
+func SchemaOfJson(
+	jsonSchema Datum,
+) (Schema, error) {
+	b, err := jsonSchema.MarshalJSON()
+	if err != nil {
+		return Schema{fields: nil}, err
+	}
+
+	var s Schema
+	if err := s.UnmarshalJSON(b); err != nil {
+		return Schema{fields: nil}, err
+	}
+
+	return s, nil
+}
+
+type Schema struct {
+	fields map[Query]Field
+}
+
+func (s Schema) String() string {
+	keys := slices.Sorted(maps.Keys(s.fields))
+
+	sb := strings.Builder{}
+	sb.WriteString("Schema[")
+	for i, q := range keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(q.String())
+		sb.WriteByte('=')
+		sb.WriteString(schemaTypeRepr(s.fields[q].typ))
+	}
+	sb.WriteByte(']')
+
+	return sb.String()
+}
+
+func (s Schema) Fields() map[Query]Field {
+	return maps.Clone(s.fields)
+}
+
+func (s Schema) With(
+	q Query,
+	typ Type,
+) Schema {
+	return s.WithField(q, FieldOf(typ))
+}
+
+func (s Schema) WithField(
+	q Query,
+	field Field,
+) Schema {
+	fields := maps.Clone(s.fields)
+	if fields == nil {
+		fields = make(map[Query]Field, 1)
+	}
+
+	fields[q] = field
+
+	return Schema{fields: fields}
+}
+
+func (s Schema) Without(
+	q Query,
+) Schema {
+	fields := maps.Clone(s.fields)
+	delete(fields, q)
+
+	return Schema{fields: fields}
+}
+
+// Validate returns a *SchemaError listing every violation, or nil.
+func (s Schema) Validate(
+	d Datum,
+) error {
+	violations := s.Violations(d)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return newSchemaError(violations)
+}
+
+// Violations lists every violation, ordered by path.
+func (s Schema) Violations(
+	d Datum,
+) []Violation {
+	var violations []Violation
+	s.violations(GQErr(), d, &violations)
+
+	return violations
+}
+
+// JsonSchema exports the schema as a JSON Schema, see SchemaOfJson.
+func (s Schema) JsonSchema() (Datum, error) {
+	b, err := s.MarshalJSON()
+	if err != nil {
+		return OfErr(), err
+	}
+
+	return ofJson(b)
+}
+
+func (s Schema) MarshalJSON() ([]byte, error) {
+	node, err := s.jsonSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return gson.Marshal(node)
+}
+
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	if s == nil {
+		return newNilError()
+	}
+
+	node, err := gson.Unmarshal[map[string]any](b)
+	if err != nil {
+		return newSchemaInvalidError(err.Error())
+	}
+
+	parsed, err := schemaOfJsonObj(node)
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+
+	return nil
+}
+
+// =====================================.
+
+// Violation is a single mismatch found by Schema.Validate. The query is
+// GQErr() when the validated datum itself mismatches.
+type Violation struct {
+	Query  Query
+	Reason string
+}
+
+func (v Violation) String() string {
+	if v.Query == GQErr() {
+		return v.Reason
+	}
+
+	return v.Query.String() + ": " + v.Reason
+}
+
+type SchemaError struct {
+	violations []Violation
+}
+
+func (e *SchemaError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString("schema violations: [")
+
+	for i, v := range e.violations {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(v.String())
+	}
+	sb.WriteByte(']')
+
+	return sb.String()
+}
+
+func (e *SchemaError) Code() uint64 {
+	return ErrCodeSchemaViolation
+}
+
+func (e *SchemaError) Violations() []Violation {
+	return slices.Clone(e.violations)
+}
+
+func newSchemaError(
+	violations []Violation,
+) error {
+	return E(&SchemaError{
+		violations: violations,
+	})
+}
+
+func newSchemaInvalidError(
+	msg string,
+) error {
+	return newGiraffeError(
+		ErrCodeSchemaInvalid,
+		fmt.Sprintf("invalid schema: %s", msg),
+	)
+}
@@ -0,0 +1,105 @@
+package giraffe
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// InferSchema infers the shape of the samples, which must all be objects. A
+// path is optional (Nil) when it is missing from, or null in, some samples.
+// Int and Flt seen at the same path are unioned. Arrays are typed by the
+// union of their elements, and the objects within an array are inferred
+// together, relative to the array. A path seen both as an array and as a
+// non-array, or an array of arrays, is inferred as any type.
+func InferSchema(
+	samples ...Datum,
+) (Inferred, error) {
+	root := newInferNode()
+
+	for i, it := range samples {
+		if !it.typ.IsObj() {
+			return Inferred{root: nil}, newSchemaInvalidError(
+				"sample is not an obj: #" + strconv.Itoa(i) + ", " + schemaTypeRepr(it.typ),
+			)
+		}
+
+		root.count++
+		root.observe(it)
+	}
+
+	return Inferred{root: root}, nil
+}
+
+type Inferred struct {
+	root *inferNode
+}
+
+// InferredPath is a single path of an Inferred schema. Count is the number of
+// times the path was present, including as null, out of Of times it could
+// have been, that is, the number of times its parent was an object. The
+// elements of an array are denoted by the `*` segment, e.g. `items.*.id`.
+type InferredPath struct {
+	Query Query
+	Type  Type
+	Count int
+	Of    int
+}
+
+func (p InferredPath) String() string {
+	return fmt.Sprintf(
+		"InferredPath[%s, %s, %d/%d]",
+		p.Query,
+		schemaTypeRepr(p.Type),
+		p.Count,
+		p.Of,
+	)
+}
+
+func (i Inferred) String() string {
+	return "Inferred[" + strconv.Itoa(i.Samples()) + "]"
+}
+
+func (i Inferred) Samples() int {
+	if i.root == nil {
+		return 0
+	}
+
+	return i.root.count
+}
+
+// Paths lists every inferred path, ordered by path.
+func (i Inferred) Paths() []InferredPath {
+	if i.root == nil {
+		return nil
+	}
+
+	var paths []InferredPath
+	i.root.paths(GQErr(), &paths)
+
+	return paths
+}
+
+// Schema is the validating form of the inferred shape.
+func (i Inferred) Schema() Schema {
+	if i.root == nil {
+		return SchemaOf(nil)
+	}
+
+	return i.root.schema()
+}
+
+func (i Inferred) JsonSchema() (Datum, error) {
+	return i.Schema().JsonSchema()
+}
+
+// GoStruct renders the inferred shape as Go struct declarations, with json
+// tags, the root one being named after name. Optional paths are pointers.
+func (i Inferred) GoStruct(
+	name string,
+) (string, error) {
+	if i.root == nil {
+		return "", newNilError()
+	}
+
+	return i.root.goStruct(name)
+}
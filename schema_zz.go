@@ -0,0 +1,626 @@
+package giraffe
+
+import (
+	"encoding/json"
+	"maps"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hkoosha/giraffe/cmd"
+	"github.com/hkoosha/giraffe/core/serdes/gson"
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+)
+
+const (
+	jsKeyType       = "type"
+	jsKeyProperties = "properties"
+	jsKeyRequired   = "required"
+	jsKeyItems      = "items"
+	jsKeyEnum       = "enum"
+	jsKeyMinimum    = "minimum"
+	jsKeyMaximum    = "maximum"
+	jsKeyPattern    = "pattern"
+
+	jsTypeNull    = "null"
+	jsTypeArray   = "array"
+	jsTypeObject  = "object"
+	jsTypeInteger = "integer"
+	jsTypeNumber  = "number"
+	jsTypeBoolean = "boolean"
+	jsTypeString  = "string"
+)
+
+func schemaTypeRepr(
+	t Type,
+) string {
+	var names []string
+	for _, it := range dTypes {
+		if t&it == it {
+			names = append(names, it.String())
+		}
+	}
+
+	repr := strings.Join(names, "|")
+	if repr == "" {
+		repr = "any"
+	}
+
+	if t.IsArr() {
+		repr += arrRepr
+	}
+
+	if t.IsNil() {
+		repr += nilRepr
+	}
+
+	return repr
+}
+
+func schemaPath(
+	prefix Query,
+	next string,
+) Query {
+	if prefix == GQErr() {
+		return Query(next)
+	}
+
+	return Query(prefix.String() + cmd.Sep.String() + next)
+}
+
+func addViolation(
+	violations *[]Violation,
+	path Query,
+	reason string,
+) {
+	*violations = append(*violations, Violation{
+		Query:  path,
+		Reason: reason,
+	})
+}
+
+// =====================================.
+
+func (s Schema) violations(
+	prefix Query,
+	d Datum,
+	violations *[]Violation,
+) {
+	if !d.typ.IsObj() {
+		addViolation(violations, prefix, "expected obj, got "+schemaTypeRepr(d.typ))
+
+		return
+	}
+
+	for _, q := range slices.Sorted(maps.Keys(s.fields)) {
+		field := s.fields[q]
+		path := schemaPath(prefix, q.String())
+
+		ok, err := d.Has(q)
+		switch {
+		case err != nil:
+			addViolation(violations, path, err.Error())
+
+			continue
+
+		case !ok && field.IsRequired():
+			addViolation(violations, path, "missing")
+
+			continue
+
+		case !ok:
+			continue
+		}
+
+		v, err := d.Get(q)
+		if err != nil {
+			addViolation(violations, path, err.Error())
+
+			continue
+		}
+
+		field.violations(path, v, violations)
+	}
+}
+
+func (f Field) violations(
+	path Query,
+	v Datum,
+	violations *[]Violation,
+) {
+	switch {
+	case v.typ.IsNil() && !f.typ.IsNil():
+		addViolation(violations, path, "unexpected null")
+
+	case v.typ.IsNil():
+
+	case f.typ.IsArr() && !v.typ.IsArr():
+		addViolation(violations, path, "expected "+schemaTypeRepr(f.typ)+", got "+schemaTypeRepr(v.typ))
+
+	case f.typ.IsArr():
+		for i, it := range v.arr() {
+			f.elemViolations(schemaPath(path, strconv.Itoa(i)), it, violations)
+		}
+
+	default:
+		f.elemViolations(path, v, violations)
+	}
+}
+
+func (f Field) elemViolations(
+	path Query,
+	v Datum,
+	violations *[]Violation,
+) {
+	if base := f.typ & types; base != 0 && (v.typ&base == 0 || v.typ.IsArr()) {
+		addViolation(violations, path, "expected "+schemaTypeRepr(base)+", got "+schemaTypeRepr(v.typ))
+
+		return
+	}
+
+	if len(f.enum) > 0 && !slices.ContainsFunc(f.enum, v.eq) {
+		addViolation(violations, path, "not in enum: "+v.String())
+	}
+
+	if f.min != nil || f.max != nil {
+		if num, ok := v.schemaNum(); ok {
+			if f.min != nil && num.Cmp(f.min) < 0 {
+				addViolation(violations, path, "below minimum: "+f.min.String())
+			}
+			if f.max != nil && num.Cmp(f.max) > 0 {
+				addViolation(violations, path, "above maximum: "+f.max.String())
+			}
+		}
+	}
+
+	if f.pattern != nil && v.typ.IsStr() && !f.pattern.MatchString(M(v.Str())) {
+		addViolation(violations, path, "does not match pattern: "+f.pattern.String())
+	}
+
+	if f.nested != nil && v.typ.IsObj() {
+		f.nested.violations(path, v, violations)
+	}
+}
+
+func (d Datum) schemaNum() (*big.Float, bool) {
+	switch {
+	case d.typ.IsInt():
+		return new(big.Float).SetInt(M(d.Int())), true
+
+	case d.typ.IsFlt():
+		return M(d.Flt()), true
+
+	default:
+		return nil, false
+	}
+}
+
+// =====================================.
+
+func (s Schema) jsonSchema() (map[string]any, error) {
+	root := map[string]any{
+		jsKeyType: jsTypeObject,
+	}
+
+	for _, q := range slices.Sorted(maps.Keys(s.fields)) {
+		field := s.fields[q]
+
+		segments, err := schemaSegments(q)
+		if err != nil {
+			return nil, err
+		}
+
+		node := root
+		for i, seg := range segments {
+			if field.IsRequired() {
+				jsRequire(node, seg)
+			}
+
+			props, ok := node[jsKeyProperties].(map[string]any)
+			if !ok {
+				props = map[string]any{}
+				node[jsKeyProperties] = props
+			}
+
+			child, ok := props[seg].(map[string]any)
+			if !ok {
+				child = map[string]any{
+					jsKeyType: jsTypeObject,
+				}
+				props[seg] = child
+			}
+
+			if i == len(segments)-1 {
+				fNode, fErr := field.jsonSchema()
+				if fErr != nil {
+					return nil, fErr
+				}
+
+				jsMerge(child, fNode)
+			}
+
+			node = child
+		}
+	}
+
+	return root, nil
+}
+
+func (f Field) jsonSchema() (map[string]any, error) {
+	node := map[string]any{}
+
+	var typs []any
+	for _, it := range dTypes {
+		if f.typ&it != it {
+			continue
+		}
+
+		switch it {
+		case Obj:
+			typs = append(typs, jsTypeObject)
+		case Int:
+			if f.typ&Flt != Flt {
+				typs = append(typs, jsTypeInteger)
+			}
+		case Flt:
+			typs = append(typs, jsTypeNumber)
+		case Bln:
+			typs = append(typs, jsTypeBoolean)
+		case Str:
+			typs = append(typs, jsTypeString)
+		}
+	}
+
+	if len(typs) > 0 {
+		node[jsKeyType] = jsTypes(typs)
+	}
+
+	if len(f.enum) > 0 {
+		enum := make([]any, len(f.enum))
+		for i, it := range f.enum {
+			var err error
+			if enum[i], err = it.plain(); err != nil {
+				return nil, err
+			}
+		}
+		node[jsKeyEnum] = enum
+	}
+
+	if f.min != nil {
+		node[jsKeyMinimum] = json.Number(f.min.Text('g', -1))
+	}
+
+	if f.max != nil {
+		node[jsKeyMaximum] = json.Number(f.max.Text('g', -1))
+	}
+
+	if f.pattern != nil {
+		node[jsKeyPattern] = f.pattern.String()
+	}
+
+	if f.nested != nil {
+		nested, err := f.nested.jsonSchema()
+		if err != nil {
+			return nil, err
+		}
+
+		jsMerge(node, nested)
+	}
+
+	if f.typ.IsArr() {
+		node = map[string]any{
+			jsKeyType:  jsTypeArray,
+			jsKeyItems: node,
+		}
+	}
+
+	if f.typ.IsNil() && node[jsKeyType] != nil {
+		node[jsKeyType] = jsTypes(append(jsTypeList(node[jsKeyType]), jsTypeNull))
+	}
+
+	return node, nil
+}
+
+func schemaSegments(
+	q Query,
+) ([]string, error) {
+	var segments []string
+
+	for at := q.impl(); ; at = at.Next() {
+		if !at.Flags().IsObj() {
+			return nil, newSchemaInvalidError("only object paths can be exported: " + q.String())
+		}
+
+		segments = append(segments, at.Attr())
+
+		if at.Flags().IsLeaf() {
+			return segments, nil
+		}
+	}
+}
+
+func jsTypes(
+	typs []any,
+) any {
+	if len(typs) == 1 {
+		return typs[0]
+	}
+
+	return typs
+}
+
+func jsTypeList(
+	typ any,
+) []any {
+	switch t := typ.(type) {
+	case []any:
+		return t
+	case nil:
+		return nil
+	default:
+		return []any{t}
+	}
+}
+
+func jsRequire(
+	node map[string]any,
+	key string,
+) {
+	required, _ := node[jsKeyRequired].([]any)
+	if slices.Contains(required, any(key)) {
+		return
+	}
+
+	required = append(required, key)
+	slices.SortFunc(required, func(a, b any) int {
+		return strings.Compare(a.(string), b.(string)) //nolint:forcetypeassert
+	})
+	node[jsKeyRequired] = required
+}
+
+func jsMerge(
+	into map[string]any,
+	from map[string]any,
+) {
+	for k, v := range from {
+		switch k {
+		case jsKeyProperties:
+			props, ok := into[k].(map[string]any)
+			if !ok {
+				props = map[string]any{}
+				into[k] = props
+			}
+
+			//nolint:forcetypeassert
+			for pk, pv := range v.(map[string]any) {
+				if existing, ok := props[pk].(map[string]any); ok {
+					jsMerge(existing, pv.(map[string]any))
+				} else {
+					props[pk] = pv
+				}
+			}
+
+		case jsKeyRequired:
+			//nolint:forcetypeassert
+			for _, r := range v.([]any) {
+				jsRequire(into, r.(string))
+			}
+
+		default:
+			into[k] = v
+		}
+	}
+}
+
+// =====================================.
+
+func schemaOfJsonObj(
+	node map[string]any,
+) (Schema, error) {
+	s := Schema{
+		fields: map[Query]Field{},
+	}
+
+	required := map[string]bool{}
+	if raw, ok := node[jsKeyRequired]; ok {
+		list, ok := raw.([]any)
+		if !ok {
+			return Schema{fields: nil}, newSchemaInvalidError("required must be an array")
+		}
+
+		for _, it := range list {
+			name, ok := it.(string)
+			if !ok {
+				return Schema{fields: nil}, newSchemaInvalidError("required must be an array of strings")
+			}
+			required[name] = true
+		}
+	}
+
+	props := map[string]any{}
+	if raw, ok := node[jsKeyProperties]; ok {
+		if props, ok = raw.(map[string]any); !ok {
+			return Schema{fields: nil}, newSchemaInvalidError("properties must be an object")
+		}
+	}
+
+	for name, raw := range props {
+		prop, ok := raw.(map[string]any)
+		if !ok {
+			return Schema{fields: nil}, newSchemaInvalidError("property must be an object: " + name)
+		}
+
+		field, err := fieldOfJson(prop)
+		if err != nil {
+			return Schema{fields: nil}, err
+		}
+
+		if !required[name] {
+			field.typ |= Nil
+		}
+
+		q, err := GQParse(internal.Escaped(name))
+		if err != nil {
+			return Schema{fields: nil}, err
+		}
+
+		s.fields[q] = field
+	}
+
+	return s, nil
+}
+
+func fieldOfJson(
+	node map[string]any,
+) (Field, error) {
+	var typs []string
+	for _, it := range jsTypeList(node[jsKeyType]) {
+		name, ok := it.(string)
+		if !ok {
+			return FieldOf(Err), newSchemaInvalidError("type must be a string or an array of strings")
+		}
+		typs = append(typs, name)
+	}
+
+	if slices.Contains(typs, jsTypeArray) {
+		return arrFieldOfJson(node, typs)
+	}
+
+	field := FieldOf(0)
+	for _, name := range typs {
+		switch name {
+		case jsTypeNull:
+			field.typ |= Nil
+		case jsTypeObject:
+			field.typ |= Obj
+		case jsTypeInteger:
+			field.typ |= Int
+		case jsTypeNumber:
+			field.typ |= Int | Flt
+		case jsTypeBoolean:
+			field.typ |= Bln
+		case jsTypeString:
+			field.typ |= Str
+		default:
+			return FieldOf(Err), newSchemaInvalidError("unknown type: " + name)
+		}
+	}
+
+	if raw, ok := node[jsKeyEnum]; ok {
+		list, ok := raw.([]any)
+		if !ok || len(list) == 0 {
+			return FieldOf(Err), newSchemaInvalidError("enum must be a non-empty array")
+		}
+
+		field.enum = make([]Datum, len(list))
+		for i, it := range list {
+			b, err := gson.Marshal(it)
+			if err != nil {
+				return FieldOf(Err), err
+			}
+
+			if field.enum[i], err = ofJson(b); err != nil {
+				return FieldOf(Err), err
+			}
+		}
+	}
+
+	var err error
+	if field.min, err = jsBound(node, jsKeyMinimum); err != nil {
+		return FieldOf(Err), err
+	}
+	if field.max, err = jsBound(node, jsKeyMaximum); err != nil {
+		return FieldOf(Err), err
+	}
+
+	if raw, ok := node[jsKeyPattern]; ok {
+		pattern, ok := raw.(string)
+		if !ok {
+			return FieldOf(Err), newSchemaInvalidError("pattern must be a string")
+		}
+
+		if field.pattern, err = regexp.Compile(pattern); err != nil {
+			return FieldOf(Err), newSchemaInvalidError(err.Error())
+		}
+	}
+
+	if _, ok := node[jsKeyProperties]; ok {
+		if field.typ&types != Obj {
+			return FieldOf(Err), newSchemaInvalidError("properties on non-object type")
+		}
+
+		nested, err := schemaOfJsonObj(node)
+		if err != nil {
+			return FieldOf(Err), err
+		}
+
+		field.nested = &nested
+	}
+
+	return field, nil
+}
+
+func arrFieldOfJson(
+	node map[string]any,
+	typs []string,
+) (Field, error) {
+	nullable := false
+	for _, name := range typs {
+		switch name {
+		case jsTypeArray:
+		case jsTypeNull:
+			nullable = true
+		default:
+			return FieldOf(Err), newSchemaInvalidError("array mixed with other types: " + name)
+		}
+	}
+
+	items := map[string]any{}
+	if raw, ok := node[jsKeyItems]; ok {
+		if items, ok = raw.(map[string]any); !ok {
+			return FieldOf(Err), newSchemaInvalidError("items must be an object")
+		}
+	}
+
+	field, err := fieldOfJson(items)
+	if err != nil {
+		return FieldOf(Err), err
+	}
+
+	if field.typ.IsArr() {
+		return FieldOf(Err), newSchemaInvalidError("nested arrays are not supported")
+	}
+
+	// Elements can not be null, Nil applies to the array itself.
+	field.typ = (field.typ &^ Nil) | Arr
+	if nullable {
+		field.typ |= Nil
+	}
+
+	return field, nil
+}
+
+func jsBound(
+	node map[string]any,
+	key string,
+) (*big.Float, error) {
+	raw, ok := node[key]
+	if !ok {
+		return nil, nil //nolint:nilnil
+	}
+
+	num, ok := raw.(json.Number)
+	if !ok {
+		return nil, newSchemaInvalidError(key + " must be a number")
+	}
+
+	bound, ok := new(big.Float).SetString(num.String())
+	if !ok {
+		return nil, newSchemaInvalidError(key + " must be a number")
+	}
+
+	return bound, nil
+}
@@ -0,0 +1,272 @@
+package giraffe
+
+import (
+	"go/format"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+)
+
+const inferElemSegment = "*"
+
+func newInferNode() *inferNode {
+	return &inferNode{
+		props:   map[string]*inferNode{},
+		typ:     0,
+		count:   0,
+		objs:    0,
+		nulls:   false,
+		arr:     false,
+		scalar:  false,
+		anyElem: false,
+	}
+}
+
+type inferNode struct {
+	props map[string]*inferNode
+
+	// Union of the non-null types seen, for arrays of their elements.
+	typ Type
+
+	// Times present, including as null.
+	count int
+
+	// Times it, or one of its elements, was an object. This is the number
+	// of times each of the props could have been present.
+	objs int
+
+	nulls   bool
+	arr     bool
+	scalar  bool
+	anyElem bool
+}
+
+func (n *inferNode) observe(
+	d Datum,
+) {
+	switch {
+	case d.typ.IsNil():
+		n.nulls = true
+
+	case d.typ.IsArr():
+		n.arr = true
+		for _, it := range d.arr() {
+			n.observeElem(it, true)
+		}
+
+	default:
+		n.scalar = true
+		n.observeElem(d, false)
+	}
+}
+
+func (n *inferNode) observeElem(
+	d Datum,
+	isElem bool,
+) {
+	if isElem && (d.typ.IsNil() || d.typ.IsArr()) {
+		n.anyElem = true
+
+		return
+	}
+
+	n.typ |= d.typ & types
+
+	if !d.typ.IsObj() {
+		return
+	}
+
+	n.objs++
+	for k, v := range d.obj() {
+		prop, ok := n.props[k]
+		if !ok {
+			prop = newInferNode()
+			n.props[k] = prop
+		}
+
+		prop.count++
+		prop.observe(v)
+	}
+}
+
+func (n *inferNode) typeOf(
+	of int,
+) Type {
+	typ := n.typ
+
+	if n.anyElem || (n.arr && n.scalar) {
+		typ = 0
+	}
+
+	if n.arr && !n.scalar {
+		typ |= Arr
+	}
+
+	if n.nulls || n.count < of {
+		typ |= Nil
+	}
+
+	return typ
+}
+
+func (n *inferNode) keys() []string {
+	return slices.Sorted(maps.Keys(n.props))
+}
+
+// =====================================.
+
+func (n *inferNode) paths(
+	prefix Query,
+	paths *[]InferredPath,
+) {
+	for _, k := range n.keys() {
+		prop := n.props[k]
+		path := schemaPath(prefix, internal.Escaped(k))
+
+		*paths = append(*paths, InferredPath{
+			Query: path,
+			Type:  prop.typeOf(n.objs),
+			Count: prop.count,
+			Of:    n.objs,
+		})
+
+		if prop.arr {
+			path = schemaPath(path, inferElemSegment)
+		}
+
+		prop.paths(path, paths)
+	}
+}
+
+func (n *inferNode) schema() Schema {
+	fields := make(map[Query]Field, len(n.props))
+
+	for _, k := range n.keys() {
+		prop := n.props[k]
+		field := FieldOf(prop.typeOf(n.objs))
+
+		if field.typ&types == Obj && len(prop.props) > 0 {
+			field = field.WithSchema(prop.schema())
+		}
+
+		fields[M(GQParse(internal.Escaped(k)))] = field
+	}
+
+	return Schema{fields: fields}
+}
+
+// =====================================.
+
+func (n *inferNode) goStruct(
+	name string,
+) (string, error) {
+	names := map[string]bool{}
+	var decls []string
+
+	n.goDecl(goName(name, names), names, &decls)
+
+	src, err := format.Source([]byte(strings.Join(decls, "\n\n") + "\n"))
+	if err != nil {
+		return "", E(err)
+	}
+
+	return string(src), nil
+}
+
+func (n *inferNode) goDecl(
+	name string,
+	names map[string]bool,
+	decls *[]string,
+) {
+	at := len(*decls)
+	*decls = append(*decls, "")
+
+	sb := strings.Builder{}
+	sb.WriteString("type " + name + " struct {\n")
+
+	fieldNames := map[string]bool{}
+	for _, k := range n.keys() {
+		prop := n.props[k]
+		typ := prop.typeOf(n.objs)
+		fieldName := goName(k, fieldNames)
+
+		elem := "any"
+		switch typ & types {
+		case Obj:
+			elem = goName(name+fieldName, names)
+			prop.goDecl(elem, names, decls)
+		case Int:
+			elem = "int64"
+		case Flt, Int | Flt:
+			elem = "float64"
+		case Bln:
+			elem = "bool"
+		case Str:
+			elem = "string"
+		}
+
+		tag := k
+		switch {
+		case typ.IsArr() && typ.IsNil():
+			elem = "[]" + elem
+			tag += ",omitempty"
+		case typ.IsArr():
+			elem = "[]" + elem
+		case typ.IsNil() && elem != "any":
+			elem = "*" + elem
+			tag += ",omitempty"
+		case typ.IsNil():
+			tag += ",omitempty"
+		}
+
+		sb.WriteString("\t" + fieldName + " " + elem + " `json:" + strconv.Quote(tag) + "`\n")
+	}
+
+	sb.WriteString("}")
+
+	(*decls)[at] = sb.String()
+}
+
+// goName makes an exported Go identifier of key, unique within seen.
+func goName(
+	key string,
+	seen map[string]bool,
+) string {
+	sb := strings.Builder{}
+	upper := true
+
+	for _, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = true
+
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	name := sb.String()
+	switch {
+	case name == "":
+		name = "Field"
+	case unicode.IsDigit(rune(name[0])):
+		name = "X" + name
+	}
+
+	unique := name
+	for i := 2; seen[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	seen[unique] = true
+
+	return unique
+}
@@ -0,0 +1,97 @@
+package giraffe
+
+import (
+	"slices"
+	"strings"
+)
+
+// Template is a string with expressions embedded in it, rendered against a
+// Datum:
+//
+//	Hello {{ .user.name | upper }}, you have {{ .inbox.count | default 0 }} messages.
+//
+// An expression is a query, the leading dot optional and a lone dot being the
+// datum itself, followed by filters, each after a pipe:
+//   - default V: V, a JSON literal, when the value is missing or null,
+//   - upper and lower: the string in upper or lower case,
+//   - json: the value as JSON.
+//
+// A value which is missing, with no default, fails the rendering. Strings are
+// rendered as they are, objects and arrays as JSON and null as "null".
+type Template struct {
+	spec  string
+	parts []tmplPart
+}
+
+// ParseTemplate parses spec, failing on malformed expressions, queries or
+// filters.
+func ParseTemplate(
+	spec string,
+) (*Template, error) {
+	return parseTemplate(spec)
+}
+
+// IsTemplate reports whether s embeds any expressions. Strings which do not
+// render as themselves.
+func IsTemplate(
+	s string,
+) bool {
+	return strings.Contains(s, tmplOpen)
+}
+
+// Render renders t against d.
+func (t *Template) Render(
+	d Datum,
+) (string, error) {
+	return t.render(d)
+}
+
+// Queries are the queries the expressions of t read, in order.
+func (t *Template) Queries() []Query {
+	var queries []Query
+	for _, p := range t.parts {
+		if p.expr != nil && p.expr.query != GQErr() {
+			queries = append(queries, p.expr.query)
+		}
+	}
+
+	return slices.Clip(queries)
+}
+
+func (t *Template) String() string {
+	return t.spec
+}
+
+// =====================================.
+
+// DocTemplate is a document rendered against a Datum. Its strings are
+// templates, see Template, and its objects of the form below are replaced by
+// the value of the expression, keeping its type. The default, if given, is
+// used when the value is missing or null, as the default filter does.
+//
+//	{"id": {"$q": ".user.id"}, "tags": {"$q": ".user.tags", "$default": []}}
+type DocTemplate struct {
+	root *tmplNode
+}
+
+// ParseDocTemplate parses the templates in doc, failing on malformed ones.
+func ParseDocTemplate(
+	doc Datum,
+) (*DocTemplate, error) {
+	return parseDocTemplate(doc)
+}
+
+// Render renders t against d.
+func (t *DocTemplate) Render(
+	d Datum,
+) (Datum, error) {
+	return t.root.render(d)
+}
+
+// Queries are the queries t reads, walking objects in the order of Datum.Keys.
+func (t *DocTemplate) Queries() []Query {
+	var queries []Query
+	t.root.queries(&queries)
+
+	return slices.Clip(queries)
+}
@@ -0,0 +1,476 @@
+package giraffe
+
+import (
+	"strings"
+
+	. "github.com/hkoosha/giraffe/core/t11y/dot"
+	"github.com/hkoosha/giraffe/internal"
+)
+
+const (
+	tmplOpen    = "{{"
+	tmplClose   = "}}"
+	tmplPipe    = '|'
+	tmplSelf    = "."
+	tmplQuery   = "$q"
+	tmplDefault = "$default"
+
+	tmplFilterDefault = "default"
+	tmplFilterUpper   = "upper"
+	tmplFilterLower   = "lower"
+	tmplFilterJson    = "json"
+)
+
+// tmplPart is either a literal text, or an expression.
+type tmplPart struct {
+	expr *tmplExpr
+	text string
+}
+
+type tmplFilter struct {
+	arg  *Datum
+	name string
+}
+
+type tmplExpr struct {
+	spec    string
+	query   Query
+	filters []tmplFilter
+}
+
+// tmplNode is a node of a document template: a literal, an expression, a
+// string template, or an object or array holding templates.
+type tmplNode struct {
+	lit      Datum
+	expr     *tmplExpr
+	str      *Template
+	keys     []string
+	children []*tmplNode
+	ordered  bool
+}
+
+// =====================================.
+
+func parseTemplate(
+	spec string,
+) (*Template, error) {
+	t := &Template{spec: spec, parts: nil}
+
+	for rest := spec; rest != ""; {
+		open := strings.Index(rest, tmplOpen)
+		if open < 0 {
+			t.parts = append(t.parts, tmplPart{expr: nil, text: rest})
+
+			break
+		}
+
+		if open > 0 {
+			t.parts = append(t.parts, tmplPart{expr: nil, text: rest[:open]})
+		}
+
+		rest = rest[open+len(tmplOpen):]
+
+		end := strings.Index(rest, tmplClose)
+		if end < 0 {
+			return nil, newTemplateInvalidError(spec, "unclosed expression")
+		}
+
+		expr, err := parseTmplExpr(rest[:end])
+		if err != nil {
+			return nil, E(err, newTemplateInvalidError(spec, "invalid expression"))
+		}
+
+		t.parts = append(t.parts, tmplPart{expr: expr, text: ""})
+		rest = rest[end+len(tmplClose):]
+	}
+
+	return t, nil
+}
+
+// tmplSplit splits spec at the pipes which are not quoted.
+func tmplSplit(
+	spec string,
+) []string {
+	var (
+		parts  []string
+		quoted bool
+		escape bool
+		from   int
+	)
+
+	for i, c := range spec {
+		switch {
+		case escape:
+			escape = false
+
+		case quoted && c == '\\':
+			escape = true
+
+		case c == '"':
+			quoted = !quoted
+
+		case !quoted && c == tmplPipe:
+			parts = append(parts, spec[from:i])
+			from = i + 1
+		}
+	}
+
+	return append(parts, spec[from:])
+}
+
+func parseTmplExpr(
+	spec string,
+) (*tmplExpr, error) {
+	parts := tmplSplit(spec)
+
+	expr := &tmplExpr{
+		spec:    strings.TrimSpace(spec),
+		query:   GQErr(),
+		filters: nil,
+	}
+
+	if q := strings.TrimSpace(parts[0]); q != tmplSelf {
+		q = strings.TrimPrefix(q, tmplSelf)
+
+		parsed, err := internal.Parse(q)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = readonly(parsed); err != nil {
+			return nil, err
+		}
+
+		expr.query = Query(q)
+	}
+
+	for _, part := range parts[1:] {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), " ")
+		arg = strings.TrimSpace(arg)
+
+		f := tmplFilter{arg: nil, name: name}
+
+		switch {
+		case name == tmplFilterDefault && arg == "":
+			return nil, EF("filter takes a value: %s", name)
+
+		case name == tmplFilterDefault:
+			v, err := ofJson([]byte(arg))
+			if err != nil {
+				return nil, E(err, EF("invalid value of filter: %s", name))
+			}
+
+			f.arg = &v
+
+		case name != tmplFilterUpper && name != tmplFilterLower && name != tmplFilterJson:
+			return nil, EF("unknown filter: %s", name)
+
+		case arg != "":
+			return nil, EF("filter takes no value: %s", name)
+		}
+
+		expr.filters = append(expr.filters, f)
+	}
+
+	return expr, nil
+}
+
+func parseDocTemplate(
+	doc Datum,
+) (*DocTemplate, error) {
+	root, err := parseTmplNode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DocTemplate{root: root}, nil
+}
+
+func parseTmplNode(
+	d Datum,
+) (*tmplNode, error) {
+	n := &tmplNode{
+		lit:      d,
+		expr:     nil,
+		str:      nil,
+		keys:     nil,
+		children: nil,
+		ordered:  d.isOrdered(),
+	}
+
+	switch {
+	case d.typ.IsNil():
+		return n, nil
+
+	case d.typ.IsStr():
+		if s := cast[string](d); IsTemplate(s) {
+			var err error
+			if n.str, err = parseTemplate(s); err != nil {
+				return nil, err
+			}
+		}
+
+		return n, nil
+
+	case d.typ.IsArr():
+		for i := range d.len() {
+			child, err := parseTmplNode(d.arrAt(i))
+			if err != nil {
+				return nil, err
+			}
+
+			n.children = append(n.children, child)
+		}
+
+	case d.typ.IsObj():
+		if q, ok := d.objAt(tmplQuery); ok {
+			return parseTmplQuery(d, q)
+		}
+
+		for _, k := range d.keysInOrder() {
+			v, _ := d.objAt(k)
+			child, err := parseTmplNode(v)
+			if err != nil {
+				return nil, err
+			}
+
+			n.keys = append(n.keys, k)
+			n.children = append(n.children, child)
+		}
+
+	default:
+		return n, nil
+	}
+
+	// Subtrees with no templates are rendered as they are.
+	for _, child := range n.children {
+		if !child.isLit() {
+			return n, nil
+		}
+	}
+
+	n.keys = nil
+	n.children = nil
+
+	return n, nil
+}
+
+func parseTmplQuery(
+	d Datum,
+	q Datum,
+) (*tmplNode, error) {
+	spec, err := q.Str()
+	if err != nil {
+		return nil, E(err, newTemplateInvalidError(tmplQuery, "expression must be a string"))
+	}
+
+	expr, err := parseTmplExpr(spec)
+	if err != nil {
+		return nil, E(err, newTemplateInvalidError(spec, "invalid expression"))
+	}
+
+	for _, k := range d.keysInOrder() {
+		switch k {
+		case tmplQuery:
+
+		case tmplDefault:
+			v, _ := d.objAt(k)
+			expr.filters = append(expr.filters, tmplFilter{arg: &v, name: tmplFilterDefault})
+
+		default:
+			return nil, newTemplateInvalidError(spec, "unexpected key next to "+tmplQuery+": "+k)
+		}
+	}
+
+	return &tmplNode{
+		lit:      OfErr(),
+		expr:     expr,
+		str:      nil,
+		keys:     nil,
+		children: nil,
+		ordered:  false,
+	}, nil
+}
+
+// =====================================.
+
+func (n *tmplNode) isLit() bool {
+	return n.expr == nil && n.str == nil && n.children == nil
+}
+
+func (n *tmplNode) queries(
+	queries *[]Query,
+) {
+	switch {
+	case n.expr != nil && n.expr.query != GQErr():
+		*queries = append(*queries, n.expr.query)
+
+	case n.str != nil:
+		*queries = append(*queries, n.str.Queries()...)
+	}
+
+	for _, child := range n.children {
+		child.queries(queries)
+	}
+}
+
+func (n *tmplNode) render(
+	d Datum,
+) (Datum, error) {
+	switch {
+	case n.isLit():
+		return n.lit, nil
+
+	case n.expr != nil:
+		return n.expr.eval(d)
+
+	case n.str != nil:
+		s, err := n.str.render(d)
+		if err != nil {
+			return OfErr(), err
+		}
+
+		return _newDatum(Str, s), nil
+
+	case n.lit.typ.IsArr():
+		arr := make([]Datum, len(n.children))
+		for i, child := range n.children {
+			var err error
+			if arr[i], err = child.render(d); err != nil {
+				return OfErr(), err
+			}
+		}
+
+		return _newDatum(Arr, arr), nil
+
+	default:
+		obj := newObj(n.ordered)
+		for i, child := range n.children {
+			v, err := child.render(d)
+			if err != nil {
+				return OfErr(), err
+			}
+
+			obj.putObj(n.keys[i], v)
+		}
+
+		return obj, nil
+	}
+}
+
+func (t *Template) render(
+	d Datum,
+) (string, error) {
+	sb := strings.Builder{}
+
+	for _, p := range t.parts {
+		if p.expr == nil {
+			sb.WriteString(p.text)
+
+			continue
+		}
+
+		v, err := p.expr.eval(d)
+		if err != nil {
+			return "", err
+		}
+
+		s, err := tmplString(v)
+		if err != nil {
+			return "", E(err, newTemplateRenderError(p.expr.spec))
+		}
+
+		sb.WriteString(s)
+	}
+
+	return sb.String(), nil
+}
+
+func tmplString(
+	v Datum,
+) (string, error) {
+	switch {
+	case v.typ.IsNil():
+		return "null", nil
+
+	case v.typ.IsObj(), v.typ.IsArr():
+		b, err := v.MarshalJSON()
+
+		return string(b), err
+
+	default:
+		return v.SimpleString()
+	}
+}
+
+// eval is the value of the expression, the filters applied. A missing value
+// is carried through the filters, until a default fills it.
+func (e *tmplExpr) eval(
+	d Datum,
+) (Datum, error) {
+	v, err := d, error(nil)
+	if e.query != GQErr() {
+		v, err = d.Get(e.query)
+	}
+
+	missing := err != nil
+	for _, f := range e.filters {
+		switch {
+		case f.name == tmplFilterDefault:
+			if missing || v.typ.IsNil() {
+				v, missing = *f.arg, false
+			}
+
+		case missing:
+
+		case f.name == tmplFilterJson:
+			b, jErr := v.MarshalJSON()
+			if jErr != nil {
+				return OfErr(), E(jErr, newTemplateRenderError(e.spec))
+			}
+
+			v = _newDatum(Str, string(b))
+
+		default:
+			s, sErr := v.Str()
+			if sErr != nil {
+				return OfErr(), E(sErr, newTemplateRenderError(e.spec))
+			}
+
+			if f.name == tmplFilterUpper {
+				s = strings.ToUpper(s)
+			} else {
+				s = strings.ToLower(s)
+			}
+
+			v = _newDatum(Str, s)
+		}
+	}
+
+	if missing {
+		return OfErr(), E(err, newTemplateRenderError(e.spec))
+	}
+
+	return v, nil
+}
+
+// =====================================.
+
+func newTemplateInvalidError(
+	spec string,
+	reason string,
+) error {
+	return newGiraffeError(
+		ErrCodeTemplateInvalid,
+		"invalid template: "+reason+", template="+spec,
+	)
+}
+
+func newTemplateRenderError(
+	spec string,
+) error {
+	return newGiraffeError(
+		ErrCodeTemplateRender,
+		"cannot render: {{ "+spec+" }}",
+	)
+}